@@ -0,0 +1,56 @@
+package api
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/plucury/chait/api/provider"
+	"github.com/spf13/viper"
+)
+
+// ResolveModel returns what a model name stands for: the target of its alias in
+// model_aliases, written "model" or "provider:model", or the name itself. The provider
+// is empty unless the alias names one.
+func ResolveModel(name string) (providerName, model string) {
+	// Viper lowercases the keys of maps, aliases are matched regardless of case
+	target, ok := viper.GetStringMapString("model_aliases")[strings.ToLower(name)]
+	if !ok || target == "" {
+		return "", name
+	}
+	if prefix, rest, found := strings.Cut(target, ":"); found && slices.Contains(provider.GetAvailableProviderNames(), prefix) {
+		return prefix, rest
+	}
+	return "", target
+}
+
+// ModelFor resolves a model name or alias for a provider, and fails if the alias is for
+// another provider
+func ModelFor(p provider.Provider, name string) (string, error) {
+	providerName, model := ResolveModel(name)
+	if providerName != "" && providerName != p.GetName() {
+		return "", fmt.Errorf("%s is an alias of %s:%s, not a model of %s", name, providerName, model, p.GetName())
+	}
+	return model, nil
+}
+
+// SetModel sets the model of the active provider from a model name or alias, switching
+// to the provider the alias names first
+func SetModel(name string) error {
+	providerName, _ := ResolveModel(name)
+	if providerName == "" || providerName == GetActiveProviderName() {
+		return SetProviderModel(GetActiveProvider(), name)
+	}
+
+	p, exists := provider.GetProvider(providerName)
+	if !exists {
+		return fmt.Errorf("provider %s not found", providerName)
+	}
+	if !p.IsReady() {
+		return fmt.Errorf("%s is an alias for provider %s, which is not ready, please set its API key first", name, providerName)
+	}
+	if err := SetProviderModel(p, name); err != nil {
+		return err
+	}
+	return SetActiveProvider(providerName)
+}
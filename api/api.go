@@ -1,7 +1,9 @@
 package api
 
 import (
+	"context"
 	"fmt"
+	"strings"
 
 	"github.com/plucury/chait/api/provider"
 	"github.com/plucury/chait/util"
@@ -14,6 +16,36 @@ type ChatMessage = provider.ChatMessage
 // Re-export TemperaturePreset from provider package
 type TemperaturePreset = provider.TemperaturePreset
 
+// Re-export SamplingParams from provider package
+type SamplingParams = provider.SamplingParams
+
+// Re-export Usage from provider package
+type Usage = provider.Usage
+
+// Re-export SupportsSeed from provider package
+var SupportsSeed = provider.SupportsSeed
+
+// Re-export SupportsVision from provider package
+var SupportsVision = provider.SupportsVision
+
+// Re-export SupportsJSONMode from provider package
+var SupportsJSONMode = provider.SupportsJSONMode
+
+// Re-export FormatRetryStatus from provider package
+var FormatRetryStatus = provider.FormatRetryStatus
+
+// Re-export KeyStatus and its values from provider package
+type KeyStatus = provider.KeyStatus
+
+const (
+	KeyValid      = provider.KeyValid
+	KeyInvalid    = provider.KeyInvalid
+	KeyUnverified = provider.KeyUnverified
+)
+
+// Re-export ValidateAPIKey from provider package
+var ValidateAPIKey = provider.ValidateAPIKey
+
 // DefaultProvider is the default provider name
 const DefaultProvider = "deepseek"
 
@@ -39,9 +71,9 @@ func init() {
 		configProvider := viper.GetString("active_provider")
 		if _, exists := provider.GetProvider(configProvider); exists {
 			providerName = configProvider
-			util.DebugLog("Loaded active provider from config: %s", providerName)
+			util.DebugLog(util.ModuleProvider, "Loaded active provider from config: %s", providerName)
 		} else {
-			util.DebugLog("Provider from config not found: %s, using default: %s", configProvider, DefaultProvider)
+			util.DebugLog(util.ModuleProvider, "Provider from config not found: %s, using default: %s", configProvider, DefaultProvider)
 		}
 	}
 
@@ -60,17 +92,17 @@ func init() {
 }
 
 func LoadProviderConfig(providerName string, config map[string]interface{}) error {
-	util.DebugLog("Loading configuration for provider: %s", providerName)
+	util.DebugLog(util.ModuleProvider, "Loading configuration for provider: %s", providerName)
 	p, exists := provider.GetProvider(providerName)
 	if !exists {
 		return fmt.Errorf("provider %s not found", providerName)
 	}
 
 	if err := p.LoadConfig(config); err != nil {
-		util.DebugLog("Error loading configuration for provider %s: %v", providerName, err)
+		util.DebugLog(util.ModuleProvider, "Error loading configuration for provider %s: %v", providerName, err)
 		return err
 	}
-	util.DebugLog("Successfully loaded configuration for provider: %s", providerName)
+	util.DebugLog(util.ModuleProvider, "Successfully loaded configuration for provider: %s", providerName)
 	return nil
 }
 
@@ -110,39 +142,56 @@ func GetCurrentTemperaturePresets() []TemperaturePreset {
 
 // SetAPIKey sets the API key for the active provider and saves it to the configuration
 func SetAPIKey(apiKey string) error {
-	err := activeProvider.SetAPIKey(apiKey)
+	return SetProviderAPIKey(activeProvider.GetName(), apiKey)
+}
+
+// SetProviderAPIKey sets the API key of a provider and persists it to the configuration
+func SetProviderAPIKey(providerName, apiKey string) error {
+	p, exists := provider.GetProvider(providerName)
+	if !exists {
+		return fmt.Errorf("provider %s not found", providerName)
+	}
+	err := p.SetAPIKey(apiKey)
 	if err != nil {
 		return err
 	}
 
-	viper.Set(fmt.Sprintf("providers.%s.api_key", activeProvider.GetName()), apiKey)
+	viper.Set(fmt.Sprintf("providers.%s.api_key", providerName), apiKey)
 
 	// Write to the configuration file
-	if err := viper.WriteConfig(); err != nil {
-		util.DebugLog("Error persisting API key to config: %v", err)
+	if err := util.WriteConfig(); err != nil {
+		util.DebugLog(util.ModuleConfig, "Error persisting API key to config: %v", err)
 		// Don't return error as the provider was successfully set in memory
 		// Just log the error for debugging purposes
 	}
 	return nil
 }
 
-func SetActiveProvider(providerName string) error {
-	util.DebugLog("Setting active provider to: %s", providerName)
+// UseProvider switches the active provider for the current process without persisting it
+func UseProvider(providerName string) error {
 	p, exists := provider.GetProvider(providerName)
 	if !exists {
-		util.DebugLog("Provider not found: %s", providerName)
+		util.DebugLog(util.ModuleProvider, "Provider not found: %s", providerName)
 		return fmt.Errorf("provider %s not found", providerName)
 	}
 
 	activeProvider = p
-	util.DebugLog("Active provider set to: %s", providerName)
+	util.DebugLog(util.ModuleProvider, "Active provider set to: %s", providerName)
+	return nil
+}
+
+func SetActiveProvider(providerName string) error {
+	util.DebugLog(util.ModuleProvider, "Setting active provider to: %s", providerName)
+	if err := UseProvider(providerName); err != nil {
+		return err
+	}
 
 	// Persist the active provider to configuration
 	viper.Set("provider", providerName)
 
 	// Write to the configuration file
-	if err := viper.WriteConfig(); err != nil {
-		util.DebugLog("Error persisting active provider to config: %v", err)
+	if err := util.WriteConfig(); err != nil {
+		util.DebugLog(util.ModuleConfig, "Error persisting active provider to config: %v", err)
 		// Don't return error as the provider was successfully set in memory
 		// Just log the error for debugging purposes
 	}
@@ -151,14 +200,18 @@ func SetActiveProvider(providerName string) error {
 }
 
 func SetProviderModel(provider provider.Provider, model string) error {
-	err := provider.SetCurrentModel(model)
+	model, err := ModelFor(provider, model)
+	if err != nil {
+		return err
+	}
+	err = provider.SetCurrentModel(model)
 	if err != nil {
 		return fmt.Errorf("failed to set model for provider %s: %v", provider.GetName(), err)
 	}
 	viper.Set(fmt.Sprintf("providers.%s.model", provider.GetName()), model)
 	// Write to the configuration file
-	if err := viper.WriteConfig(); err != nil {
-		util.DebugLog("Error persisting active provider to config: %v", err)
+	if err := util.WriteConfig(); err != nil {
+		util.DebugLog(util.ModuleConfig, "Error persisting active provider to config: %v", err)
 		// Don't return error as the provider was successfully set in memory
 		// Just log the error for debugging purposes
 	}
@@ -172,22 +225,114 @@ func SetProviderTemperature(provider provider.Provider, temperature float64) err
 	}
 	viper.Set(fmt.Sprintf("providers.%s.temperature", provider.GetName()), temperature)
 	// Write to the configuration file
-	if err := viper.WriteConfig(); err != nil {
-		util.DebugLog("Error persisting active provider to config: %v", err)
+	if err := util.WriteConfig(); err != nil {
+		util.DebugLog(util.ModuleConfig, "Error persisting active provider to config: %v", err)
 		// Don't return error as the provider was successfully set in memory
 		// Just log the error for debugging purposes
 	}
 	return nil
 }
 
-// SendStreamingChatRequest 发送流式聊天请求到当前活跃的 provider
-// 返回一个通道，用于接收流式响应
-func SendStreamingChatRequest(messages []ChatMessage) (<-chan provider.StreamResponse, error) {
-	util.DebugLog("Sending streaming chat request to provider: %s", activeProvider.GetName())
+// SetProviderSamplingParams sets top_p and the penalties of the provider and persists them
+func SetProviderSamplingParams(provider provider.Provider, params SamplingParams) error {
+	if err := provider.SetSamplingParams(params); err != nil {
+		return fmt.Errorf("failed to set sampling parameters for provider %s: %v", provider.GetName(), err)
+	}
+	settings := map[string]float64{
+		"top_p":             params.TopP,
+		"frequency_penalty": params.FrequencyPenalty,
+		"presence_penalty":  params.PresencePenalty,
+	}
+	for key, value := range settings {
+		viper.Set(fmt.Sprintf("providers.%s.%s", provider.GetName(), key), value)
+	}
+	// Write to the configuration file
+	if err := util.WriteConfig(); err != nil {
+		util.DebugLog(util.ModuleConfig, "Error persisting sampling parameters to config: %v", err)
+	}
+	return nil
+}
+
+// SendStreamingChatRequest 发送流式聊天请求到当前活跃的 provider，失败时依次尝试 fallback 中的 provider
+// 返回一个通道，用于接收流式响应；取消 ctx 会中止请求并关闭通道
+func SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan provider.StreamResponse, error) {
+	util.DebugLog(util.ModuleProvider, "Sending streaming chat request to provider: %s", activeProvider.GetName())
 
 	// 发送流式请求
-	util.DebugLog("Sending streaming request to %s with %d messages", activeProvider.GetName(), len(messages))
-	return activeProvider.SendStreamingChatRequest(messages)
+	util.DebugLog(util.ModuleProvider, "Sending streaming request to %s with %d messages", activeProvider.GetName(), len(messages))
+	if fallbacks := GetFallbackProviders(); len(fallbacks) > 0 {
+		// Retry failed requests on the configured fallback providers
+		return streamWithFallback(ctx, append([]provider.Provider{activeProvider}, fallbacks...), messages)
+	}
+	return activeProvider.SendStreamingChatRequest(ctx, messages)
+}
+
+// SendChatRequest 发送聊天请求到当前活跃的 provider，并返回完整的响应内容
+func SendChatRequest(ctx context.Context, messages []ChatMessage) (string, error) {
+	return SendChatRequestWith(ctx, activeProvider, messages)
+}
+
+// SendChatRequestWith 发送聊天请求到指定的 provider，并返回完整的响应内容
+func SendChatRequestWith(ctx context.Context, p provider.Provider, messages []ChatMessage) (string, error) {
+	util.DebugLog(util.ModuleProvider, "Sending chat request to %s with %d messages", p.GetName(), len(messages))
+	streamChan, err := p.SendStreamingChatRequest(ctx, messages)
+	if err != nil {
+		return "", err
+	}
+
+	var fullResponse strings.Builder
+	for streamResp := range streamChan {
+		if streamResp.Error != nil {
+			return fullResponse.String(), streamResp.Error
+		}
+		fullResponse.WriteString(streamResp.Content)
+	}
+	if err := ctx.Err(); err != nil {
+		return fullResponse.String(), err
+	}
+	return fullResponse.String(), nil
+}
+
+// Embeddings computes embeddings of the inputs with the given provider, if it supports them
+func Embeddings(ctx context.Context, p provider.Provider, inputs []string, model string) ([][]float64, *Usage, error) {
+	embedder, ok := p.(provider.Embedder)
+	if !ok {
+		return nil, nil, fmt.Errorf("provider %s does not support embeddings (supported: %s)", p.GetName(), strings.Join(GetEmbeddingProviderNames(), ", "))
+	}
+	util.DebugLog(util.ModuleProvider, "Requesting embeddings of %d inputs from %s", len(inputs), p.GetName())
+	return embedder.Embeddings(ctx, inputs, model)
+}
+
+// GetEmbeddingProviderNames 返回支持 embeddings 的 provider 名称列表
+func GetEmbeddingProviderNames() []string {
+	var names []string
+	for _, p := range GetAvailableProviders() {
+		if _, ok := p.(provider.Embedder); ok {
+			names = append(names, p.GetName())
+		}
+	}
+	return names
+}
+
+// Transcribe transcribes audio with the given provider, if it supports transcription
+func Transcribe(ctx context.Context, p provider.Provider, filename string, audio []byte, model, language string) (string, error) {
+	transcriber, ok := p.(provider.Transcriber)
+	if !ok {
+		return "", fmt.Errorf("provider %s does not support transcription (supported: %s)", p.GetName(), strings.Join(GetTranscriptionProviderNames(), ", "))
+	}
+	util.DebugLog(util.ModuleProvider, "Requesting a transcription of %s from %s", filename, p.GetName())
+	return transcriber.Transcribe(ctx, filename, audio, model, language)
+}
+
+// GetTranscriptionProviderNames 返回支持语音转写的 provider 名称列表
+func GetTranscriptionProviderNames() []string {
+	var names []string
+	for _, p := range GetAvailableProviders() {
+		if _, ok := p.(provider.Transcriber); ok {
+			names = append(names, p.GetName())
+		}
+	}
+	return names
 }
 
 // GetAvailableProviders 返回所有可用的 provider 实例
@@ -209,19 +354,19 @@ func GetProvider(name string) (provider.Provider, bool) {
 
 // GetReadyProviders 返回所有就绪的 provider 列表
 func GetReadyProviders() []provider.Provider {
-	util.DebugLog("Getting list of ready providers")
+	util.DebugLog(util.ModuleProvider, "Getting list of ready providers")
 	var readyProviders []provider.Provider
 	providers := GetAvailableProviders()
 
 	for _, p := range providers {
 		if p.IsReady() {
-			util.DebugLog("Provider ready: %s", p.GetName())
+			util.DebugLog(util.ModuleProvider, "Provider ready: %s", p.GetName())
 			readyProviders = append(readyProviders, p)
 		} else {
-			util.DebugLog("Provider not ready: %s", p.GetName())
+			util.DebugLog(util.ModuleProvider, "Provider not ready: %s", p.GetName())
 		}
 	}
 
-	util.DebugLog("Found %d ready providers", len(readyProviders))
+	util.DebugLog(util.ModuleProvider, "Found %d ready providers", len(readyProviders))
 	return readyProviders
 }
@@ -0,0 +1,105 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
+)
+
+// GetFallbackProviders returns the providers configured with the "fallback" setting, in
+// order, that can take over from the active provider: the active provider itself, unknown
+// names and providers without an API key are skipped
+func GetFallbackProviders() []provider.Provider {
+	var chain []provider.Provider
+	seen := map[string]bool{activeProvider.GetName(): true}
+	for _, entry := range viper.GetStringSlice("fallback") {
+		// Accept a list as well as a comma-separated string
+		for _, name := range strings.Split(entry, ",") {
+			name = strings.TrimSpace(name)
+			if name == "" || seen[name] {
+				continue
+			}
+			seen[name] = true
+
+			p, exists := provider.GetProvider(name)
+			if !exists {
+				util.WarnLog(util.ModuleConfig, "Unknown fallback provider: %s", name)
+				continue
+			}
+			if !p.IsReady() {
+				util.DebugLog(util.ModuleProvider, "Skipping fallback provider %s without an API key", name)
+				continue
+			}
+			chain = append(chain, p)
+		}
+	}
+	return chain
+}
+
+// streamWithFallback streams the response of the first provider of the chain that does not
+// fail before answering. Requests that cannot be sent and failures worth another provider
+// (see provider.IsFallbackError) move on to the next one, announced with a status update;
+// once content has been received the response is never switched.
+func streamWithFallback(ctx context.Context, chain []provider.Provider, messages []ChatMessage) (<-chan provider.StreamResponse, error) {
+	first, err := chain[0].SendStreamingChatRequest(ctx, messages)
+	if err != nil && len(chain) == 1 {
+		return nil, err
+	}
+
+	out := make(chan provider.StreamResponse)
+	go func() {
+		defer close(out)
+
+		// send forwards a response unless the request was cancelled and nobody is reading anymore
+		send := func(r provider.StreamResponse) bool {
+			select {
+			case out <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		stream, failure := first, err
+		for i := 0; ; i++ {
+			if failure == nil {
+				started := false
+				for r := range stream {
+					if !started && r.Error != nil && i+1 < len(chain) && provider.IsFallbackError(r.Error) {
+						failure = r.Error
+						break
+					}
+					started = started || r.Content != ""
+					if !send(r) {
+						return
+					}
+				}
+				if failure == nil {
+					return
+				}
+			}
+			if i+1 == len(chain) {
+				// The request of the last provider could not be sent
+				send(provider.StreamResponse{Error: failure})
+				return
+			}
+
+			// The abandoned stream ends by itself: it fails after sending the error
+			next := chain[i+1]
+			util.WarnLog(util.ModuleProvider, "%s failed, falling back to %s: %v", chain[i].GetName(), next.GetName(), failure)
+			if !send(provider.StreamResponse{Status: fmt.Sprintf("%s failed (%v), falling back to %s", chain[i].GetName(), failure, next.GetName())}) {
+				return
+			}
+
+			stream, failure = next.SendStreamingChatRequest(ctx, messages)
+			if failure == nil && !send(provider.StreamResponse{AnsweredBy: next.GetName(), AnsweredModel: next.GetCurrentModel()}) {
+				return
+			}
+		}
+	}()
+	return out, nil
+}
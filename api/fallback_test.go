@@ -0,0 +1,76 @@
+package api
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/plucury/chait/api/provider"
+)
+
+// fakeProvider answers with a fixed content, or fails before sending the request
+type fakeProvider struct {
+	provider.Provider
+	name    string
+	content string
+	err     error
+}
+
+func (p fakeProvider) GetName() string         { return p.name }
+func (p fakeProvider) GetCurrentModel() string { return p.name + "-model" }
+
+func (p fakeProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan provider.StreamResponse, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	ch := make(chan provider.StreamResponse, 1)
+	ch <- provider.StreamResponse{Content: p.content, Done: true}
+	close(ch)
+	return ch, nil
+}
+
+func TestStreamWithFallbackRequestErrors(t *testing.T) {
+	failing := fakeProvider{name: "a", err: errors.New("no API key")}
+	tests := []struct {
+		name         string
+		chain        []provider.Provider
+		wantErr      bool
+		wantContent  string
+		wantAnswered string
+		wantStreamed error
+	}{
+		{"first answers", []provider.Provider{fakeProvider{name: "a", content: "hi"}, fakeProvider{name: "b", content: "hello"}}, false, "hi", "", nil},
+		{"first cannot send", []provider.Provider{failing, fakeProvider{name: "b", content: "hello"}}, false, "hello", "b", nil},
+		{"two cannot send", []provider.Provider{failing, fakeProvider{name: "b", err: errors.New("bad proxy")}, fakeProvider{name: "c", content: "hey"}}, false, "hey", "c", nil},
+		{"last cannot send", []provider.Provider{failing, fakeProvider{name: "b", err: errors.New("bad proxy")}}, false, "", "", errors.New("bad proxy")},
+		{"only provider cannot send", []provider.Provider{failing}, true, "", "", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stream, err := streamWithFallback(context.Background(), tt.chain, nil)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("streamWithFallback() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			var content, answered string
+			var streamed error
+			for r := range stream {
+				content += r.Content
+				if r.AnsweredBy != "" {
+					answered = r.AnsweredBy
+				}
+				if r.Error != nil {
+					streamed = r.Error
+				}
+			}
+			if content != tt.wantContent || answered != tt.wantAnswered {
+				t.Errorf("got content %q answered by %q, want %q by %q", content, answered, tt.wantContent, tt.wantAnswered)
+			}
+			if (streamed == nil) != (tt.wantStreamed == nil) || (streamed != nil && streamed.Error() != tt.wantStreamed.Error()) {
+				t.Errorf("streamed error = %v, want %v", streamed, tt.wantStreamed)
+			}
+		})
+	}
+}
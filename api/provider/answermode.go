@@ -0,0 +1,34 @@
+package provider
+
+import "github.com/plucury/chait/util"
+
+// briefMaxTokens caps the length of responses in brief mode
+const briefMaxTokens = 300
+
+// Instructions added to the system messages by the answer modes
+var answerModeInstructions = map[string]string{
+	util.AnswerModeBrief:    "Answer as briefly as possible: give the answer itself in at most three sentences, without introduction, caveats or restating the question.",
+	util.AnswerModeDetailed: "Answer in detail: explain your reasoning step by step, cover edge cases and alternatives, and include examples where they help.",
+}
+
+// withAnswerMode adds the instruction of the answer mode after the system messages starting
+// the conversation
+func withAnswerMode(messages []ChatMessage) []ChatMessage {
+	instruction, ok := answerModeInstructions[util.AnswerMode()]
+	if !ok {
+		return messages
+	}
+	return withInstruction(messages, instruction)
+}
+
+// withInstruction adds a system message after the system messages starting the conversation
+func withInstruction(messages []ChatMessage, instruction string) []ChatMessage {
+	i := 0
+	for i < len(messages) && isSystemLevel(messages[i].Role) {
+		i++
+	}
+	result := make([]ChatMessage, 0, len(messages)+1)
+	result = append(result, messages[:i]...)
+	result = append(result, ChatMessage{Role: "system", Content: instruction})
+	return append(result, messages[i:]...)
+}
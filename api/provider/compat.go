@@ -0,0 +1,358 @@
+package provider
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+const (
+	// defaultTimeout is how long to wait for a provider to start responding
+	defaultTimeout = 120 * time.Second
+	// defaultStreamIdleTimeout is how long a stream may go without receiving any data,
+	// including heartbeats, before it is considered stalled
+	defaultStreamIdleTimeout = 60 * time.Second
+)
+
+// chatCompletionRequest represents the request to an OpenAI-compatible chat completions API
+type chatCompletionRequest struct {
+	Model          string          `json:"model"`
+	Messages       []ChatMessage   `json:"messages"`
+	System         string          `json:"system,omitempty"` // System prompt for providers that take it outside the messages
+	Temperature    float64         `json:"temperature,omitempty"`
+	Stream         bool            `json:"stream,omitempty"`
+	StreamOptions  *streamOptions  `json:"stream_options,omitempty"`
+	User           string          `json:"user,omitempty"`
+	MaxTokens      int             `json:"max_tokens,omitempty"`
+	Seed           *int64          `json:"seed,omitempty"`
+	SamplingParams                 // top_p and penalties, inlined into the request
+	ResponseFormat *responseFormat `json:"response_format,omitempty"`
+
+	// Parameters of OpenAI o-series reasoning models, see applyReasoningParams
+	MaxCompletionTokens int    `json:"max_completion_tokens,omitempty"`
+	ReasoningEffort     string `json:"reasoning_effort,omitempty"`
+}
+
+// streamOptions configures what a streaming response includes
+type streamOptions struct {
+	IncludeUsage bool `json:"include_usage"`
+}
+
+// responseFormat selects the output format of an OpenAI-compatible chat completions API
+type responseFormat struct {
+	Type string `json:"type"`
+}
+
+// chatCompletionResponse represents a response (or stream chunk) from an OpenAI-compatible chat completions API
+type chatCompletionResponse struct {
+	ID      string `json:"id"`
+	Object  string `json:"object"`
+	Created int64  `json:"created"`
+	Model   string `json:"model"`
+	Choices []struct {
+		Index        int         `json:"index"`
+		Message      ChatMessage `json:"message"`
+		Delta        ChatMessage `json:"delta,omitempty"`
+		FinishReason string      `json:"finish_reason"`
+	} `json:"choices"`
+	Citations []string             `json:"citations,omitempty"`
+	Usage     *Usage               `json:"usage,omitempty"`
+	Error     *chatCompletionError `json:"error,omitempty"`
+}
+
+// chatCompletionError represents an error from an OpenAI-compatible chat completions API
+type chatCompletionError struct {
+	Message string `json:"message"`
+	Type    string `json:"type"`
+	Param   string `json:"param"`
+	Code    string `json:"code"`
+}
+
+// chatEndpoint describes an OpenAI-compatible chat completions endpoint
+type chatEndpoint struct {
+	Name      string // Display name used in logs and errors, e.g. "OpenAI"
+	URL       string
+	APIKey    string
+	UserAgent string // Custom User-Agent header, empty for the default
+	Proxy     string // Proxy URL, empty to use HTTP_PROXY/HTTPS_PROXY from the environment
+
+	ContentType string // Content type of the request body, empty for JSON
+
+	Timeout           time.Duration // Time allowed to connect and receive the response headers
+	StreamIdleTimeout time.Duration // Time allowed between two reads of the stream
+
+	// OnFinish is called with the last parsed chunk when the stream ends,
+	// its result is sent as trailing content before the stream is closed
+	OnFinish func(last *chatCompletionResponse) string
+}
+
+// clientKey identifies the settings that require a separate HTTP client
+type clientKey struct {
+	Proxy   string
+	Timeout time.Duration
+}
+
+// Shared HTTP clients, so consecutive requests reuse pooled keep-alive (HTTP/2) connections
+var (
+	clientsMu sync.Mutex
+	clients   = make(map[clientKey]*http.Client)
+)
+
+// sharedHTTPClient returns the HTTP client used to reach the endpoint, honoring its proxy setting
+// Clients are created once per proxy and timeout and shared by all providers
+func sharedHTTPClient(endpoint chatEndpoint) (*http.Client, error) {
+	key := clientKey{Proxy: endpoint.Proxy, Timeout: endpoint.Timeout}
+
+	clientsMu.Lock()
+	defer clientsMu.Unlock()
+	if client, ok := clients[key]; ok {
+		return client, nil
+	}
+
+	transport, err := util.ProxyTransport(endpoint.Proxy)
+	if err != nil {
+		return nil, err
+	}
+	transport.DialContext = (&net.Dialer{Timeout: endpoint.Timeout, KeepAlive: 30 * time.Second}).DialContext
+	transport.TLSHandshakeTimeout = endpoint.Timeout
+	transport.ResponseHeaderTimeout = endpoint.Timeout
+	transport.ForceAttemptHTTP2 = true
+	transport.MaxIdleConnsPerHost = 4
+	if endpoint.Proxy != "" {
+		proxyURL, _ := url.Parse(endpoint.Proxy)
+		util.DebugLog(util.ModuleProvider, "Using proxy %s for %s", proxyURL.Redacted(), endpoint.Name)
+	}
+
+	client := &http.Client{Transport: transport}
+	clients[key] = client
+	util.DebugLog(util.ModuleProvider, "Created shared HTTP client (proxy: %t, timeout: %s)", endpoint.Proxy != "", endpoint.Timeout)
+	return client, nil
+}
+
+// apiError is returned when a provider answers with a non-200 status
+type apiError struct {
+	StatusCode int
+	Message    string // Error message parsed from the response, empty if it could not be parsed
+	Body       string
+	RetryAfter time.Duration // Wait requested by the provider for rate limited requests, zero if unknown
+}
+
+func (e *apiError) Error() string {
+	if e.Message != "" {
+		return fmt.Sprintf("API error: %s", e.Message)
+	}
+	return fmt.Sprintf("API request failed with status %d: %s", e.StatusCode, e.Body)
+}
+
+// sendChatRequest sends a single request to the endpoint and returns the response if its status is 200
+func sendChatRequest(ctx context.Context, client *http.Client, endpoint chatEndpoint, requestJSON []byte) (*http.Response, error) {
+	// 创建 HTTP 请求
+	req, err := http.NewRequestWithContext(ctx, "POST", endpoint.URL, bytes.NewReader(requestJSON))
+	if err != nil {
+		return nil, fmt.Errorf("error creating request: %v", err)
+	}
+
+	// 设置请求头
+	contentType := endpoint.ContentType
+	if contentType == "" {
+		contentType = "application/json"
+	}
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+endpoint.APIKey)
+	if endpoint.UserAgent != "" {
+		req.Header.Set("User-Agent", endpoint.UserAgent)
+	}
+
+	// 发送请求
+	resp, err := client.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		return nil, &connectionError{Name: endpoint.Name, Err: err}
+	}
+
+	// 检查状态码
+	if resp.StatusCode != http.StatusOK {
+		// 读取错误响应
+		respBody, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+
+		// 尝试解析错误响应
+		apiErr := &apiError{StatusCode: resp.StatusCode, Body: string(respBody), RetryAfter: parseRetryAfter(resp.Header)}
+		var errorResp chatCompletionResponse
+		if err := json.Unmarshal(respBody, &errorResp); err == nil && errorResp.Error != nil {
+			apiErr.Message = errorResp.Error.Message
+		}
+		return nil, apiErr
+	}
+
+	return resp, nil
+}
+
+// streamChatCompletion sends a streaming request to an OpenAI-compatible endpoint
+// and returns a channel receiving the delta content of each chunk.
+// Transient failures are retried before the stream starts, reporting each retry as a status update.
+// Cancelling the context aborts the request and closes the channel.
+func streamChatCompletion(ctx context.Context, endpoint chatEndpoint, requestBody interface{}) (<-chan StreamResponse, error) {
+	respChan := make(chan StreamResponse)
+
+	// 将请求体转换为 JSON
+	requestJSON, err := json.Marshal(requestBody)
+	if err != nil {
+		return nil, fmt.Errorf("error marshaling request: %v", err)
+	}
+
+	client, err := sharedHTTPClient(endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	// 启动 goroutine 处理流式响应
+	go func() {
+		defer close(respChan)
+
+		// send delivers a response unless the request was cancelled and nobody is reading anymore
+		send := func(r StreamResponse) bool {
+			select {
+			case respChan <- r:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}
+
+		resp, err := withRetry(ctx, endpoint.Name, loadRetryPolicy(), func(status string, retryAt time.Time) {
+			send(StreamResponse{Status: status, RetryAt: retryAt})
+		}, func() (*http.Response, error) {
+			return sendChatRequest(ctx, client, endpoint, requestJSON)
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				util.DebugLog(util.ModuleProvider, "%s request cancelled", endpoint.Name)
+				return
+			}
+			send(StreamResponse{Error: err})
+			return
+		}
+		defer resp.Body.Close()
+
+		reader := bufio.NewReader(resp.Body)
+		var lastChunk *chatCompletionResponse
+		var usage *Usage // Reported with the last chunk, or with every chunk by some providers
+
+		// Close the body if no data arrives within the idle timeout so the blocked read returns
+		var stalled atomic.Bool
+		watchdog := time.AfterFunc(endpoint.StreamIdleTimeout, func() {
+			stalled.Store(true)
+			resp.Body.Close()
+		})
+		defer watchdog.Stop()
+
+		// finish sends the trailing content produced by OnFinish, if any
+		finish := func() {
+			if endpoint.OnFinish == nil || lastChunk == nil {
+				return
+			}
+			if trailer := endpoint.OnFinish(lastChunk); trailer != "" {
+				send(StreamResponse{Content: trailer})
+			}
+		}
+
+		for {
+			line, err := reader.ReadBytes('\n')
+			if err != nil {
+				if ctx.Err() != nil {
+					util.DebugLog(util.ModuleProvider, "%s stream cancelled", endpoint.Name)
+				} else if stalled.Load() {
+					util.WarnLog(util.ModuleProvider, "%s stream stalled for %s", endpoint.Name, endpoint.StreamIdleTimeout)
+					send(StreamResponse{Error: fmt.Errorf("%w: no data from %s API for %s", ErrStreamStalled, endpoint.Name, endpoint.StreamIdleTimeout)})
+				} else if err != io.EOF {
+					send(StreamResponse{Error: fmt.Errorf("error reading stream: %v", err)})
+				} else {
+					finish()
+					if usage != nil {
+						send(StreamResponse{Done: true, Usage: usage})
+					}
+				}
+				break
+			}
+			watchdog.Reset(endpoint.StreamIdleTimeout)
+
+			// Skip empty lines
+			line = bytes.TrimSpace(line)
+			if len(line) == 0 {
+				continue
+			}
+
+			// Skip SSE comments used as heartbeats (": ping") and non-data fields
+			if line[0] == ':' || bytes.HasPrefix(line, []byte("event:")) ||
+				bytes.HasPrefix(line, []byte("id:")) || bytes.HasPrefix(line, []byte("retry:")) {
+				if util.LogEnabled(util.LevelTrace, util.ModuleProvider) {
+					util.TraceLog(util.ModuleProvider, "%s stream keep-alive: %s", endpoint.Name, string(line))
+				}
+				continue
+			}
+
+			// Remove "data:" prefix
+			if bytes.HasPrefix(line, []byte("data:")) {
+				line = bytes.TrimSpace(bytes.TrimPrefix(line, []byte("data:")))
+			}
+
+			// Check for stream end
+			if string(line) == "[DONE]" {
+				finish()
+				send(StreamResponse{Done: true, Usage: usage})
+				break
+			}
+
+			// Skip empty JSON objects or invalid lines
+			if string(line) == "{}" || len(line) == 0 {
+				continue
+			}
+
+			// Trace log the line for troubleshooting only when trace logging is enabled
+			if util.LogEnabled(util.LevelTrace, util.ModuleProvider) {
+				util.TraceLog(util.ModuleProvider, "%s stream line: %s", endpoint.Name, string(line))
+			}
+
+			// Parse the response
+			var streamResp chatCompletionResponse
+			if err := json.Unmarshal(line, &streamResp); err != nil {
+				util.WarnLog(util.ModuleProvider, "Error parsing %s stream: %v (line: %s)", endpoint.Name, err, string(line))
+				continue // Skip this line instead of breaking
+			}
+
+			// Check for API errors
+			if streamResp.Error != nil {
+				send(StreamResponse{Error: fmt.Errorf("API error: %s", streamResp.Error.Message)})
+				break
+			}
+			if streamResp.Usage != nil {
+				usage = streamResp.Usage
+			}
+
+			// Extract content from choices
+			if len(streamResp.Choices) > 0 {
+				lastChunk = &streamResp
+				content := streamResp.Choices[0].Delta.Content
+				if content != "" && !send(StreamResponse{Content: content}) {
+					break
+				}
+			}
+		}
+	}()
+
+	return respChan, nil
+}
@@ -0,0 +1,234 @@
+package provider
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"sort"
+	"testing"
+)
+
+// registeredProviders returns the names of all registered providers in a stable order
+func registeredProviders() []string {
+	var names []string
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// otherModel returns a built-in model of the provider that is not its default,
+// or the user-defined model of the test config for providers with a single model
+func otherModel(p Provider) string {
+	for _, model := range p.GetAvailableModels() {
+		if model != p.GetDefaultModel() {
+			return model
+		}
+	}
+	return "future-model"
+}
+
+// viaJSON returns the config as it is read back from the JSON config file
+func viaJSON(t *testing.T, config map[string]interface{}) map[string]interface{} {
+	t.Helper()
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatalf("marshal config: %v", err)
+	}
+	decoded := make(map[string]interface{})
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("unmarshal config: %v", err)
+	}
+	return decoded
+}
+
+// loadSaved loads the config into a new instance of the provider and returns what it saves
+func loadSaved(t *testing.T, name string, config map[string]interface{}) (Provider, map[string]interface{}) {
+	t.Helper()
+	p := providers[name]()
+	if err := p.LoadConfig(config); err != nil {
+		t.Fatalf("LoadConfig: %v", err)
+	}
+	saved := make(map[string]interface{})
+	p.SaveConfig(saved)
+	return p, saved
+}
+
+func TestConfigRoundTrip(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          func(p Provider) map[string]interface{}
+		wantModel       func(p Provider) string
+		wantTemperature func(p Provider) float64
+	}{
+		{
+			name: "all settings",
+			config: func(p Provider) map[string]interface{} {
+				return map[string]interface{}{
+					"api_key":             "sk-test-1234567890",
+					"model":               otherModel(p),
+					"temperature":         0.5,
+					"user_agent":          "chait-test/1.0",
+					"user":                "tester",
+					"proxy":               "http://127.0.0.1:8080",
+					"extra_models":        []interface{}{"future-model"},
+					"max_tokens":          512.0,
+					"top_p":               0.9,
+					"system_role":         "developer",
+					"reasoning_effort":    "high",
+					"frequency_penalty":   -0.5,
+					"presence_penalty":    1,
+					"timeout":             30.0,
+					"stream_idle_timeout": 90.0,
+				}
+			},
+			wantModel:       func(p Provider) string { return otherModel(p) },
+			wantTemperature: func(p Provider) float64 { return 0.5 },
+		},
+		{
+			name: "integer temperature",
+			config: func(p Provider) map[string]interface{} {
+				return map[string]interface{}{"api_key": "sk-test", "temperature": 1}
+			},
+			wantModel:       func(p Provider) string { return p.GetDefaultModel() },
+			wantTemperature: func(p Provider) float64 { return 1 },
+		},
+		{
+			name: "int64 temperature and max tokens",
+			config: func(p Provider) map[string]interface{} {
+				return map[string]interface{}{"temperature": int64(0), "max_tokens": int64(100)}
+			},
+			wantModel:       func(p Provider) string { return p.GetDefaultModel() },
+			wantTemperature: func(p Provider) float64 { return 0 },
+		},
+		{
+			name: "extra model selected",
+			config: func(p Provider) map[string]interface{} {
+				return map[string]interface{}{"model": "future-model", "extra_models": "future-model, other-model"}
+			},
+			wantModel:       func(p Provider) string { return "future-model" },
+			wantTemperature: func(p Provider) float64 { return p.GetDefaultTemperature() },
+		},
+		{
+			name:            "missing keys",
+			config:          func(p Provider) map[string]interface{} { return map[string]interface{}{} },
+			wantModel:       func(p Provider) string { return p.GetDefaultModel() },
+			wantTemperature: func(p Provider) float64 { return p.GetDefaultTemperature() },
+		},
+		{
+			name: "invalid model",
+			config: func(p Provider) map[string]interface{} {
+				return map[string]interface{}{"model": "no-such-model", "temperature": 0.5}
+			},
+			wantModel:       func(p Provider) string { return p.GetDefaultModel() },
+			wantTemperature: func(p Provider) float64 { return 0.5 },
+		},
+		{
+			name: "negative temperature",
+			config: func(p Provider) map[string]interface{} {
+				return map[string]interface{}{"temperature": -1}
+			},
+			wantModel:       func(p Provider) string { return p.GetDefaultModel() },
+			wantTemperature: func(p Provider) float64 { return 0 },
+		},
+		{
+			name: "wrong value types",
+			config: func(p Provider) map[string]interface{} {
+				return map[string]interface{}{"model": 42, "temperature": "hot", "max_tokens": "many"}
+			},
+			wantModel:       func(p Provider) string { return p.GetDefaultModel() },
+			wantTemperature: func(p Provider) float64 { return p.GetDefaultTemperature() },
+		},
+	}
+
+	for _, name := range registeredProviders() {
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				fresh := providers[name]()
+				p, saved := loadSaved(t, name, tt.config(fresh))
+
+				if got, want := p.GetCurrentModel(), tt.wantModel(fresh); got != want {
+					t.Errorf("model = %q, want %q", got, want)
+				}
+				if got, want := p.GetCurrentTemperature(), tt.wantTemperature(fresh); got != want {
+					t.Errorf("temperature = %v, want %v", got, want)
+				}
+
+				// Saving what was loaded and loading it again, directly or through the
+				// JSON config file, must not change any setting
+				_, resaved := loadSaved(t, name, saved)
+				if !reflect.DeepEqual(viaJSON(t, resaved), viaJSON(t, saved)) {
+					t.Errorf("config changed after a round trip:\n got  %v\n want %v", resaved, saved)
+				}
+				_, reloaded := loadSaved(t, name, viaJSON(t, saved))
+				if !reflect.DeepEqual(viaJSON(t, reloaded), viaJSON(t, saved)) {
+					t.Errorf("config changed after a round trip through JSON:\n got  %v\n want %v", reloaded, saved)
+				}
+			})
+		}
+	}
+}
+
+func TestLoadConfigClampsTemperature(t *testing.T) {
+	tests := []struct {
+		provider string
+		temp     interface{}
+		want     float64
+	}{
+		{"openai", 1.5, 1.0},
+		{"openai", 2, 1.0},
+		{"deepseek", 3.0, 2.0},
+		{"grok", int64(5), 2.0},
+		{"groq", 2.5, 2.0},
+		{"moonshot", 1.2, 1.0},
+		{"zhipu", 7, 1.0},
+		{"perplexity", 2.0, 1.9},
+		{"perplexity", 1.95, 1.95},
+		{"together", 2.5, 2.0},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%s/%v", tt.provider, tt.temp), func(t *testing.T) {
+			p, _ := loadSaved(t, tt.provider, map[string]interface{}{"temperature": tt.temp})
+			if got := p.GetCurrentTemperature(); got != tt.want {
+				t.Errorf("temperature = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestConfigRoundTripKeepsCommonSettings(t *testing.T) {
+	config := map[string]interface{}{
+		"user_agent":          "chait-test/1.0",
+		"user":                "tester",
+		"proxy":               "socks5://127.0.0.1:1080",
+		"extra_models":        []interface{}{"future-model"},
+		"max_tokens":          256.0,
+		"reasoning_effort":    "low",
+		"timeout":             "90s",
+		"stream_idle_timeout": 45.0,
+	}
+	want := map[string]interface{}{
+		"user_agent":          "chait-test/1.0",
+		"user":                "tester",
+		"proxy":               "socks5://127.0.0.1:1080",
+		"extra_models":        []interface{}{"future-model"},
+		"max_tokens":          256.0,
+		"reasoning_effort":    "low",
+		"timeout":             90.0,
+		"stream_idle_timeout": 45.0,
+	}
+
+	for _, name := range registeredProviders() {
+		t.Run(name, func(t *testing.T) {
+			_, saved := loadSaved(t, name, config)
+			saved = viaJSON(t, saved)
+			for key, value := range want {
+				if !reflect.DeepEqual(saved[key], value) {
+					t.Errorf("%s = %#v, want %#v", key, saved[key], value)
+				}
+			}
+		})
+	}
+}
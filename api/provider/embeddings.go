@@ -0,0 +1,93 @@
+package provider
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+// Embedder is implemented by providers that can compute text embeddings
+type Embedder interface {
+	// GetDefaultEmbeddingModel returns the embedding model used when none is given
+	GetDefaultEmbeddingModel() string
+
+	// Embeddings returns one vector per input, in the order of the inputs
+	// An empty model selects the default embedding model
+	Embeddings(ctx context.Context, inputs []string, model string) ([][]float64, *Usage, error)
+}
+
+// embeddingBatchSize is the number of inputs sent in a single request
+const embeddingBatchSize = 100
+
+// embeddingRequest is the request body of an OpenAI-compatible embeddings API
+type embeddingRequest struct {
+	Model string   `json:"model"`
+	Input []string `json:"input"`
+	User  string   `json:"user,omitempty"`
+}
+
+// embeddingResponse is the response of an OpenAI-compatible embeddings API
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+	Usage *Usage `json:"usage,omitempty"`
+}
+
+// requestEmbeddings computes embeddings with an OpenAI-compatible embeddings endpoint,
+// sending the inputs in batches
+func requestEmbeddings(ctx context.Context, endpoint chatEndpoint, model, user string, inputs []string) ([][]float64, *Usage, error) {
+	client, err := sharedHTTPClient(endpoint)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	vectors := make([][]float64, 0, len(inputs))
+	usage := &Usage{}
+	for start := 0; start < len(inputs); start += embeddingBatchSize {
+		batch := inputs[start:min(start+embeddingBatchSize, len(inputs))]
+		requestJSON, err := json.Marshal(embeddingRequest{Model: model, Input: batch, User: user})
+		if err != nil {
+			return nil, nil, fmt.Errorf("error marshaling request: %v", err)
+		}
+
+		util.DebugLog(util.ModuleProvider, "Requesting %d embeddings from %s with model %s", len(batch), endpoint.Name, model)
+		resp, err := withRetry(ctx, endpoint.Name, loadRetryPolicy(), func(status string, retryAt time.Time) {
+			util.InfoLog(util.ModuleProvider, "%s", FormatRetryStatus(status, retryAt))
+		}, func() (*http.Response, error) {
+			return sendChatRequest(ctx, client, endpoint, requestJSON)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var response embeddingResponse
+		err = json.NewDecoder(resp.Body).Decode(&response)
+		resp.Body.Close()
+		if err != nil {
+			return nil, nil, fmt.Errorf("error decoding %s embeddings: %v", endpoint.Name, err)
+		}
+		if len(response.Data) != len(batch) {
+			return nil, nil, fmt.Errorf("%s returned %d embeddings for %d inputs", endpoint.Name, len(response.Data), len(batch))
+		}
+
+		// The API may return the vectors in any order
+		sort.Slice(response.Data, func(i, j int) bool {
+			return response.Data[i].Index < response.Data[j].Index
+		})
+		for _, d := range response.Data {
+			vectors = append(vectors, d.Embedding)
+		}
+		if response.Usage != nil {
+			usage.PromptTokens += response.Usage.PromptTokens
+			usage.TotalTokens += response.Usage.TotalTokens
+		}
+	}
+	return vectors, usage, nil
+}
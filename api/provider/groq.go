@@ -0,0 +1,207 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plucury/chait/util"
+)
+
+// GroqProvider implements the Provider interface for Groq API
+type GroqProvider struct {
+	BaseProvider // 嵌入基础提供者结构体
+}
+
+const (
+	groqAPIURL              = "https://api.groq.com/openai/v1/chat/completions"
+	groqTranscriptionsURL   = "https://api.groq.com/openai/v1/audio/transcriptions"
+	groqDefaultWhisperModel = "whisper-large-v3-turbo"
+	groqDefaultModel        = "llama-3.3-70b-versatile"
+	groqDefaultTemperature  = 1.0 // Default temperature as per Groq API documentation
+	groqMaxTemperature      = 2.0
+)
+
+// Available models for Groq API
+var groqAvailableModels = []string{
+	"llama-3.3-70b-versatile",
+	"llama-3.1-8b-instant",
+	"gemma2-9b-it",
+	"deepseek-r1-distill-llama-70b",
+}
+
+// Available temperature presets for Groq API
+var groqTemperaturePresets = []TemperaturePreset{
+	{"Focused", 0.2, "More focused and deterministic responses for specific tasks"},
+	{"Balanced Low", 0.5, "Good balance with slight focus on determinism"},
+	{"Balanced", 1.0, "Default balance between randomness and determinism"},
+	{"Creative", 1.5, "More random and creative responses"},
+	{"Highly Creative", 2.0, "Maximum randomness for highly varied outputs"},
+}
+
+// NewGroqProvider creates a new instance of GroqProvider
+func NewGroqProvider() Provider {
+	provider := &GroqProvider{
+		BaseProvider: BaseProvider{
+			Name:               "groq",
+			CurrentModel:       groqDefaultModel,
+			CurrentTemperature: groqDefaultTemperature,
+		},
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *GroqProvider) GetName() string {
+	return p.Name
+}
+
+// GetDefaultModel returns the default model for this provider
+func (p *GroqProvider) GetDefaultModel() string {
+	return groqDefaultModel
+}
+
+// GetAvailableModels returns the list of available models for this provider
+func (p *GroqProvider) GetAvailableModels() []string {
+	return p.withExtraModels(groqAvailableModels)
+}
+
+// GetDefaultTemperature returns the default temperature for this provider
+func (p *GroqProvider) GetDefaultTemperature() float64 {
+	return groqDefaultTemperature
+}
+
+// GetTemperaturePresets returns the available temperature presets for this provider
+func (p *GroqProvider) GetTemperaturePresets() []TemperaturePreset {
+	return groqTemperaturePresets
+}
+
+// SetCurrentTemperature sets the current temperature with Groq-specific validation
+func (p *GroqProvider) SetCurrentTemperature(temp float64) error {
+	// Validate temperature range specific to Groq (0-2)
+	if temp < 0 || temp > groqMaxTemperature {
+		return fmt.Errorf("Groq temperature must be between 0.0 and 2.0. Higher values like 0.8 will make the output more random, while lower values like 0.2 will make it more focused and deterministic")
+	}
+
+	p.CurrentTemperature = temp
+	return nil
+}
+
+// SendStreamingChatRequest sends a streaming chat request to the Groq API
+func (p *GroqProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("API key not set for Groq provider")
+	}
+
+	// 转换系统消息
+	messages, systemPrompt := p.prepareMessages(messages)
+
+	// 创建请求体
+	requestBody := chatCompletionRequest{
+		Model:          p.CurrentModel,
+		Messages:       messages,
+		System:         systemPrompt,
+		Temperature:    p.CurrentTemperature,
+		Stream:         true,
+		StreamOptions:  p.streamOptions(),
+		User:           p.metadataUser(),
+		MaxTokens:      p.maxTokens(),
+		Seed:           p.seed(),
+		SamplingParams: p.Sampling,
+		ResponseFormat: p.responseFormat(),
+	}
+
+	util.DebugLog(util.ModuleProvider, "Using Groq model: %s (streaming)", p.CurrentModel)
+	util.DebugLog(util.ModuleProvider, "Using temperature: %.1f", p.CurrentTemperature)
+
+	return streamChatCompletion(ctx, p.newChatEndpoint("Groq", groqAPIURL), requestBody)
+}
+
+// GetDefaultTranscriptionModel returns the transcription model used when none is given
+func (p *GroqProvider) GetDefaultTranscriptionModel() string {
+	return groqDefaultWhisperModel
+}
+
+// Transcribe transcribes audio with the Whisper models hosted by Groq
+func (p *GroqProvider) Transcribe(ctx context.Context, filename string, audio []byte, model, language string) (string, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return "", fmt.Errorf("API key not set for Groq provider")
+	}
+	if model == "" {
+		model = groqDefaultWhisperModel
+	}
+	return requestTranscription(ctx, p.newChatEndpoint("Groq", groqTranscriptionsURL), filename, audio, model, language)
+}
+
+// SetCurrentModel sets the current model after validating it
+func (p *GroqProvider) SetCurrentModel(model string) error {
+	// 验证模型是否有效
+	valid := false
+	for _, m := range p.GetAvailableModels() {
+		if m == model {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		util.WarnLog(util.ModuleProvider, "Invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+		return fmt.Errorf("invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+	}
+
+	// 设置模型并输出调试信息
+	p.CurrentModel = model
+	util.DebugLog(util.ModuleProvider, "Groq model set to: %s", model)
+	return nil
+}
+
+// LoadConfig loads the provider configuration from the given map
+func (p *GroqProvider) LoadConfig(config map[string]interface{}) error {
+	p.loadCommonConfig(config)
+
+	// 加载 API Key
+	if apiKey, ok := config["api_key"].(string); ok {
+		p.APIKey = apiKey
+		util.DebugLog(util.ModuleProvider, "Loaded API key for Groq provider")
+	}
+
+	// 加载当前模型
+	if model, ok := config["model"].(string); ok {
+		util.DebugLog(util.ModuleProvider, "Found model in config: %s", model)
+		if err := p.SetCurrentModel(model); err != nil {
+			// 如果模型无效，使用默认模型
+			util.WarnLog(util.ModuleProvider, "Invalid model in config, using default model: %s", groqDefaultModel)
+			p.CurrentModel = groqDefaultModel
+		}
+	} else {
+		// 如果没有设置模型，使用默认模型
+		util.DebugLog(util.ModuleProvider, "No model found in config, using default model: %s", groqDefaultModel)
+		p.CurrentModel = groqDefaultModel
+	}
+
+	// 加载温度设置
+	loadTemperature(p, config, groqMaxTemperature)
+
+	return nil
+}
+
+// SaveConfig saves the provider configuration to the given map
+func (p *GroqProvider) SaveConfig(config map[string]interface{}) {
+	config["api_key"] = p.APIKey
+	config["model"] = p.CurrentModel
+	config["temperature"] = p.CurrentTemperature
+
+	p.saveCommonConfig(config)
+}
+
+// IsReady returns whether the provider is ready to use
+// For Groq, the provider is ready if the API key is set
+func (p *GroqProvider) IsReady() bool {
+	return p.APIKey != ""
+}
+
+// Register the provider
+func init() {
+	Register("groq", NewGroqProvider)
+}
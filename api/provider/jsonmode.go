@@ -0,0 +1,26 @@
+package provider
+
+import (
+	"strings"
+
+	"github.com/plucury/chait/util"
+)
+
+// jsonInstruction asks for a JSON object in JSON mode. OpenAI rejects the json_object
+// response format unless the messages mention JSON, and models without the response
+// format only have this instruction to go by.
+const jsonInstruction = "Respond with a single valid JSON object and nothing else."
+
+// withJSONInstruction adds the JSON mode instruction after the system messages starting the
+// conversation, unless JSON mode is disabled or the messages already mention JSON
+func withJSONInstruction(messages []ChatMessage) []ChatMessage {
+	if !util.IsJSONMode() {
+		return messages
+	}
+	for _, m := range messages {
+		if strings.Contains(strings.ToLower(m.Content), "json") {
+			return messages
+		}
+	}
+	return withInstruction(messages, jsonInstruction)
+}
@@ -0,0 +1,74 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// KeyStatus is the result of checking an API key
+type KeyStatus int
+
+const (
+	KeyValid      KeyStatus = iota // The provider accepted the key
+	KeyInvalid                     // The provider rejected the key
+	KeyUnverified                  // The key could not be checked, e.g. because of a network problem
+)
+
+// ValidateAPIKey checks an API key with a minimal request (a one-token completion) to the
+// provider, using a separate instance so the provider itself is left unchanged.
+// The returned error explains an invalid or unverified key, or what the provider reported
+// about a key it accepted (e.g. an exhausted balance).
+func ValidateAPIKey(ctx context.Context, p Provider, apiKey string) (KeyStatus, error) {
+	factory, ok := providers[p.GetName()]
+	if !ok {
+		return KeyUnverified, fmt.Errorf("provider %s not found", p.GetName())
+	}
+
+	// 使用当前配置和新的 API Key 创建临时实例
+	config := make(map[string]interface{})
+	p.SaveConfig(config)
+	config["api_key"] = apiKey
+	config["max_tokens"] = 1
+	check := factory()
+	if err := check.LoadConfig(config); err != nil {
+		return KeyUnverified, err
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel() // Stop the response after its first chunk
+	stream, err := check.SendStreamingChatRequest(ctx, []ChatMessage{{Role: "user", Content: "ping"}})
+	if err != nil {
+		return KeyUnverified, err
+	}
+	for resp := range stream {
+		if resp.Error != nil {
+			return classifyKeyError(resp.Error)
+		}
+		if resp.Status == "" {
+			return KeyValid, nil
+		}
+	}
+
+	// The stream is closed without an error when the context ends
+	if err := ctx.Err(); err != nil {
+		return KeyUnverified, fmt.Errorf("no answer from %s: %v", p.GetName(), err)
+	}
+	return KeyValid, nil
+}
+
+// classifyKeyError tells a rejected key from failures that say nothing about the key
+func classifyKeyError(err error) (KeyStatus, error) {
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		if apiErr.StatusCode == http.StatusUnauthorized || apiErr.StatusCode == http.StatusForbidden {
+			return KeyInvalid, err
+		}
+		if apiErr.StatusCode < 500 && apiErr.StatusCode != http.StatusTooManyRequests {
+			// The request was authenticated before it was refused
+			return KeyValid, err
+		}
+	}
+	return KeyUnverified, err
+}
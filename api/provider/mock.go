@@ -0,0 +1,173 @@
+//go:build e2e
+
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+// MockProvider answers without a network connection, for the end-to-end tests. It is only
+// compiled into binaries built with -tags e2e.
+type MockProvider struct {
+	BaseProvider               // 嵌入基础提供者结构体
+	Delay        time.Duration // Pause before each streamed word
+}
+
+const (
+	mockDefaultModel       = "echo"
+	mockDefaultTemperature = 1.0
+	mockMaxTemperature     = 2.0
+)
+
+// Available models for the mock provider
+var mockAvailableModels = []string{
+	"echo",
+}
+
+// Available temperature presets for the mock provider
+var mockTemperaturePresets = []TemperaturePreset{
+	{"Focused", 0.2, "More focused and deterministic responses"},
+	{"Balanced", 1.0, "Default balance between randomness and determinism"},
+}
+
+// NewMockProvider creates a new instance of MockProvider
+func NewMockProvider() Provider {
+	return &MockProvider{
+		BaseProvider: BaseProvider{
+			Name:               "mock",
+			CurrentModel:       mockDefaultModel,
+			CurrentTemperature: mockDefaultTemperature,
+		},
+	}
+}
+
+// GetName returns the name of the provider
+func (p *MockProvider) GetName() string {
+	return p.Name
+}
+
+// GetDefaultModel returns the default model for this provider
+func (p *MockProvider) GetDefaultModel() string {
+	return mockDefaultModel
+}
+
+// GetAvailableModels returns the list of available models for this provider
+func (p *MockProvider) GetAvailableModels() []string {
+	return p.withExtraModels(mockAvailableModels)
+}
+
+// GetDefaultTemperature returns the default temperature for this provider
+func (p *MockProvider) GetDefaultTemperature() float64 {
+	return mockDefaultTemperature
+}
+
+// GetTemperaturePresets returns the available temperature presets for this provider
+func (p *MockProvider) GetTemperaturePresets() []TemperaturePreset {
+	return mockTemperaturePresets
+}
+
+// SetCurrentTemperature sets the current temperature
+func (p *MockProvider) SetCurrentTemperature(temp float64) error {
+	if temp < 0 || temp > mockMaxTemperature {
+		return fmt.Errorf("mock temperature must be between 0.0 and 2.0")
+	}
+	p.CurrentTemperature = temp
+	return nil
+}
+
+// SendStreamingChatRequest streams "Echo: " followed by the last user message word by word,
+// pausing Delay before each word so that tests can cancel a response while it streams
+func (p *MockProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("API key not set for mock provider")
+	}
+
+	prompt := ""
+	for _, m := range messages {
+		if m.Role == "user" {
+			prompt = m.Content
+		}
+	}
+	words := strings.Fields("Echo: " + prompt)
+
+	util.DebugLog(util.ModuleProvider, "Using mock model: %s (streaming)", p.CurrentModel)
+
+	responseChan := make(chan StreamResponse)
+	go func() {
+		defer close(responseChan)
+		for i, word := range words {
+			select {
+			case <-ctx.Done():
+				responseChan <- StreamResponse{Error: ctx.Err(), Done: true}
+				return
+			case <-time.After(p.Delay):
+			}
+			if i > 0 {
+				word = " " + word
+			}
+			responseChan <- StreamResponse{Content: word}
+		}
+		responseChan <- StreamResponse{
+			Done:  true,
+			Usage: &Usage{PromptTokens: len(strings.Fields(prompt)), CompletionTokens: len(words), TotalTokens: len(strings.Fields(prompt)) + len(words)},
+		}
+	}()
+	return responseChan, nil
+}
+
+// SetCurrentModel sets the current model after validating it
+func (p *MockProvider) SetCurrentModel(model string) error {
+	for _, m := range p.GetAvailableModels() {
+		if m == model {
+			p.CurrentModel = model
+			return nil
+		}
+	}
+	return fmt.Errorf("invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+}
+
+// LoadConfig loads the provider configuration from the given map, with the pause before
+// each streamed word in delay_ms
+func (p *MockProvider) LoadConfig(config map[string]interface{}) error {
+	p.loadCommonConfig(config)
+
+	if apiKey, ok := config["api_key"].(string); ok {
+		p.APIKey = apiKey
+	}
+	if model, ok := config["model"].(string); ok {
+		if err := p.SetCurrentModel(model); err != nil {
+			p.CurrentModel = mockDefaultModel
+		}
+	}
+	p.Delay = time.Duration(configInt(config, "delay_ms")) * time.Millisecond
+	loadTemperature(p, config, mockMaxTemperature)
+	return nil
+}
+
+// SaveConfig saves the provider configuration to the given map
+func (p *MockProvider) SaveConfig(config map[string]interface{}) {
+	config["api_key"] = p.APIKey
+	config["model"] = p.CurrentModel
+	config["temperature"] = p.CurrentTemperature
+	if p.Delay > 0 {
+		config["delay_ms"] = int(p.Delay / time.Millisecond)
+	}
+
+	p.saveCommonConfig(config)
+}
+
+// IsReady returns whether the provider is ready to use
+func (p *MockProvider) IsReady() bool {
+	return p.APIKey != ""
+}
+
+// Register the provider
+func init() {
+	Register("mock", NewMockProvider)
+}
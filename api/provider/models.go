@@ -0,0 +1,99 @@
+package provider
+
+import "strings"
+
+// Model families used to select family-specific behavior such as tokenizers
+const (
+	FamilyOpenAI   = "openai"
+	FamilyDeepseek = "deepseek"
+	FamilyClaude   = "claude"
+	FamilyLlama    = "llama"
+	FamilyOther    = "other"
+)
+
+// ModelCapabilities describes what chait knows about a model
+type ModelCapabilities struct {
+	Family     string // Model family, one of the Family constants
+	Tokenizer  string // Name of the tokenizer used to estimate tokens, see the tokens package
+	SystemRole string // How the model expects the system prompt, one of the SystemRole constants
+	Vision     bool   // Whether the model accepts images in user messages
+	Reasoning  bool   // OpenAI o-series model: takes max_completion_tokens and reasoning_effort, no temperature
+	JSONMode   bool   // Whether the model accepts the json_object response format
+}
+
+// modelRule maps models whose normalized name starts with Prefix to their capabilities
+type modelRule struct {
+	Prefix       string
+	Capabilities ModelCapabilities
+}
+
+// Model capability registry, checked in order so more specific prefixes must come first
+var modelRules = []modelRule{
+	{"gpt-4o", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleSystem, true, false, true}},
+	{"gpt-4.1", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleSystem, true, false, true}},
+	{"gpt-4.5", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleSystem, true, false, true}},
+	{"chatgpt-4o", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleSystem, true, false, true}},
+	{"o1-mini", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleUser, false, true, false}},    // Accepts neither system nor developer messages
+	{"o1-preview", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleUser, false, true, false}}, // Accepts neither system nor developer messages
+	{"o1", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleDeveloper, true, true, true}},
+	{"o3-mini", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleDeveloper, false, true, true}},
+	{"o3", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleDeveloper, true, true, true}},
+	{"o4", ModelCapabilities{FamilyOpenAI, "o200k_base", SystemRoleDeveloper, true, true, true}},
+	{"gpt-4-turbo", ModelCapabilities{FamilyOpenAI, "cl100k_base", SystemRoleSystem, true, false, true}},
+	{"gpt-4", ModelCapabilities{FamilyOpenAI, "cl100k_base", SystemRoleSystem, false, false, false}},
+	{"gpt-3.5", ModelCapabilities{FamilyOpenAI, "cl100k_base", SystemRoleSystem, false, false, true}},
+	{"deepseek-reasoner", ModelCapabilities{FamilyDeepseek, "deepseek", SystemRoleSystem, false, false, false}},
+	{"deepseek-r1", ModelCapabilities{FamilyDeepseek, "deepseek", SystemRoleSystem, false, false, false}},
+	{"deepseek", ModelCapabilities{FamilyDeepseek, "deepseek", SystemRoleSystem, false, false, true}},
+	{"claude", ModelCapabilities{FamilyClaude, "claude", SystemRoleTopLevel, true, false, false}},
+	{"gemini", ModelCapabilities{FamilyOther, "default", SystemRoleSystem, true, false, true}},
+	{"glm-4v", ModelCapabilities{FamilyOther, "default", SystemRoleSystem, true, false, false}},
+	{"glm-4", ModelCapabilities{FamilyOther, "default", SystemRoleSystem, false, false, true}},
+	{"grok", ModelCapabilities{FamilyOther, "default", SystemRoleSystem, false, false, true}},
+	{"moonshot", ModelCapabilities{FamilyOther, "default", SystemRoleSystem, false, false, true}},
+	{"kimi", ModelCapabilities{FamilyOther, "default", SystemRoleSystem, false, false, true}},
+	{"llama", ModelCapabilities{FamilyLlama, "llama", SystemRoleSystem, false, false, true}},
+	{"meta-llama", ModelCapabilities{FamilyLlama, "llama", SystemRoleSystem, false, false, true}},
+}
+
+// LookupModel returns the capabilities of a model
+// Organization prefixes such as "meta-llama/" are ignored, fine-tuned OpenAI models get the
+// capabilities of their base model and unknown models get the FamilyOther defaults
+func LookupModel(model string) ModelCapabilities {
+	name := strings.ToLower(baseModel(model))
+	if i := strings.LastIndex(name, "/"); i >= 0 {
+		name = name[i+1:]
+	}
+
+	// Vision variants, e.g. "llama-3.2-11b-vision" or "grok-2-vision", accept images
+	vision := strings.Contains(name, "vision")
+	for _, rule := range modelRules {
+		if strings.HasPrefix(name, rule.Prefix) {
+			capabilities := rule.Capabilities
+			capabilities.Vision = capabilities.Vision || vision
+			return capabilities
+		}
+	}
+	return ModelCapabilities{Family: FamilyOther, Tokenizer: "default", SystemRole: SystemRoleSystem, Vision: vision}
+}
+
+// baseModel returns the model a fine-tuned OpenAI model is based on, e.g. "gpt-4o-mini-2024-07-18"
+// for "ft:gpt-4o-mini-2024-07-18:my-org::abc123", and other models unchanged
+func baseModel(model string) string {
+	rest, ok := strings.CutPrefix(model, "ft:")
+	if !ok {
+		return model
+	}
+	base, _, _ := strings.Cut(rest, ":")
+	return base
+}
+
+// SupportsVision returns true if the model accepts images in user messages
+func SupportsVision(model string) bool {
+	return LookupModel(model).Vision
+}
+
+// SupportsJSONMode returns true if the model accepts the json_object response format
+func SupportsJSONMode(model string) bool {
+	return LookupModel(model).JSONMode
+}
@@ -0,0 +1,190 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plucury/chait/util"
+)
+
+// MoonshotProvider implements the Provider interface for Moonshot API
+type MoonshotProvider struct {
+	BaseProvider // 嵌入基础提供者结构体
+}
+
+const (
+	moonshotAPIURL             = "https://api.moonshot.cn/v1/chat/completions"
+	moonshotDefaultModel       = "moonshot-v1-8k"
+	moonshotDefaultTemperature = 0.3 // Default temperature recommended by Moonshot
+	moonshotMaxTemperature     = 1.0
+)
+
+// Available models for Moonshot API
+var moonshotAvailableModels = []string{
+	"moonshot-v1-8k",   // Kimi with 8k context
+	"moonshot-v1-32k",  // Kimi with 32k context
+	"moonshot-v1-128k", // Kimi with 128k context
+	"kimi-latest",      // Latest Kimi model used by the Kimi app
+}
+
+// Available temperature presets for Moonshot API
+var moonshotTemperaturePresets = []TemperaturePreset{
+	{"Precise", 0.0, "Highly deterministic responses for factual queries"},
+	{"Balanced", 0.3, "Default balance recommended by Moonshot"},
+	{"Conversational", 0.6, "General conversation"},
+	{"Creative", 1.0, "Creative writing or brainstorming"},
+}
+
+// NewMoonshotProvider creates a new instance of MoonshotProvider
+func NewMoonshotProvider() Provider {
+	provider := &MoonshotProvider{
+		BaseProvider: BaseProvider{
+			Name:               "moonshot",
+			CurrentModel:       moonshotDefaultModel,
+			CurrentTemperature: moonshotDefaultTemperature,
+		},
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *MoonshotProvider) GetName() string {
+	return p.Name
+}
+
+// GetDefaultModel returns the default model for this provider
+func (p *MoonshotProvider) GetDefaultModel() string {
+	return moonshotDefaultModel
+}
+
+// GetAvailableModels returns the list of available models for this provider
+func (p *MoonshotProvider) GetAvailableModels() []string {
+	return p.withExtraModels(moonshotAvailableModels)
+}
+
+// GetDefaultTemperature returns the default temperature for this provider
+func (p *MoonshotProvider) GetDefaultTemperature() float64 {
+	return moonshotDefaultTemperature
+}
+
+// GetTemperaturePresets returns the available temperature presets for this provider
+func (p *MoonshotProvider) GetTemperaturePresets() []TemperaturePreset {
+	return moonshotTemperaturePresets
+}
+
+// SetCurrentTemperature sets the current temperature with Moonshot-specific validation
+func (p *MoonshotProvider) SetCurrentTemperature(temp float64) error {
+	// Validate temperature range specific to Moonshot (0-1)
+	if temp < 0 || temp > moonshotMaxTemperature {
+		return fmt.Errorf("Moonshot temperature must be between 0.0 and 1.0")
+	}
+
+	p.CurrentTemperature = temp
+	return nil
+}
+
+// SendStreamingChatRequest sends a streaming chat request to the Moonshot API
+func (p *MoonshotProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("API key not set for Moonshot provider")
+	}
+
+	// 转换系统消息
+	messages, systemPrompt := p.prepareMessages(messages)
+
+	// 创建请求体
+	requestBody := chatCompletionRequest{
+		Model:          p.CurrentModel,
+		Messages:       messages,
+		System:         systemPrompt,
+		Temperature:    p.CurrentTemperature,
+		Stream:         true,
+		StreamOptions:  p.streamOptions(),
+		User:           p.metadataUser(),
+		MaxTokens:      p.maxTokens(),
+		Seed:           p.seed(),
+		SamplingParams: p.Sampling,
+		ResponseFormat: p.responseFormat(),
+	}
+
+	util.DebugLog(util.ModuleProvider, "Using Moonshot model: %s (streaming)", p.CurrentModel)
+	util.DebugLog(util.ModuleProvider, "Using temperature: %.1f", p.CurrentTemperature)
+
+	return streamChatCompletion(ctx, p.newChatEndpoint("Moonshot", moonshotAPIURL), requestBody)
+}
+
+// SetCurrentModel sets the current model after validating it
+func (p *MoonshotProvider) SetCurrentModel(model string) error {
+	// 验证模型是否有效
+	valid := false
+	for _, m := range p.GetAvailableModels() {
+		if m == model {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+	}
+
+	p.CurrentModel = model
+	util.DebugLog(util.ModuleProvider, "Moonshot model set to: %s", model)
+	return nil
+}
+
+// LoadConfig loads the provider configuration from the given map
+func (p *MoonshotProvider) LoadConfig(config map[string]interface{}) error {
+	p.loadCommonConfig(config)
+
+	// 加载 API Key
+	if apiKey, ok := config["api_key"].(string); ok {
+		p.APIKey = apiKey
+		util.DebugLog(util.ModuleProvider, "Loaded API key for Moonshot provider")
+	}
+
+	// 加载当前模型
+	if model, ok := config["model"].(string); ok {
+		util.DebugLog(util.ModuleProvider, "Found model in config: %s", model)
+		if err := p.SetCurrentModel(model); err != nil {
+			// 如果模型无效，使用默认模型
+			p.CurrentModel = moonshotDefaultModel
+		}
+	} else {
+		// 如果没有设置模型，使用默认模型
+		util.DebugLog(util.ModuleProvider, "No model found in config, using default model: %s", moonshotDefaultModel)
+		p.CurrentModel = moonshotDefaultModel
+	}
+
+	// 加载温度设置
+	loadTemperature(p, config, moonshotMaxTemperature)
+
+	return nil
+}
+
+// SaveConfig saves the provider configuration to the given map
+func (p *MoonshotProvider) SaveConfig(config map[string]interface{}) {
+	// 保存 API Key
+	config["api_key"] = p.APIKey
+
+	// 保存当前模型
+	config["model"] = p.CurrentModel
+	util.DebugLog(util.ModuleProvider, "Saving Moonshot model to config: %s", p.CurrentModel)
+
+	// 保存温度设置
+	config["temperature"] = p.CurrentTemperature
+
+	p.saveCommonConfig(config)
+}
+
+// IsReady returns whether the provider is ready to use
+// For Moonshot, the provider is ready if the API key is set
+func (p *MoonshotProvider) IsReady() bool {
+	return p.APIKey != ""
+}
+
+func init() {
+	// Register the Moonshot provider
+	Register("moonshot", NewMoonshotProvider)
+}
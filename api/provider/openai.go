@@ -1,12 +1,8 @@
 package provider
 
 import (
-	"bufio"
-	"bytes"
-	"encoding/json"
+	"context"
 	"fmt"
-	"io"
-	"net/http"
 
 	"github.com/plucury/chait/util"
 )
@@ -17,9 +13,14 @@ type OpenAIProvider struct {
 }
 
 const (
-	openaiAPIURL             = "https://api.openai.com/v1/chat/completions"
-	openaiDefaultModel       = "gpt-4o"
-	openaiDefaultTemperature = 1.0
+	openaiAPIURL                = "https://api.openai.com/v1/chat/completions"
+	openaiEmbeddingsURL         = "https://api.openai.com/v1/embeddings"
+	openaiDefaultEmbeddingModel = "text-embedding-3-small"
+	openaiTranscriptionsURL     = "https://api.openai.com/v1/audio/transcriptions"
+	openaiDefaultWhisperModel   = "whisper-1"
+	openaiDefaultModel          = "gpt-4o"
+	openaiDefaultTemperature    = 1.0
+	openaiMaxTemperature        = 1.0
 )
 
 // Available models for OpenAI API
@@ -64,7 +65,7 @@ func (p *OpenAIProvider) GetDefaultModel() string {
 
 // GetAvailableModels returns the list of available models for this provider
 func (p *OpenAIProvider) GetAvailableModels() []string {
-	return openaiAvailableModels
+	return p.withExtraModels(openaiAvailableModels)
 }
 
 // GetDefaultTemperature returns the default temperature for this provider
@@ -80,7 +81,7 @@ func (p *OpenAIProvider) GetTemperaturePresets() []TemperaturePreset {
 // SetCurrentTemperature sets the current temperature with OpenAI-specific validation
 func (p *OpenAIProvider) SetCurrentTemperature(temp float64) error {
 	// Validate temperature range specific to OpenAI (0-1)
-	if temp < 0 || temp > 1.0 {
+	if temp < 0 || temp > openaiMaxTemperature {
 		return fmt.Errorf("OpenAI temperature must be between 0.0 and 1.0")
 	}
 
@@ -88,41 +89,8 @@ func (p *OpenAIProvider) SetCurrentTemperature(temp float64) error {
 	return nil
 }
 
-// chatRequest represents the request to the OpenAI chat API
-type openaiChatRequest struct {
-	Model       string        `json:"model"`
-	Messages    []ChatMessage `json:"messages"`
-	Temperature float64       `json:"temperature,omitempty"`
-	Stream      bool          `json:"stream,omitempty"`
-}
-
-// chatResponse represents the response from the OpenAI chat API
-type openaiChatResponse struct {
-	ID      string `json:"id"`
-	Object  string `json:"object"`
-	Created int64  `json:"created"`
-	Model   string `json:"model"`
-	Choices []struct {
-		Index        int         `json:"index"`
-		Message      ChatMessage `json:"message"`
-		Delta        ChatMessage `json:"delta,omitempty"`
-		FinishReason string      `json:"finish_reason"`
-	} `json:"choices"`
-	Error *openaiErrorResponse `json:"error,omitempty"`
-}
-
-// errorResponse represents an error from the OpenAI API
-type openaiErrorResponse struct {
-	Message string `json:"message"`
-	Type    string `json:"type"`
-	Param   string `json:"param"`
-	Code    string `json:"code"`
-}
-
 // SendStreamingChatRequest sends a streaming chat request to the OpenAI API
-func (p *OpenAIProvider) SendStreamingChatRequest(messages []ChatMessage) (<-chan StreamResponse, error) {
-	respChan := make(chan StreamResponse)
-
+func (p *OpenAIProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error) {
 	// 检查 API Key 是否已设置
 	if p.APIKey == "" {
 		return nil, fmt.Errorf("API key not set for OpenAI provider")
@@ -131,141 +99,79 @@ func (p *OpenAIProvider) SendStreamingChatRequest(messages []ChatMessage) (<-cha
 	// 确保模型已设置，如果未设置则使用默认模型
 	if p.CurrentModel == "" {
 		p.CurrentModel = openaiDefaultModel
-		fmt.Printf("WARNING: Model not set for OpenAI provider, using default model: %s\n", openaiDefaultModel)
+		util.WarnLog(util.ModuleProvider, "Model not set for OpenAI provider, using default model: %s", openaiDefaultModel)
 	}
 
 	// 输出调试信息
-	util.DebugLog("Using OpenAI model: %s (streaming)", p.CurrentModel)
+	util.DebugLog(util.ModuleProvider, "Using OpenAI model: %s (streaming)", p.CurrentModel)
 
-	// 创建请求体
-	requestBody := openaiChatRequest{
-		Model:    p.CurrentModel,
-		Messages: messages,
-		Stream:   true,
-	}
+	// 转换系统消息
+	messages, systemPrompt := p.prepareMessages(messages)
 
-	// Only set temperature for models that support it
-	if p.CurrentModel != "o1" && p.CurrentModel != "o3-mini" {
-		requestBody.Temperature = p.CurrentTemperature
-		util.DebugLog("Using temperature: %.1f", p.CurrentTemperature)
+	// 创建请求体
+	requestBody := chatCompletionRequest{
+		Model:          p.CurrentModel,
+		Messages:       messages,
+		System:         systemPrompt,
+		Stream:         true,
+		StreamOptions:  p.streamOptions(),
+		User:           p.metadataUser(),
+		MaxTokens:      p.maxTokens(),
+		Seed:           p.seed(),
+		SamplingParams: p.Sampling,
+		ResponseFormat: p.responseFormat(),
+	}
+
+	// o-series models take different parameters and no temperature
+	if p.isReasoningModel() {
+		p.applyReasoningParams(&requestBody)
 	} else {
-		util.DebugLog("Temperature ignored for model %s", p.CurrentModel)
-	}
-
-	// 将请求体转换为 JSON
-	requestJSON, err := json.Marshal(requestBody)
-	if err != nil {
-		return nil, fmt.Errorf("error marshaling request: %v", err)
+		requestBody.Temperature = p.CurrentTemperature
+		util.DebugLog(util.ModuleProvider, "Using temperature: %.1f", p.CurrentTemperature)
 	}
 
-	// 创建 HTTP 请求
-	req, err := http.NewRequest("POST", openaiAPIURL, bytes.NewBuffer(requestJSON))
-	if err != nil {
-		return nil, fmt.Errorf("error creating request: %v", err)
-	}
+	return streamChatCompletion(ctx, p.newChatEndpoint("OpenAI", openaiAPIURL), requestBody)
+}
 
-	// 设置请求头
-	req.Header.Set("Content-Type", "application/json")
-	req.Header.Set("Authorization", "Bearer "+p.APIKey)
+// GetDefaultEmbeddingModel returns the embedding model used when none is given
+func (p *OpenAIProvider) GetDefaultEmbeddingModel() string {
+	return openaiDefaultEmbeddingModel
+}
 
-	// 发送请求
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return nil, fmt.Errorf("error sending request: %v", err)
+// Embeddings computes embeddings with the OpenAI embeddings API
+func (p *OpenAIProvider) Embeddings(ctx context.Context, inputs []string, model string) ([][]float64, *Usage, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, nil, fmt.Errorf("API key not set for OpenAI provider")
 	}
-
-	// 检查状态码
-	if resp.StatusCode != http.StatusOK {
-		// 读取错误响应
-		respBody, _ := io.ReadAll(resp.Body)
-		resp.Body.Close()
-
-		// 尝试解析错误响应
-		var errorResp openaiChatResponse
-		if err := json.Unmarshal(respBody, &errorResp); err == nil && errorResp.Error != nil {
-			return nil, fmt.Errorf("API error: %s", errorResp.Error.Message)
-		}
-
-		return nil, fmt.Errorf("API request failed with status %d: %s", resp.StatusCode, string(respBody))
+	if model == "" {
+		model = openaiDefaultEmbeddingModel
 	}
+	return requestEmbeddings(ctx, p.newChatEndpoint("OpenAI", openaiEmbeddingsURL), model, p.metadataUser(), inputs)
+}
 
-	// 启动 goroutine 处理流式响应
-	go func() {
-		defer resp.Body.Close()
-		defer close(respChan)
-
-		reader := bufio.NewReader(resp.Body)
-
-		for {
-			line, err := reader.ReadBytes('\n')
-			if err != nil {
-				if err != io.EOF {
-					respChan <- StreamResponse{Error: fmt.Errorf("error reading stream: %v", err)}
-				}
-				break
-			}
-
-			// Skip empty lines
-			line = bytes.TrimSpace(line)
-			if len(line) == 0 {
-				continue
-			}
-
-			// Remove "data: " prefix
-			if bytes.HasPrefix(line, []byte("data: ")) {
-				line = bytes.TrimPrefix(line, []byte("data: "))
-			}
-
-			// Check for stream end
-			if string(line) == "[DONE]" {
-				respChan <- StreamResponse{Done: true}
-				break
-			}
-
-			// Skip empty JSON objects or invalid lines
-			if string(line) == "{}" || len(line) == 0 {
-				continue
-			}
-
-			// Debug log the line for troubleshooting only when debug mode is enabled
-			if util.IsDebugMode() {
-				util.DebugLog("OpenAI stream line: %s", string(line))
-			}
-
-			// Parse the response
-			var streamResp openaiChatResponse
-			if err := json.Unmarshal(line, &streamResp); err != nil {
-				if util.IsDebugMode() {
-					util.DebugLog("Error parsing OpenAI stream: %v (line: %s)", err, string(line))
-				}
-				continue // Skip this line instead of breaking
-			}
-
-			// Check for API errors
-			if streamResp.Error != nil {
-				respChan <- StreamResponse{Error: fmt.Errorf("API error: %s", streamResp.Error.Message)}
-				break
-			}
-
-			// Extract content from choices
-			if len(streamResp.Choices) > 0 {
-				content := streamResp.Choices[0].Delta.Content
-				if content != "" {
-					respChan <- StreamResponse{Content: content}
-				}
-			}
-		}
-	}()
+// GetDefaultTranscriptionModel returns the transcription model used when none is given
+func (p *OpenAIProvider) GetDefaultTranscriptionModel() string {
+	return openaiDefaultWhisperModel
+}
 
-	return respChan, nil
+// Transcribe transcribes audio with the OpenAI transcription API
+func (p *OpenAIProvider) Transcribe(ctx context.Context, filename string, audio []byte, model, language string) (string, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return "", fmt.Errorf("API key not set for OpenAI provider")
+	}
+	if model == "" {
+		model = openaiDefaultWhisperModel
+	}
+	return requestTranscription(ctx, p.newChatEndpoint("OpenAI", openaiTranscriptionsURL), filename, audio, model, language)
 }
 
 // SetCurrentModel sets the current model after validating it
 func (p *OpenAIProvider) SetCurrentModel(model string) error {
 	// 验证模型是否有效
 	valid := false
-	for _, m := range openaiAvailableModels {
+	for _, m := range p.GetAvailableModels() {
 		if m == model {
 			valid = true
 			break
@@ -273,48 +179,42 @@ func (p *OpenAIProvider) SetCurrentModel(model string) error {
 	}
 
 	if !valid {
-		fmt.Printf("WARNING: Invalid model: %s. Available models: %v\n", model, openaiAvailableModels)
-		return fmt.Errorf("invalid model: %s. Available models: %v", model, openaiAvailableModels)
+		util.WarnLog(util.ModuleProvider, "Invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+		return fmt.Errorf("invalid model: %s. Available models: %v", model, p.GetAvailableModels())
 	}
 
 	// 设置模型并输出调试信息
 	p.CurrentModel = model
-	util.DebugLog("OpenAI model set to: %s", model)
+	util.DebugLog(util.ModuleProvider, "OpenAI model set to: %s", model)
 	return nil
 }
 
 // LoadConfig loads the provider configuration from the given map
 func (p *OpenAIProvider) LoadConfig(config map[string]interface{}) error {
+	p.loadCommonConfig(config)
+
 	// 加载 API Key
 	if apiKey, ok := config["api_key"].(string); ok {
 		p.APIKey = apiKey
-		util.DebugLog("Loaded API key for OpenAI provider")
+		util.DebugLog(util.ModuleProvider, "Loaded API key for OpenAI provider")
 	}
 
 	// 加载当前模型
 	if model, ok := config["model"].(string); ok {
-		util.DebugLog("Found model in config: %s", model)
+		util.DebugLog(util.ModuleProvider, "Found model in config: %s", model)
 		if err := p.SetCurrentModel(model); err != nil {
 			// 如果模型无效，使用默认模型
-			fmt.Printf("WARNING: Invalid model in config, using default model: %s\n", openaiDefaultModel)
+			util.WarnLog(util.ModuleProvider, "Invalid model in config, using default model: %s", openaiDefaultModel)
 			p.CurrentModel = openaiDefaultModel
 		}
 	} else {
 		// 如果没有设置模型，使用默认模型
-		util.DebugLog("No model found in config, using default model: %s", openaiDefaultModel)
+		util.DebugLog(util.ModuleProvider, "No model found in config, using default model: %s", openaiDefaultModel)
 		p.CurrentModel = openaiDefaultModel
 	}
 
 	// 加载温度设置
-	if temp, ok := config["temperature"].(float64); ok {
-		if err := p.SetCurrentTemperature(temp); err != nil {
-			// 如果温度无效，使用默认温度
-			p.CurrentTemperature = openaiDefaultTemperature
-		}
-	} else {
-		// 如果没有设置温度，使用默认温度
-		p.CurrentTemperature = openaiDefaultTemperature
-	}
+	loadTemperature(p, config, openaiMaxTemperature)
 
 	return nil
 }
@@ -327,15 +227,17 @@ func (p *OpenAIProvider) SaveConfig(config map[string]interface{}) {
 	// 确保模型已设置，如果未设置则使用默认模型
 	if p.CurrentModel == "" {
 		p.CurrentModel = openaiDefaultModel
-		fmt.Printf("WARNING: Model not set when saving config, using default model: %s\n", openaiDefaultModel)
+		util.WarnLog(util.ModuleProvider, "Model not set when saving config, using default model: %s", openaiDefaultModel)
 	}
 
 	// 保存当前模型
 	config["model"] = p.CurrentModel
-	util.DebugLog("Saving OpenAI model to config: %s", p.CurrentModel)
+	util.DebugLog(util.ModuleProvider, "Saving OpenAI model to config: %s", p.CurrentModel)
 
 	// 保存温度设置
 	config["temperature"] = p.CurrentTemperature
+
+	p.saveCommonConfig(config)
 }
 
 // IsReady returns whether the provider is ready to use
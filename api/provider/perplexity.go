@@ -0,0 +1,208 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/plucury/chait/util"
+)
+
+// PerplexityProvider implements the Provider interface for Perplexity API
+type PerplexityProvider struct {
+	BaseProvider // 嵌入基础提供者结构体
+}
+
+const (
+	perplexityAPIURL             = "https://api.perplexity.ai/chat/completions"
+	perplexityDefaultModel       = "sonar"
+	perplexityDefaultTemperature = 0.2 // Default temperature as per Perplexity API documentation
+	perplexityMaxTemperature     = 2.0 // Exclusive upper bound
+)
+
+// Available models for Perplexity API
+var perplexityAvailableModels = []string{
+	"sonar",               // Lightweight search model
+	"sonar-pro",           // Advanced search model
+	"sonar-reasoning",     // Search with reasoning
+	"sonar-reasoning-pro", // Advanced search with reasoning
+	"sonar-deep-research", // Exhaustive research reports
+}
+
+// Available temperature presets for Perplexity API
+var perplexityTemperaturePresets = []TemperaturePreset{
+	{"Factual", 0.0, "Deterministic answers grounded in search results"},
+	{"Focused", 0.2, "Default setting for search-backed answers"},
+	{"Balanced", 0.7, "Good balance between creativity and coherence"},
+	{"Creative", 1.2, "More varied and creative responses"},
+}
+
+// NewPerplexityProvider creates a new instance of PerplexityProvider
+func NewPerplexityProvider() Provider {
+	provider := &PerplexityProvider{
+		BaseProvider: BaseProvider{
+			Name:               "perplexity",
+			CurrentModel:       perplexityDefaultModel,
+			CurrentTemperature: perplexityDefaultTemperature,
+		},
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *PerplexityProvider) GetName() string {
+	return p.Name
+}
+
+// GetDefaultModel returns the default model for this provider
+func (p *PerplexityProvider) GetDefaultModel() string {
+	return perplexityDefaultModel
+}
+
+// GetAvailableModels returns the list of available models for this provider
+func (p *PerplexityProvider) GetAvailableModels() []string {
+	return p.withExtraModels(perplexityAvailableModels)
+}
+
+// GetDefaultTemperature returns the default temperature for this provider
+func (p *PerplexityProvider) GetDefaultTemperature() float64 {
+	return perplexityDefaultTemperature
+}
+
+// GetTemperaturePresets returns the available temperature presets for this provider
+func (p *PerplexityProvider) GetTemperaturePresets() []TemperaturePreset {
+	return perplexityTemperaturePresets
+}
+
+// SetCurrentTemperature sets the current temperature with Perplexity-specific validation
+func (p *PerplexityProvider) SetCurrentTemperature(temp float64) error {
+	// Validate temperature range specific to Perplexity (0-2, exclusive)
+	if temp < 0 || temp >= perplexityMaxTemperature {
+		return fmt.Errorf("Perplexity temperature must be between 0.0 and 2.0 (exclusive)")
+	}
+
+	p.CurrentTemperature = temp
+	return nil
+}
+
+// SendStreamingChatRequest sends a streaming chat request to the Perplexity API
+func (p *PerplexityProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("API key not set for Perplexity provider")
+	}
+
+	// 转换系统消息
+	messages, systemPrompt := p.prepareMessages(messages)
+
+	// 创建请求体
+	requestBody := chatCompletionRequest{
+		Model:          p.CurrentModel,
+		Messages:       messages,
+		System:         systemPrompt,
+		Temperature:    p.CurrentTemperature,
+		Stream:         true,
+		StreamOptions:  p.streamOptions(),
+		User:           p.metadataUser(),
+		MaxTokens:      p.maxTokens(),
+		Seed:           p.seed(),
+		SamplingParams: p.Sampling,
+		ResponseFormat: p.responseFormat(),
+	}
+
+	util.DebugLog(util.ModuleProvider, "Using Perplexity model: %s (streaming)", p.CurrentModel)
+	util.DebugLog(util.ModuleProvider, "Using temperature: %.1f", p.CurrentTemperature)
+
+	endpoint := p.newChatEndpoint("Perplexity", perplexityAPIURL)
+	endpoint.OnFinish = formatCitations
+	return streamChatCompletion(ctx, endpoint, requestBody)
+}
+
+// formatCitations renders the citations returned with the last chunk as a trailing sources block
+func formatCitations(last *chatCompletionResponse) string {
+	if len(last.Citations) == 0 {
+		return ""
+	}
+
+	var sb strings.Builder
+	sb.WriteString("\n\nSources:")
+	for i, url := range last.Citations {
+		sb.WriteString(fmt.Sprintf("\n[%d] %s", i+1, url))
+	}
+	return sb.String()
+}
+
+// SetCurrentModel sets the current model after validating it
+func (p *PerplexityProvider) SetCurrentModel(model string) error {
+	// 验证模型是否有效
+	valid := false
+	for _, m := range p.GetAvailableModels() {
+		if m == model {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+	}
+
+	p.CurrentModel = model
+	util.DebugLog(util.ModuleProvider, "Perplexity model set to: %s", model)
+	return nil
+}
+
+// LoadConfig loads the provider configuration from the given map
+func (p *PerplexityProvider) LoadConfig(config map[string]interface{}) error {
+	p.loadCommonConfig(config)
+
+	// 加载 API Key
+	if apiKey, ok := config["api_key"].(string); ok {
+		p.APIKey = apiKey
+		util.DebugLog(util.ModuleProvider, "Loaded API key for Perplexity provider")
+	}
+
+	// 加载当前模型
+	if model, ok := config["model"].(string); ok {
+		util.DebugLog(util.ModuleProvider, "Found model in config: %s", model)
+		if err := p.SetCurrentModel(model); err != nil {
+			// 如果模型无效，使用默认模型
+			p.CurrentModel = perplexityDefaultModel
+		}
+	} else {
+		// 如果没有设置模型，使用默认模型
+		util.DebugLog(util.ModuleProvider, "No model found in config, using default model: %s", perplexityDefaultModel)
+		p.CurrentModel = perplexityDefaultModel
+	}
+
+	// 加载温度设置 (the upper bound itself is not accepted, values from it on are clamped to 1.9)
+	loadTemperature(p, config, perplexityMaxTemperature-0.1)
+
+	return nil
+}
+
+// SaveConfig saves the provider configuration to the given map
+func (p *PerplexityProvider) SaveConfig(config map[string]interface{}) {
+	// 保存 API Key
+	config["api_key"] = p.APIKey
+
+	// 保存当前模型
+	config["model"] = p.CurrentModel
+	util.DebugLog(util.ModuleProvider, "Saving Perplexity model to config: %s", p.CurrentModel)
+
+	// 保存温度设置
+	config["temperature"] = p.CurrentTemperature
+
+	p.saveCommonConfig(config)
+}
+
+// IsReady returns whether the provider is ready to use
+// For Perplexity, the provider is ready if the API key is set
+func (p *PerplexityProvider) IsReady() bool {
+	return p.APIKey != ""
+}
+
+func init() {
+	// Register the Perplexity provider
+	Register("perplexity", NewPerplexityProvider)
+}
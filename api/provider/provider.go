@@ -1,15 +1,57 @@
 package provider
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/plucury/chait/util"
 )
 
 // ChatMessage represents a message in the chat
 type ChatMessage struct {
-	Role    string `json:"role"`
-	Content string `json:"content"`
+	Role    string   `json:"role"`
+	Content string   `json:"content"`
+	Images  []string `json:"-"` // Image data URLs attached to the message
+}
+
+// contentPart is an element of a multipart message content
+type contentPart struct {
+	Type     string    `json:"type"`
+	Text     string    `json:"text,omitempty"`
+	ImageURL *imageURL `json:"image_url,omitempty"`
+}
+
+// imageURL references an image by URL or data URL
+type imageURL struct {
+	URL string `json:"url"`
+}
+
+// MarshalJSON encodes messages with images as multipart content for vision models
+func (m ChatMessage) MarshalJSON() ([]byte, error) {
+	if len(m.Images) == 0 {
+		return json.Marshal(struct {
+			Role    string `json:"role"`
+			Content string `json:"content"`
+		}{m.Role, m.Content})
+	}
+
+	parts := []contentPart{}
+	if m.Content != "" {
+		parts = append(parts, contentPart{Type: "text", Text: m.Content})
+	}
+	for _, image := range m.Images {
+		parts = append(parts, contentPart{Type: "image_url", ImageURL: &imageURL{URL: image}})
+	}
+	return json.Marshal(struct {
+		Role    string        `json:"role"`
+		Content []contentPart `json:"content"`
+	}{m.Role, parts})
 }
 
 // TemperaturePreset represents a predefined temperature setting for specific use cases
@@ -19,11 +61,51 @@ type TemperaturePreset struct {
 	Description string
 }
 
+// ErrStreamStalled is returned through the stream when no data arrives within the idle timeout
+var ErrStreamStalled = errors.New("stream stalled")
+
 // StreamResponse represents a streaming response chunk from the API
 type StreamResponse struct {
 	Content string
 	Done    bool
 	Error   error
+	Status  string    // Progress information such as retries, not part of the response
+	RetryAt time.Time // Time of the next attempt when Status reports a retry
+	Usage   *Usage    // Token usage, reported with the final response if the provider includes it
+
+	// Provider and model answering instead of the active provider after a fallback,
+	// reported once before the response starts
+	AnsweredBy    string
+	AnsweredModel string
+}
+
+// Usage is the number of tokens a request consumed as reported by the provider
+type Usage struct {
+	PromptTokens     int `json:"prompt_tokens"`
+	CompletionTokens int `json:"completion_tokens"`
+	TotalTokens      int `json:"total_tokens"`
+}
+
+// SamplingParams holds the optional sampling settings sent with each request
+// Zero values are not sent, so the provider defaults apply
+type SamplingParams struct {
+	TopP             float64 `json:"top_p,omitempty"`
+	FrequencyPenalty float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64 `json:"presence_penalty,omitempty"`
+}
+
+// Validate returns an error if a setting is outside the range accepted by OpenAI-compatible APIs
+func (s SamplingParams) Validate() error {
+	if s.TopP < 0 || s.TopP > 1 {
+		return fmt.Errorf("top_p must be between 0.0 and 1.0")
+	}
+	if s.FrequencyPenalty < -2 || s.FrequencyPenalty > 2 {
+		return fmt.Errorf("frequency_penalty must be between -2.0 and 2.0")
+	}
+	if s.PresencePenalty < -2 || s.PresencePenalty > 2 {
+		return fmt.Errorf("presence_penalty must be between -2.0 and 2.0")
+	}
+	return nil
 }
 
 // Provider defines the interface for AI chat providers
@@ -55,6 +137,12 @@ type Provider interface {
 	// SetCurrentTemperature sets the current temperature
 	SetCurrentTemperature(temp float64) error
 
+	// GetSamplingParams returns the current top_p and penalty settings
+	GetSamplingParams() SamplingParams
+
+	// SetSamplingParams sets top_p and the penalties
+	SetSamplingParams(params SamplingParams) error
+
 	// GetAPIKey returns the API key (masked for security)
 	GetAPIKey() string
 
@@ -65,7 +153,8 @@ type Provider interface {
 	IsReady() bool
 
 	// SendStreamingChatRequest sends a chat request and returns a channel for streaming responses
-	SendStreamingChatRequest(messages []ChatMessage) (<-chan StreamResponse, error)
+	// Cancelling the context aborts the request
+	SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error)
 
 	// LoadConfig loads the provider configuration from the given map
 	LoadConfig(config map[string]interface{}) error
@@ -80,6 +169,316 @@ type BaseProvider struct {
 	APIKey             string
 	CurrentModel       string
 	CurrentTemperature float64
+	UserAgent          string   // Custom User-Agent header, empty for the default
+	User               string   // Optional end-user identifier sent with requests
+	Proxy              string   // Proxy URL overriding the global proxy setting
+	ExtraModels        []string // User-defined models not yet known to chait
+	MaxTokens          int      // Maximum number of tokens per response, zero for the provider default
+	Sampling           SamplingParams
+	SystemRole         string // How to send the system prompt, empty to follow the model capability registry
+	ReasoningEffort    string // reasoning_effort of o-series models: low, medium or high, empty for the default
+
+	Timeout           time.Duration // Time allowed to receive the response headers, zero for the default
+	StreamIdleTimeout time.Duration // Time allowed between stream chunks, zero for the default
+}
+
+// loadCommonConfig loads the settings shared by all providers from the given map
+func (p *BaseProvider) loadCommonConfig(config map[string]interface{}) {
+	if userAgent, ok := config["user_agent"].(string); ok {
+		p.UserAgent = userAgent
+	}
+	if user, ok := config["user"].(string); ok {
+		p.User = user
+	}
+	if proxy, ok := config["proxy"].(string); ok {
+		p.Proxy = proxy
+	}
+	p.SystemRole = ""
+	if role, ok := config["system_role"].(string); ok && role != "" {
+		if isValidSystemRole(role) {
+			p.SystemRole = role
+		} else {
+			util.WarnLog(util.ModuleConfig, "Invalid system_role %q for %s (expected one of: %s)", role, p.Name, strings.Join(validSystemRoles, ", "))
+		}
+	}
+
+	p.ReasoningEffort = ""
+	if effort, ok := config["reasoning_effort"].(string); ok && effort != "" {
+		if isValidReasoningEffort(effort) {
+			p.ReasoningEffort = effort
+		} else {
+			util.WarnLog(util.ModuleConfig, "Invalid reasoning_effort %q for %s (expected one of: %s)", effort, p.Name, strings.Join(validReasoningEfforts, ", "))
+		}
+	}
+
+	// 加载最大 token 数
+	p.MaxTokens = configInt(config, "max_tokens")
+
+	// 加载采样参数
+	p.Sampling = SamplingParams{}
+	sampling := SamplingParams{}
+	sampling.TopP, _ = configFloat(config, "top_p")
+	sampling.FrequencyPenalty, _ = configFloat(config, "frequency_penalty")
+	sampling.PresencePenalty, _ = configFloat(config, "presence_penalty")
+	if err := p.SetSamplingParams(sampling); err != nil {
+		util.WarnLog(util.ModuleConfig, "Ignoring sampling settings for %s: %v", p.Name, err)
+	}
+
+	// 加载超时设置
+	p.Timeout = configDuration(config, "timeout")
+	p.StreamIdleTimeout = configDuration(config, "stream_idle_timeout")
+
+	// 加载用户自定义模型
+	p.ExtraModels = nil
+	switch models := config["extra_models"].(type) {
+	case []interface{}:
+		for _, m := range models {
+			if name, ok := m.(string); ok && name != "" {
+				p.ExtraModels = append(p.ExtraModels, name)
+			}
+		}
+	case []string:
+		p.ExtraModels = append(p.ExtraModels, models...)
+	case string:
+		// Comma-separated list as set by "chait config"
+		for _, name := range strings.Split(models, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				p.ExtraModels = append(p.ExtraModels, name)
+			}
+		}
+	}
+}
+
+// saveCommonConfig saves the settings shared by all providers to the given map
+func (p *BaseProvider) saveCommonConfig(config map[string]interface{}) {
+	if p.UserAgent != "" {
+		config["user_agent"] = p.UserAgent
+	}
+	if p.User != "" {
+		config["user"] = p.User
+	}
+	if p.Proxy != "" {
+		config["proxy"] = p.Proxy
+	}
+	if p.MaxTokens > 0 {
+		config["max_tokens"] = p.MaxTokens
+	}
+	if p.SystemRole != "" {
+		config["system_role"] = p.SystemRole
+	}
+	if p.ReasoningEffort != "" {
+		config["reasoning_effort"] = p.ReasoningEffort
+	}
+	if p.Sampling.TopP != 0 {
+		config["top_p"] = p.Sampling.TopP
+	}
+	if p.Sampling.FrequencyPenalty != 0 {
+		config["frequency_penalty"] = p.Sampling.FrequencyPenalty
+	}
+	if p.Sampling.PresencePenalty != 0 {
+		config["presence_penalty"] = p.Sampling.PresencePenalty
+	}
+	if p.Timeout > 0 {
+		config["timeout"] = p.Timeout.Seconds()
+	}
+	if p.StreamIdleTimeout > 0 {
+		config["stream_idle_timeout"] = p.StreamIdleTimeout.Seconds()
+	}
+	if len(p.ExtraModels) > 0 {
+		config["extra_models"] = p.ExtraModels
+	}
+}
+
+// configFloat reads a number that may have been decoded as a float or set as an integer,
+// e.g. by "chait config providers.grok.temperature 1"
+func configFloat(config map[string]interface{}, key string) (float64, bool) {
+	switch v := config[key].(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// loadTemperature sets the provider's temperature from the config, clamping values the provider
+// rejects to [0, maxTemp] with a warning and using the default temperature if the setting is missing or invalid
+func loadTemperature(p Provider, config map[string]interface{}, maxTemp float64) {
+	temp, ok := configFloat(config, "temperature")
+	if !ok {
+		if value, set := config["temperature"]; set {
+			util.WarnLog(util.ModuleConfig, "Invalid temperature %v for %s, using the default %.2g", value, p.GetName(), p.GetDefaultTemperature())
+		}
+		p.SetCurrentTemperature(p.GetDefaultTemperature())
+		return
+	}
+	if err := p.SetCurrentTemperature(temp); err == nil {
+		return
+	}
+
+	clamped := math.Max(0, math.Min(temp, maxTemp))
+	if clamped != temp {
+		util.WarnLog(util.ModuleConfig, "Temperature %g for %s is out of range, clamped to %.2g", temp, p.GetName(), clamped)
+	}
+	if err := p.SetCurrentTemperature(clamped); err != nil {
+		util.WarnLog(util.ModuleConfig, "%v, using the default %.2g", err, p.GetDefaultTemperature())
+		p.SetCurrentTemperature(p.GetDefaultTemperature())
+	}
+}
+
+// configInt reads a positive integer setting, zero if unset or invalid
+func configInt(config map[string]interface{}, key string) int {
+	switch v := config[key].(type) {
+	case float64, float32, int, int64:
+		if f, _ := configFloat(config, key); f > 0 && f == float64(int(f)) {
+			return int(f)
+		}
+	case string:
+		if n, err := strconv.Atoi(v); err == nil && n > 0 {
+			return n
+		}
+	default:
+		return 0
+	}
+	util.WarnLog(util.ModuleConfig, "Invalid %s %v, using the default", key, config[key])
+	return 0
+}
+
+// configDuration reads a duration given in seconds or as a string like "90s", zero if unset or invalid
+func configDuration(config map[string]interface{}, key string) time.Duration {
+	if seconds, ok := configFloat(config, key); ok {
+		if seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		return 0
+	}
+	if v, ok := config[key].(string); ok {
+		if d, err := time.ParseDuration(v); err == nil && d > 0 {
+			return d
+		}
+		util.WarnLog(util.ModuleConfig, "Invalid %s %q, using the default", key, v)
+	}
+	return 0
+}
+
+// withExtraModels returns the built-in models followed by the user-defined ones not already listed
+func (p *BaseProvider) withExtraModels(models []string) []string {
+	if len(p.ExtraModels) == 0 {
+		return models
+	}
+
+	result := append([]string{}, models...)
+	for _, extra := range p.ExtraModels {
+		known := false
+		for _, m := range result {
+			if m == extra {
+				known = true
+				break
+			}
+		}
+		if !known {
+			result = append(result, extra)
+		}
+	}
+	return result
+}
+
+// newChatEndpoint describes the provider's chat completions endpoint with its connection settings
+func (p *BaseProvider) newChatEndpoint(displayName, url string) chatEndpoint {
+	proxy := p.Proxy
+	if proxy == "" {
+		proxy = util.GlobalProxy()
+	}
+	endpoint := chatEndpoint{
+		Name:              displayName,
+		URL:               url,
+		APIKey:            p.APIKey,
+		UserAgent:         p.UserAgent,
+		Proxy:             proxy,
+		Timeout:           defaultTimeout,
+		StreamIdleTimeout: defaultStreamIdleTimeout,
+	}
+	if p.Timeout > 0 {
+		endpoint.Timeout = p.Timeout
+	}
+	if p.StreamIdleTimeout > 0 {
+		endpoint.StreamIdleTimeout = p.StreamIdleTimeout
+	}
+	return endpoint
+}
+
+// maxTokens returns the response length cap to send, the session override taking precedence,
+// lowered in brief mode except for reasoning models, whose cap also covers their reasoning
+func (p *BaseProvider) maxTokens() int {
+	maxTokens := p.MaxTokens
+	if override := util.MaxTokensOverride(); override > 0 {
+		maxTokens = override
+	}
+	if util.AnswerMode() == util.AnswerModeBrief && !p.isReasoningModel() && (maxTokens == 0 || maxTokens > briefMaxTokens) {
+		return briefMaxTokens
+	}
+	return maxTokens
+}
+
+// Providers whose API accepts the seed parameter
+var seedProviders = map[string]bool{
+	"openai":   true,
+	"grok":     true,
+	"together": true,
+}
+
+// SupportsSeed returns true if the provider accepts a sampling seed
+func SupportsSeed(name string) bool {
+	return seedProviders[name]
+}
+
+// seed returns the sampling seed to send, nil if none is set or the provider does not accept it
+func (p *BaseProvider) seed() *int64 {
+	seed, ok := util.Seed()
+	if !ok || !SupportsSeed(p.Name) {
+		return nil
+	}
+	return &seed
+}
+
+// metadataUser returns the end-user identifier to send, or an empty string if metadata is disabled
+func (p *BaseProvider) metadataUser() string {
+	if util.IsMetadataDisabled() {
+		return ""
+	}
+	return p.User
+}
+
+// responseFormat returns the JSON object response format if JSON mode is enabled and the
+// current model accepts it; other models only get the instruction of withJSONInstruction
+func (p *BaseProvider) responseFormat() *responseFormat {
+	if !util.IsJSONMode() || !SupportsJSONMode(p.CurrentModel) {
+		return nil
+	}
+	return &responseFormat{Type: "json_object"}
+}
+
+// GetSamplingParams returns the current top_p and penalty settings
+func (p *BaseProvider) GetSamplingParams() SamplingParams {
+	return p.Sampling
+}
+
+// SetSamplingParams sets top_p and the penalties after validating them
+func (p *BaseProvider) SetSamplingParams(params SamplingParams) error {
+	if err := params.Validate(); err != nil {
+		return err
+	}
+	p.Sampling = params
+	return nil
+}
+
+// streamOptions asks for the token usage to be reported at the end of the stream
+func (p *BaseProvider) streamOptions() *streamOptions {
+	return &streamOptions{IncludeUsage: true}
 }
 
 // GetAPIKey returns a masked version of the API key for security
@@ -177,7 +576,7 @@ func GetProvider(name string) (Provider, bool) {
 		return nil, false
 	}
 	// debug
-	util.DebugLog("Creating new provider instance for %s", name)
+	util.DebugLog(util.ModuleProvider, "Creating new provider instance for %s", name)
 
 	// 创建新实例并缓存
 	instance := factory()
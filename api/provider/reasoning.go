@@ -0,0 +1,33 @@
+package provider
+
+import "github.com/plucury/chait/util"
+
+// validReasoningEfforts lists the accepted values of the reasoning_effort setting
+var validReasoningEfforts = []string{"low", "medium", "high"}
+
+// isValidReasoningEffort returns true if the value is a known reasoning effort
+func isValidReasoningEffort(effort string) bool {
+	for _, valid := range validReasoningEfforts {
+		if effort == valid {
+			return true
+		}
+	}
+	return false
+}
+
+// isReasoningModel returns true if the current model is an OpenAI o-series reasoning model
+func (p *BaseProvider) isReasoningModel() bool {
+	return LookupModel(p.CurrentModel).Reasoning
+}
+
+// applyReasoningParams adapts a request to the parameters o-series models accept:
+// they reject temperature, max_tokens and the sampling parameters, and take
+// max_completion_tokens, which also covers the reasoning tokens, and reasoning_effort
+func (p *BaseProvider) applyReasoningParams(request *chatCompletionRequest) {
+	request.Temperature = 0
+	request.SamplingParams = SamplingParams{}
+	request.MaxCompletionTokens = request.MaxTokens
+	request.MaxTokens = 0
+	request.ReasoningEffort = p.ReasoningEffort
+	util.DebugLog(util.ModuleProvider, "Using reasoning parameters for %s (effort: %q, max completion tokens: %d)", p.CurrentModel, p.ReasoningEffort, request.MaxCompletionTokens)
+}
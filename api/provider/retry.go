@@ -0,0 +1,170 @@
+package provider
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+// maxRetryAfter is the longest wait requested by a rate limited provider that is honored automatically
+const maxRetryAfter = 2 * time.Minute
+
+// retryPolicy controls how transient request failures are retried
+type retryPolicy struct {
+	MaxAttempts int           // Total number of attempts, 1 disables retries
+	Backoff     time.Duration // Delay before the first retry, doubled for each following one
+	MaxBackoff  time.Duration // Upper bound of the delay between attempts
+	Jitter      float64       // Random fraction (0-1) added to or removed from each delay
+}
+
+// loadRetryPolicy returns the retry policy from the configuration
+func loadRetryPolicy() retryPolicy {
+	settings := util.GetRetrySettings()
+	return retryPolicy{
+		MaxAttempts: settings.MaxAttempts,
+		Backoff:     settings.Backoff,
+		MaxBackoff:  settings.MaxBackoff,
+		Jitter:      settings.Jitter,
+	}
+}
+
+// delay returns the time to wait before the given retry (1 for the first retry)
+func (r retryPolicy) delay(retry int) time.Duration {
+	d := r.Backoff
+	for i := 1; i < retry && d < r.MaxBackoff; i++ {
+		d *= 2
+	}
+	if d > r.MaxBackoff {
+		d = r.MaxBackoff
+	}
+	if r.Jitter > 0 {
+		d += time.Duration((rand.Float64()*2 - 1) * r.Jitter * float64(d))
+	}
+	return d
+}
+
+// connectionError is returned when a request could not reach the provider
+type connectionError struct {
+	Name string
+	Err  error
+}
+
+func (e *connectionError) Error() string {
+	return fmt.Sprintf("error connecting to %s API: %v", e.Name, e.Err)
+}
+
+func (e *connectionError) Unwrap() error {
+	return e.Err
+}
+
+// isTransient returns true for failures that may succeed when retried:
+// connection errors, timeouts, rate limits and server errors
+func isTransient(err error) bool {
+	var connErr *connectionError
+	if errors.As(err, &connErr) {
+		return true
+	}
+
+	var apiErr *apiError
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode >= 500 || apiErr.StatusCode == http.StatusRequestTimeout ||
+			apiErr.StatusCode == http.StatusTooManyRequests
+	}
+	return false
+}
+
+// IsFallbackError returns true for failures worth retrying with another provider:
+// transient failures that outlasted the retries, and exhausted quotas or balances
+func IsFallbackError(err error) bool {
+	if isTransient(err) {
+		return true
+	}
+	var apiErr *apiError
+	return errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusPaymentRequired
+}
+
+// parseRetryAfter reads how long to wait from Retry-After or the rate limit reset headers
+func parseRetryAfter(header http.Header) time.Duration {
+	if ms, err := strconv.ParseFloat(header.Get("Retry-After-Ms"), 64); err == nil && ms > 0 {
+		return time.Duration(ms * float64(time.Millisecond))
+	}
+	if value := header.Get("Retry-After"); value != "" {
+		if seconds, err := strconv.ParseFloat(value, 64); err == nil && seconds > 0 {
+			return time.Duration(seconds * float64(time.Second))
+		}
+		if date, err := http.ParseTime(value); err == nil {
+			if wait := time.Until(date); wait > 0 {
+				return wait
+			}
+		}
+	}
+
+	// OpenAI-style headers, e.g. x-ratelimit-remaining-requests: 0 and x-ratelimit-reset-requests: 6m0s
+	var wait time.Duration
+	for _, limit := range []string{"requests", "tokens"} {
+		if header.Get("X-Ratelimit-Remaining-"+limit) != "0" {
+			continue
+		}
+		if reset, err := time.ParseDuration(header.Get("X-Ratelimit-Reset-" + limit)); err == nil && reset > wait {
+			wait = reset
+		}
+	}
+	return wait
+}
+
+// FormatRetryStatus renders a retry status with a countdown to the next attempt
+func FormatRetryStatus(status string, retryAt time.Time) string {
+	if retryAt.IsZero() {
+		return status
+	}
+	seconds := int(math.Ceil(time.Until(retryAt).Seconds()))
+	if seconds < 0 {
+		seconds = 0
+	}
+	return fmt.Sprintf("%s, retrying in %ds…", status, seconds)
+}
+
+// withRetry calls send until it succeeds, fails with a permanent error or runs out of attempts.
+// notify is called before each retry with a status message and the time of the next attempt.
+func withRetry(ctx context.Context, name string, policy retryPolicy, notify func(status string, retryAt time.Time), send func() (*http.Response, error)) (*http.Response, error) {
+	for attempt := 1; ; attempt++ {
+		resp, err := send()
+		if err == nil {
+			return resp, nil
+		}
+		if attempt >= policy.MaxAttempts || !isTransient(err) {
+			return nil, err
+		}
+
+		wait := policy.delay(attempt)
+		status := fmt.Sprintf("Request failed (attempt %d/%d)", attempt, policy.MaxAttempts)
+
+		// Rate limited requests wait for as long as the provider asks
+		var apiErr *apiError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusTooManyRequests {
+			if apiErr.RetryAfter > maxRetryAfter {
+				return nil, fmt.Errorf("rate limited by %s API, retry after %s: %w", name, apiErr.RetryAfter.Round(time.Second), err)
+			}
+			if apiErr.RetryAfter > 0 {
+				wait = apiErr.RetryAfter
+			}
+			status = fmt.Sprintf("Rate limited by %s API (attempt %d/%d)", name, attempt, policy.MaxAttempts)
+		}
+
+		util.WarnLog(util.ModuleProvider, "%s request failed (attempt %d/%d), retrying in %s: %v", name, attempt, policy.MaxAttempts, wait, err)
+		notify(status, time.Now().Add(wait))
+
+		select {
+		case <-time.After(wait):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package provider
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Ways a provider expects the system prompt
+const (
+	SystemRoleSystem    = "system"    // A message with the system role
+	SystemRoleDeveloper = "developer" // A message with the developer role, used by OpenAI reasoning models
+	SystemRoleUser      = "user"      // Text prepended to the first user message
+	SystemRoleTopLevel  = "top-level" // A separate request field next to the messages
+)
+
+// validSystemRoles lists the accepted values of the system_role setting
+var validSystemRoles = []string{SystemRoleSystem, SystemRoleDeveloper, SystemRoleUser, SystemRoleTopLevel}
+
+// systemRole returns how the current model expects the system prompt,
+// the system_role setting taking precedence over the model capability registry
+func (p *BaseProvider) systemRole() string {
+	if p.SystemRole != "" {
+		return p.SystemRole
+	}
+	return LookupModel(p.CurrentModel).SystemRole
+}
+
+// prepareMessages converts the system messages of a conversation to the form the current model expects,
+// adding the instructions of the answer mode and of JSON mode
+// It returns the messages to send and, for the top-level form, the system prompt to send separately
+func (p *BaseProvider) prepareMessages(messages []ChatMessage) ([]ChatMessage, string) {
+	return mapSystemRole(withJSONInstruction(withAnswerMode(messages)), p.systemRole())
+}
+
+// mapSystemRole converts the system-level messages (system and developer messages) to the given
+// form, keeping their order. Developer messages keep their role only for models expecting the
+// developer role; other models receive them like system messages.
+func mapSystemRole(messages []ChatMessage, role string) ([]ChatMessage, string) {
+	if role == "" {
+		role = SystemRoleSystem
+	}
+
+	var system []string
+	result := make([]ChatMessage, 0, len(messages))
+	for _, m := range messages {
+		if !isSystemLevel(m.Role) {
+			result = append(result, m)
+			continue
+		}
+		if m.Content == "" {
+			continue // Drop empty system prompts instead of sending empty messages
+		}
+		if role == SystemRoleSystem || role == SystemRoleDeveloper {
+			m.Role = role
+			result = append(result, m)
+			continue
+		}
+		system = append(system, m.Content)
+	}
+
+	prompt := strings.Join(system, "\n\n")
+	if role != SystemRoleUser || prompt == "" {
+		return result, prompt
+	}
+
+	// Prepend the instructions to the first user message without modifying the caller's slice
+	for i, m := range result {
+		if m.Role == "user" {
+			m.Content = fmt.Sprintf("%s\n\n%s", prompt, m.Content)
+			result[i] = m
+			return result, ""
+		}
+	}
+	return append([]ChatMessage{{Role: "user", Content: prompt}}, result...), ""
+}
+
+// isSystemLevel returns true for the roles of instructions rather than conversation turns
+func isSystemLevel(role string) bool {
+	return role == "system" || role == "developer"
+}
+
+// isValidSystemRole returns true if the value is accepted by the system_role setting
+func isValidSystemRole(role string) bool {
+	for _, r := range validSystemRoles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,238 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plucury/chait/util"
+)
+
+// TogetherProvider implements the Provider interface for Together AI API
+type TogetherProvider struct {
+	BaseProvider // 嵌入基础提供者结构体
+}
+
+const (
+	togetherAPIURL                = "https://api.together.xyz/v1/chat/completions"
+	togetherEmbeddingsURL         = "https://api.together.xyz/v1/embeddings"
+	togetherDefaultEmbeddingModel = "togethercomputer/m2-bert-80M-8k-retrieval"
+	togetherDefaultModel          = "meta-llama/Llama-3.3-70B-Instruct-Turbo"
+	togetherDefaultTemperature    = 0.7
+	togetherMaxTemperature        = 2.0 // Upper bound for models without a specific limit
+)
+
+// Available models for Together AI API
+var togetherAvailableModels = []string{
+	"meta-llama/Llama-3.3-70B-Instruct-Turbo",
+	"meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo",
+	"meta-llama/Meta-Llama-3.1-405B-Instruct-Turbo",
+	"deepseek-ai/DeepSeek-V3",
+	"deepseek-ai/DeepSeek-R1",
+	"Qwen/Qwen2.5-72B-Instruct-Turbo",
+	"Qwen/Qwen2.5-Coder-32B-Instruct",
+	"mistralai/Mixtral-8x7B-Instruct-v0.1",
+	"mistralai/Mistral-7B-Instruct-v0.3",
+	"google/gemma-2-27b-it",
+}
+
+// Maximum temperature for models that degrade above the provider-wide limit
+var togetherModelMaxTemperatures = map[string]float64{
+	"deepseek-ai/DeepSeek-R1":              1.0, // Reasoning output becomes incoherent at higher values
+	"mistralai/Mixtral-8x7B-Instruct-v0.1": 1.0,
+	"mistralai/Mistral-7B-Instruct-v0.3":   1.0,
+	"google/gemma-2-27b-it":                1.0,
+}
+
+// Available temperature presets for Together AI API
+var togetherTemperaturePresets = []TemperaturePreset{
+	{"Precise", 0.0, "Highly deterministic responses for factual queries"},
+	{"Focused", 0.3, "Code generation and data extraction"},
+	{"Balanced", 0.7, "Good balance between creativity and coherence"},
+	{"Creative", 1.0, "More varied and creative responses"},
+	{"Very Creative", 1.5, "Highly varied responses (not supported by every model)"},
+}
+
+// NewTogetherProvider creates a new instance of TogetherProvider
+func NewTogetherProvider() Provider {
+	provider := &TogetherProvider{
+		BaseProvider: BaseProvider{
+			Name:               "together",
+			CurrentModel:       togetherDefaultModel,
+			CurrentTemperature: togetherDefaultTemperature,
+		},
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *TogetherProvider) GetName() string {
+	return p.Name
+}
+
+// GetDefaultModel returns the default model for this provider
+func (p *TogetherProvider) GetDefaultModel() string {
+	return togetherDefaultModel
+}
+
+// GetAvailableModels returns the list of available models for this provider
+func (p *TogetherProvider) GetAvailableModels() []string {
+	return p.withExtraModels(togetherAvailableModels)
+}
+
+// GetDefaultTemperature returns the default temperature for this provider
+func (p *TogetherProvider) GetDefaultTemperature() float64 {
+	return togetherDefaultTemperature
+}
+
+// GetTemperaturePresets returns the available temperature presets for this provider
+func (p *TogetherProvider) GetTemperaturePresets() []TemperaturePreset {
+	return togetherTemperaturePresets
+}
+
+// maxTemperature returns the maximum temperature supported by the given model
+func (p *TogetherProvider) maxTemperature(model string) float64 {
+	if maxTemp, ok := togetherModelMaxTemperatures[model]; ok {
+		return maxTemp
+	}
+	return togetherMaxTemperature
+}
+
+// SetCurrentTemperature sets the current temperature with model-specific validation
+func (p *TogetherProvider) SetCurrentTemperature(temp float64) error {
+	maxTemp := p.maxTemperature(p.CurrentModel)
+	if temp < 0 || temp > maxTemp {
+		return fmt.Errorf("Together AI temperature for %s must be between 0.0 and %.1f", p.CurrentModel, maxTemp)
+	}
+
+	p.CurrentTemperature = temp
+	return nil
+}
+
+// SendStreamingChatRequest sends a streaming chat request to the Together AI API
+func (p *TogetherProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("API key not set for Together AI provider")
+	}
+
+	// 转换系统消息
+	messages, systemPrompt := p.prepareMessages(messages)
+
+	// 创建请求体
+	requestBody := chatCompletionRequest{
+		Model:          p.CurrentModel,
+		Messages:       messages,
+		System:         systemPrompt,
+		Temperature:    p.CurrentTemperature,
+		Stream:         true,
+		StreamOptions:  p.streamOptions(),
+		User:           p.metadataUser(),
+		MaxTokens:      p.maxTokens(),
+		Seed:           p.seed(),
+		SamplingParams: p.Sampling,
+		ResponseFormat: p.responseFormat(),
+	}
+
+	util.DebugLog(util.ModuleProvider, "Using Together AI model: %s (streaming)", p.CurrentModel)
+	util.DebugLog(util.ModuleProvider, "Using temperature: %.1f", p.CurrentTemperature)
+
+	return streamChatCompletion(ctx, p.newChatEndpoint("Together AI", togetherAPIURL), requestBody)
+}
+
+// GetDefaultEmbeddingModel returns the embedding model used when none is given
+func (p *TogetherProvider) GetDefaultEmbeddingModel() string {
+	return togetherDefaultEmbeddingModel
+}
+
+// Embeddings computes embeddings with the Together AI embeddings API
+func (p *TogetherProvider) Embeddings(ctx context.Context, inputs []string, model string) ([][]float64, *Usage, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, nil, fmt.Errorf("API key not set for Together AI provider")
+	}
+	if model == "" {
+		model = togetherDefaultEmbeddingModel
+	}
+	return requestEmbeddings(ctx, p.newChatEndpoint("Together AI", togetherEmbeddingsURL), model, p.metadataUser(), inputs)
+}
+
+// SetCurrentModel sets the current model after validating it
+// The current temperature is clamped to the limit of the new model
+func (p *TogetherProvider) SetCurrentModel(model string) error {
+	// 验证模型是否有效
+	valid := false
+	for _, m := range p.GetAvailableModels() {
+		if m == model {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+	}
+
+	p.CurrentModel = model
+	util.DebugLog(util.ModuleProvider, "Together AI model set to: %s", model)
+
+	if maxTemp := p.maxTemperature(model); p.CurrentTemperature > maxTemp {
+		util.DebugLog(util.ModuleProvider, "Clamping temperature %.1f to %.1f for model %s", p.CurrentTemperature, maxTemp, model)
+		p.CurrentTemperature = maxTemp
+	}
+	return nil
+}
+
+// LoadConfig loads the provider configuration from the given map
+func (p *TogetherProvider) LoadConfig(config map[string]interface{}) error {
+	p.loadCommonConfig(config)
+
+	// 加载 API Key
+	if apiKey, ok := config["api_key"].(string); ok {
+		p.APIKey = apiKey
+		util.DebugLog(util.ModuleProvider, "Loaded API key for Together AI provider")
+	}
+
+	// 加载当前模型
+	if model, ok := config["model"].(string); ok {
+		util.DebugLog(util.ModuleProvider, "Found model in config: %s", model)
+		if err := p.SetCurrentModel(model); err != nil {
+			// 如果模型无效，使用默认模型
+			p.CurrentModel = togetherDefaultModel
+		}
+	} else {
+		// 如果没有设置模型，使用默认模型
+		util.DebugLog(util.ModuleProvider, "No model found in config, using default model: %s", togetherDefaultModel)
+		p.CurrentModel = togetherDefaultModel
+	}
+
+	// 加载温度设置
+	loadTemperature(p, config, p.maxTemperature(p.CurrentModel))
+
+	return nil
+}
+
+// SaveConfig saves the provider configuration to the given map
+func (p *TogetherProvider) SaveConfig(config map[string]interface{}) {
+	// 保存 API Key
+	config["api_key"] = p.APIKey
+
+	// 保存当前模型
+	config["model"] = p.CurrentModel
+	util.DebugLog(util.ModuleProvider, "Saving Together AI model to config: %s", p.CurrentModel)
+
+	// 保存温度设置
+	config["temperature"] = p.CurrentTemperature
+
+	p.saveCommonConfig(config)
+}
+
+// IsReady returns whether the provider is ready to use
+// For Together AI, the provider is ready if the API key is set
+func (p *TogetherProvider) IsReady() bool {
+	return p.APIKey != ""
+}
+
+func init() {
+	// Register the Together AI provider
+	Register("together", NewTogetherProvider)
+}
@@ -0,0 +1,78 @@
+package provider
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+// Transcriber is implemented by providers that can transcribe audio
+type Transcriber interface {
+	// GetDefaultTranscriptionModel returns the transcription model used when none is given
+	GetDefaultTranscriptionModel() string
+
+	// Transcribe returns the text spoken in an audio file
+	// An empty model selects the default transcription model, an empty language detects it
+	Transcribe(ctx context.Context, filename string, audio []byte, model, language string) (string, error)
+}
+
+// MaxTranscriptionSize is the largest audio file accepted by the transcription APIs
+const MaxTranscriptionSize = 25 << 20
+
+// transcriptionResponse is the JSON response of an OpenAI-compatible transcription API
+type transcriptionResponse struct {
+	Text string `json:"text"`
+}
+
+// requestTranscription transcribes audio with an OpenAI-compatible transcription endpoint
+func requestTranscription(ctx context.Context, endpoint chatEndpoint, filename string, audio []byte, model, language string) (string, error) {
+	if len(audio) > MaxTranscriptionSize {
+		return "", fmt.Errorf("audio file is %d MB, %s accepts at most %d MB", len(audio)>>20, endpoint.Name, MaxTranscriptionSize>>20)
+	}
+
+	client, err := sharedHTTPClient(endpoint)
+	if err != nil {
+		return "", err
+	}
+
+	// 创建 multipart 请求体
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	part, err := writer.CreateFormFile("file", filename)
+	if err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	part.Write(audio)
+	writer.WriteField("model", model)
+	writer.WriteField("response_format", "json")
+	if language != "" {
+		writer.WriteField("language", language)
+	}
+	if err := writer.Close(); err != nil {
+		return "", fmt.Errorf("error creating request: %v", err)
+	}
+	endpoint.ContentType = writer.FormDataContentType()
+
+	util.DebugLog(util.ModuleProvider, "Transcribing %s (%d bytes) with %s model %s", filename, len(audio), endpoint.Name, model)
+	resp, err := withRetry(ctx, endpoint.Name, loadRetryPolicy(), func(status string, retryAt time.Time) {
+		util.InfoLog(util.ModuleProvider, "%s", FormatRetryStatus(status, retryAt))
+	}, func() (*http.Response, error) {
+		return sendChatRequest(ctx, client, endpoint, body.Bytes())
+	})
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var response transcriptionResponse
+	if err := json.NewDecoder(resp.Body).Decode(&response); err != nil {
+		return "", fmt.Errorf("error decoding %s transcription: %v", endpoint.Name, err)
+	}
+	return response.Text, nil
+}
@@ -0,0 +1,211 @@
+package provider
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/plucury/chait/util"
+)
+
+// ZhipuProvider implements the Provider interface for Zhipu API
+type ZhipuProvider struct {
+	BaseProvider // 嵌入基础提供者结构体
+}
+
+const (
+	zhipuAPIURL                = "https://open.bigmodel.cn/api/paas/v4/chat/completions"
+	zhipuEmbeddingsURL         = "https://open.bigmodel.cn/api/paas/v4/embeddings"
+	zhipuDefaultEmbeddingModel = "embedding-3"
+	zhipuDefaultModel          = "glm-4-plus"
+	zhipuDefaultTemperature    = 0.95 // Default temperature as per Zhipu API documentation
+	zhipuMaxTemperature        = 1.0
+)
+
+// Available models for Zhipu API
+var zhipuAvailableModels = []string{
+	"glm-4-plus",  // GLM-4 flagship model
+	"glm-4-air",   // Cost-effective GLM-4
+	"glm-4-airx",  // Fast GLM-4-Air
+	"glm-4-long",  // GLM-4 with 1M context
+	"glm-4-flash", // Free GLM-4 model
+}
+
+// Available temperature presets for Zhipu API
+var zhipuTemperaturePresets = []TemperaturePreset{
+	{"Precise", 0.1, "Highly deterministic responses for factual queries"},
+	{"Focused", 0.5, "Code generation and data extraction"},
+	{"Balanced", 0.8, "General conversation"},
+	{"Default", 0.95, "Default setting recommended by Zhipu"},
+	{"Creative", 1.0, "Creative writing or poetry"},
+}
+
+// NewZhipuProvider creates a new instance of ZhipuProvider
+func NewZhipuProvider() Provider {
+	provider := &ZhipuProvider{
+		BaseProvider: BaseProvider{
+			Name:               "zhipu",
+			CurrentModel:       zhipuDefaultModel,
+			CurrentTemperature: zhipuDefaultTemperature,
+		},
+	}
+	return provider
+}
+
+// GetName returns the name of the provider
+func (p *ZhipuProvider) GetName() string {
+	return p.Name
+}
+
+// GetDefaultModel returns the default model for this provider
+func (p *ZhipuProvider) GetDefaultModel() string {
+	return zhipuDefaultModel
+}
+
+// GetAvailableModels returns the list of available models for this provider
+func (p *ZhipuProvider) GetAvailableModels() []string {
+	return p.withExtraModels(zhipuAvailableModels)
+}
+
+// GetDefaultTemperature returns the default temperature for this provider
+func (p *ZhipuProvider) GetDefaultTemperature() float64 {
+	return zhipuDefaultTemperature
+}
+
+// GetTemperaturePresets returns the available temperature presets for this provider
+func (p *ZhipuProvider) GetTemperaturePresets() []TemperaturePreset {
+	return zhipuTemperaturePresets
+}
+
+// SetCurrentTemperature sets the current temperature with Zhipu-specific validation
+func (p *ZhipuProvider) SetCurrentTemperature(temp float64) error {
+	// Validate temperature range specific to Zhipu (0-1)
+	if temp < 0 || temp > zhipuMaxTemperature {
+		return fmt.Errorf("Zhipu temperature must be between 0.0 and 1.0")
+	}
+
+	p.CurrentTemperature = temp
+	return nil
+}
+
+// SendStreamingChatRequest sends a streaming chat request to the Zhipu API
+func (p *ZhipuProvider) SendStreamingChatRequest(ctx context.Context, messages []ChatMessage) (<-chan StreamResponse, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, fmt.Errorf("API key not set for Zhipu provider")
+	}
+
+	// 转换系统消息
+	messages, systemPrompt := p.prepareMessages(messages)
+
+	// 创建请求体
+	requestBody := chatCompletionRequest{
+		Model:          p.CurrentModel,
+		Messages:       messages,
+		System:         systemPrompt,
+		Temperature:    p.CurrentTemperature,
+		Stream:         true,
+		StreamOptions:  p.streamOptions(),
+		User:           p.metadataUser(),
+		MaxTokens:      p.maxTokens(),
+		Seed:           p.seed(),
+		SamplingParams: p.Sampling,
+		ResponseFormat: p.responseFormat(),
+	}
+
+	util.DebugLog(util.ModuleProvider, "Using Zhipu model: %s (streaming)", p.CurrentModel)
+	util.DebugLog(util.ModuleProvider, "Using temperature: %.1f", p.CurrentTemperature)
+
+	return streamChatCompletion(ctx, p.newChatEndpoint("Zhipu", zhipuAPIURL), requestBody)
+}
+
+// GetDefaultEmbeddingModel returns the embedding model used when none is given
+func (p *ZhipuProvider) GetDefaultEmbeddingModel() string {
+	return zhipuDefaultEmbeddingModel
+}
+
+// Embeddings computes embeddings with the Zhipu embeddings API
+func (p *ZhipuProvider) Embeddings(ctx context.Context, inputs []string, model string) ([][]float64, *Usage, error) {
+	// 检查 API Key 是否已设置
+	if p.APIKey == "" {
+		return nil, nil, fmt.Errorf("API key not set for Zhipu provider")
+	}
+	if model == "" {
+		model = zhipuDefaultEmbeddingModel
+	}
+	return requestEmbeddings(ctx, p.newChatEndpoint("Zhipu", zhipuEmbeddingsURL), model, p.metadataUser(), inputs)
+}
+
+// SetCurrentModel sets the current model after validating it
+func (p *ZhipuProvider) SetCurrentModel(model string) error {
+	// 验证模型是否有效
+	valid := false
+	for _, m := range p.GetAvailableModels() {
+		if m == model {
+			valid = true
+			break
+		}
+	}
+
+	if !valid {
+		return fmt.Errorf("invalid model: %s. Available models: %v", model, p.GetAvailableModels())
+	}
+
+	p.CurrentModel = model
+	util.DebugLog(util.ModuleProvider, "Zhipu model set to: %s", model)
+	return nil
+}
+
+// LoadConfig loads the provider configuration from the given map
+func (p *ZhipuProvider) LoadConfig(config map[string]interface{}) error {
+	p.loadCommonConfig(config)
+
+	// 加载 API Key
+	if apiKey, ok := config["api_key"].(string); ok {
+		p.APIKey = apiKey
+		util.DebugLog(util.ModuleProvider, "Loaded API key for Zhipu provider")
+	}
+
+	// 加载当前模型
+	if model, ok := config["model"].(string); ok {
+		util.DebugLog(util.ModuleProvider, "Found model in config: %s", model)
+		if err := p.SetCurrentModel(model); err != nil {
+			// 如果模型无效，使用默认模型
+			p.CurrentModel = zhipuDefaultModel
+		}
+	} else {
+		// 如果没有设置模型，使用默认模型
+		util.DebugLog(util.ModuleProvider, "No model found in config, using default model: %s", zhipuDefaultModel)
+		p.CurrentModel = zhipuDefaultModel
+	}
+
+	// 加载温度设置
+	loadTemperature(p, config, zhipuMaxTemperature)
+
+	return nil
+}
+
+// SaveConfig saves the provider configuration to the given map
+func (p *ZhipuProvider) SaveConfig(config map[string]interface{}) {
+	// 保存 API Key
+	config["api_key"] = p.APIKey
+
+	// 保存当前模型
+	config["model"] = p.CurrentModel
+	util.DebugLog(util.ModuleProvider, "Saving Zhipu model to config: %s", p.CurrentModel)
+
+	// 保存温度设置
+	config["temperature"] = p.CurrentTemperature
+
+	p.saveCommonConfig(config)
+}
+
+// IsReady returns whether the provider is ready to use
+// For Zhipu, the provider is ready if the API key is set
+func (p *ZhipuProvider) IsReady() bool {
+	return p.APIKey != ""
+}
+
+func init() {
+	// Register the Zhipu provider
+	Register("zhipu", NewZhipuProvider)
+}
@@ -0,0 +1,150 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/plucury/chait/session"
+	"github.com/spf13/viper"
+)
+
+// defaultMaxConversationMessages is the number of messages above which a conversation is archived
+const defaultMaxConversationMessages = 200
+
+// archiveDoneMsg carries the summary of the older half of a conversation being archived
+type archiveDoneMsg struct {
+	session *session.Session // Session being archived, to ignore conversations replaced meanwhile
+	older   int              // Number of user and assistant messages summarized
+	summary string
+	err     error
+}
+
+// maxConversationMessages returns the number of user and assistant messages above which the
+// older half of a conversation is archived, 0 if conversations are never archived
+func maxConversationMessages() int {
+	if viper.IsSet("max_conversation_messages") {
+		return viper.GetInt("max_conversation_messages")
+	}
+	return defaultMaxConversationMessages
+}
+
+// archiveIfTooLong starts summarizing the older half of a saved conversation longer than
+// max_conversation_messages. The summary is requested in the background and the archive
+// is made by handleArchiveDone.
+func (m *interactiveModel) archiveIfTooLong() tea.Cmd {
+	limit := maxConversationMessages()
+	if limit <= 0 || m.archiving || m.session == nil {
+		return nil
+	}
+	system, _, chat := splitRecent(m.messages, len(m.messages))
+	if len(chat) <= limit {
+		return nil
+	}
+
+	// Archive the older half, up to the start of an exchange
+	older := len(chat) - len(chat)/2
+	for older < len(chat) && chat[older].Type != MessageTypeUser {
+		older++
+	}
+	if older == len(chat) {
+		return nil
+	}
+
+	// Keep what the previous parts were about in the new summary
+	toSummarize := chat[:older]
+	if len(system) > 0 {
+		if _, previous, found := strings.Cut(system[0].Content, summaryHeading); found {
+			toSummarize = append([]Message{{Type: MessageTypeSystem, Content: "Summary of the earlier parts: " + previous}}, toSummarize...)
+		}
+	}
+
+	m.archiving = true
+	s := m.session
+	return func() tea.Msg {
+		summary, err := summarize(toSummarize)
+		return archiveDoneMsg{session: s, older: older, summary: summary, err: err}
+	}
+}
+
+// handleArchiveDone moves the older half of the conversation into the current session,
+// saved as a finished part, and continues in a new session linked to it, starting with
+// the summary in the system prompt
+func (m *interactiveModel) handleArchiveDone(msg archiveDoneMsg) {
+	m.archiving = false
+	if msg.session != m.session {
+		return
+	}
+	fail := func(err error) {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: fmt.Sprintf("Could not archive the older messages of this long conversation: %v", err)})
+	}
+	if msg.err != nil {
+		fail(msg.err)
+		return
+	}
+	if err := m.saveSession(); err != nil {
+		fail(err)
+		return
+	}
+
+	// Split the conversation after the last archived message
+	split, chat := len(m.messages), 0
+	for i, message := range m.messages {
+		if message.Type == MessageTypeUser || message.Type == MessageTypeAssistant {
+			if chat == msg.older {
+				split = i
+				break
+			}
+			chat++
+		}
+	}
+	savedSplit, chat := len(m.session.Messages), 0
+	for i, message := range m.session.Messages {
+		if message.Role == "user" || message.Role == "assistant" {
+			if chat == msg.older {
+				savedSplit = i
+				break
+			}
+			chat++
+		}
+	}
+
+	// The parts continuing a conversation keep its title, the archived ones are numbered
+	archive := m.session
+	next := linkNextPart(archive)
+	archive.Messages = archive.Messages[:savedSplit]
+	if err := session.Save(archive); err != nil {
+		fail(err)
+		return
+	}
+
+	// Continue with the instructions, the summary, the pinned messages and the recent messages
+	var messages, pinned []Message
+	for _, message := range m.messages[:split] {
+		if isInstruction(message.Type) {
+			messages = append(messages, message)
+		} else if message.Pinned {
+			pinned = append(pinned, message)
+		}
+	}
+	if len(messages) == 0 {
+		messages = []Message{{Type: MessageTypeSystem, Content: defaultSystemPrompt}}
+	}
+	instructions, _, _ := strings.Cut(messages[0].Content, summaryHeading)
+	messages[0].Content = instructions + summaryHeading + msg.summary
+	messages = append(messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("The conversation grew beyond %d messages: the first %d were archived as %s (open it with :o %s) and summarized into the system prompt", maxConversationMessages(), msg.older, archive.Title, archive.ID),
+		Note:    true,
+	})
+	messages = append(messages, pinned...)
+	m.messages = append(messages, m.messages[split:]...)
+	m.session = next
+	m.copyMode = false
+	if err := m.saveSession(); err != nil {
+		DebugLog("Error saving conversation: %v", err)
+	}
+	if m.autoScrollBottom {
+		m.scrollToBottom()
+	}
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/spf13/viper"
+)
+
+// maxTextFileSize is the largest text file included in a message
+const maxTextFileSize = 1024 * 1024
+
+// defaultAttachMaxTokens is the estimated tokens above which a file is refused, unless
+// attach_max_tokens is set
+const defaultAttachMaxTokens = 20000
+
+// fileReference matches the @path references expanded when a message is sent
+var fileReference = regexp.MustCompile(`(^|\s)@(\S+)`)
+
+// attachedFile is a text file included in the next message
+type attachedFile struct {
+	Path    string
+	Content string
+}
+
+// attachMaxTokens returns the estimated tokens above which a file is refused, 0 for no limit
+func attachMaxTokens() int {
+	if !viper.IsSet("attach_max_tokens") {
+		return defaultAttachMaxTokens
+	}
+	return max(0, viper.GetInt("attach_max_tokens"))
+}
+
+// expandHome replaces a leading ~/ with the home directory
+func expandHome(path string) string {
+	if strings.HasPrefix(path, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			return filepath.Join(home, path[2:])
+		}
+	}
+	return path
+}
+
+// isImageFile returns true if the file starts like an image
+func isImageFile(path string) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+	head := make([]byte, 512)
+	n, _ := io.ReadFull(f, head)
+	return isImageData(head[:n])
+}
+
+// loadTextFile reads a text file to include in a message, refusing binary files and files
+// above the size and token limits
+func loadTextFile(path string) (attachedFile, error) {
+	info, err := os.Stat(expandHome(path))
+	if err != nil {
+		return attachedFile{}, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if info.IsDir() {
+		return attachedFile{}, fmt.Errorf("%s is a directory", path)
+	}
+	if info.Size() > maxTextFileSize {
+		return attachedFile{}, fmt.Errorf("%s is too large (%s bytes, maximum is %s)", path, formatCount(int(info.Size())), formatCount(maxTextFileSize))
+	}
+	data, err := os.ReadFile(expandHome(path))
+	if err != nil {
+		return attachedFile{}, fmt.Errorf("error reading %s: %v", path, err)
+	}
+	if !utf8.Valid(data) || bytes.IndexByte(data, 0) >= 0 {
+		return attachedFile{}, fmt.Errorf("%s is not a text file", path)
+	}
+	if n, limit := currentTokenizer().Count(string(data)), attachMaxTokens(); limit > 0 && n > limit {
+		return attachedFile{}, fmt.Errorf("%s has ~%s tokens, more than attach_max_tokens (%s)", path, formatCount(n), formatCount(limit))
+	}
+	DebugLog("Loaded text file %s (%d bytes)", path, len(data))
+	return attachedFile{Path: path, Content: string(data)}, nil
+}
+
+// block renders the file as a fenced code block under a header with its path
+func (f attachedFile) block() string {
+	content := strings.TrimRight(f.Content, "\n")
+	fence := codeFence
+	for strings.Contains(content, fence) {
+		fence += "`"
+	}
+	lang := strings.TrimPrefix(filepath.Ext(f.Path), ".")
+	return fmt.Sprintf("File %s (%d lines):\n%s%s\n%s\n%s", f.Path, strings.Count(content, "\n")+1, fence, lang, content, fence)
+}
+
+// summary describes the file for the messages of chait
+func (f attachedFile) summary() string {
+	return fmt.Sprintf("%s (%d lines, ~%s tokens)", f.Path, strings.Count(strings.TrimRight(f.Content, "\n"), "\n")+1, formatCount(currentTokenizer().Count(f.Content)))
+}
+
+// referencedFiles returns the existing files referenced as @path in a message, words
+// starting with @ that are not files, such as handles, are left alone
+func referencedFiles(text string) ([]attachedFile, error) {
+	var files []attachedFile
+	seen := map[string]bool{}
+	for _, match := range fileReference.FindAllStringSubmatch(text, -1) {
+		path := strings.TrimRight(match[2], ".,;:!?)")
+		info, err := os.Stat(expandHome(path))
+		if err != nil || info.IsDir() || seen[path] {
+			continue
+		}
+		seen[path] = true
+		file, err := loadTextFile(path)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, file)
+	}
+	return files, nil
+}
+
+// handleAttachTextFile includes a text file in the next message with ':f <path>'
+func (m *interactiveModel) handleAttachTextFile(path string) {
+	file, err := loadTextFile(path)
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	m.pendingFiles = append(m.pendingFiles, file)
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Attached %s, it will be included in the next message.", file.summary()),
+	})
+}
+
+// withAttachedFiles returns a message followed by the files attached with ':f' and those
+// it references as @path
+func (m *interactiveModel) withAttachedFiles(prompt string) (string, error) {
+	referenced, err := referencedFiles(prompt)
+	if err != nil {
+		return "", err
+	}
+	files := append([]attachedFile{}, m.pendingFiles...)
+	for _, file := range referenced {
+		attached := false
+		for _, pending := range m.pendingFiles {
+			attached = attached || pending.Path == file.Path
+		}
+		if !attached {
+			files = append(files, file)
+		}
+	}
+	if len(files) == 0 {
+		return prompt, nil
+	}
+
+	blocks := make([]string, 0, len(files)+1)
+	if strings.TrimSpace(prompt) != "" {
+		blocks = append(blocks, prompt)
+	}
+	for _, file := range files {
+		blocks = append(blocks, file.block())
+	}
+	return strings.Join(blocks, "\n\n"), nil
+}
@@ -0,0 +1,42 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"errors"
+	"os"
+	"strings"
+
+	"github.com/atotto/clipboard"
+	"github.com/plucury/chait/util"
+)
+
+// osc52Sequence returns the escape sequence asking the terminal to put text in the
+// clipboard, wrapped for tmux to pass it through to the outer terminal
+func osc52Sequence(text string) string {
+	seq := "\x1b]52;c;" + base64.StdEncoding.EncodeToString([]byte(text)) + "\a"
+	if os.Getenv("TMUX") != "" {
+		seq = "\x1bPtmux;" + strings.ReplaceAll(seq, "\x1b", "\x1b\x1b") + "\x1b\\"
+	}
+	return seq
+}
+
+// copyToClipboard copies text to the system clipboard. Over SSH, or when the system clipboard
+// is not available as on headless machines, the terminal is asked to copy it with OSC 52
+// instead, unless the terminal was recorded without support for it.
+func copyToClipboard(text string) error {
+	err := clipboard.WriteAll(text)
+	remote := os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != ""
+	if err == nil && !remote {
+		return nil
+	}
+	if caps, recorded := loadRecordedTerminalCapabilities(); recorded && !caps.OSC52 {
+		return err // nil if the system clipboard worked
+	}
+	if err != nil {
+		util.DebugLog(util.ModuleTUI, "System clipboard unavailable (%v), copying with OSC 52", err)
+	}
+	if _, werr := os.Stdout.WriteString(osc52Sequence(text)); werr != nil {
+		return errors.Join(err, werr)
+	}
+	return nil
+}
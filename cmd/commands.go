@@ -0,0 +1,407 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
+)
+
+// lineCommand is a ':' command of interactive mode. The lineCommands table drives both
+// the dispatch and the generated help (:h, chait help commands, the man page), so the
+// documentation cannot drift from the commands that exist.
+type lineCommand struct {
+	Name    string // e.g. ":p"
+	Args    string // Argument synopsis, e.g. "[provider]"
+	Summary string
+	run     func(m *interactiveModel, args []string)
+}
+
+// Usage returns the command with its argument synopsis
+func (c lineCommand) Usage() string {
+	if c.Args == "" {
+		return c.Name
+	}
+	return c.Name + " " + c.Args
+}
+
+// lineCommands lists the ':' commands in the order they are documented
+var lineCommands []lineCommand
+
+func init() {
+	// Assigned in init because :h renders this table
+	lineCommands = []lineCommand{
+		{":h", "", "Show this message", func(m *interactiveModel, args []string) {
+			m.messages = append(m.messages, helpMessage())
+		}},
+		{":p", "[provider]", "Select providers, or switch directly to the given one", (*interactiveModel).handleProviderCommand},
+		{":p!", "", "Switch back to the configured provider after falling back to a ready one", (*interactiveModel).handleProviderRestoreCommand},
+		{":m", "[model]", "Select models, or switch directly to the given one", (*interactiveModel).handleModelCommand},
+		{":t", "[value]", "Set the temperature", (*interactiveModel).handleTemperatureCommand},
+		{":s", "[prompt]", "Edit the system prompt of the conversation, or replace it with the given one", (*interactiveModel).handleSystemCommand},
+		{":k", "", "Set the API key", func(m *interactiveModel, args []string) {
+			m.enterSettingAPIKeyMode()
+		}},
+		{":c", "", "Start a new conversation", func(m *interactiveModel, args []string) {
+			m.messages = systemMessagesWithProblems()
+			m.streamStalled = false
+			m.session = nil
+			m.pendingImages = nil
+			m.pendingFiles = nil
+		}},
+		{":f", "<path|url>", "Attach a text file, an image file or an image URL to the next message (@path in a message includes a file too)", func(m *interactiveModel, args []string) {
+			m.handleAttachCommand(strings.Join(args, " "))
+		}},
+		{":j", "", "Toggle JSON mode", func(m *interactiveModel, args []string) {
+			util.SetJSONMode(!util.IsJSONMode())
+			state := "disabled"
+			if util.IsJSONMode() {
+				state = "enabled, responses are requested as JSON objects"
+				if model := api.GetCurrentModel(); !api.SupportsJSONMode(model) {
+					state += fmt.Sprintf("\nNote: model %s has no JSON response format, JSON is only asked for in the system prompt.", model)
+				}
+			}
+			m.messages = append(m.messages, Message{
+				Type:    MessageTypeChait,
+				Content: fmt.Sprintf("JSON mode %s", state),
+			})
+		}},
+		{":brief", "", "Toggle brief answers: a brevity instruction and a lower max_tokens", func(m *interactiveModel, args []string) {
+			m.toggleAnswerMode(util.AnswerModeBrief)
+		}},
+		{":detailed", "", "Toggle detailed answers with reasoning and examples", func(m *interactiveModel, args []string) {
+			m.toggleAnswerMode(util.AnswerModeDetailed)
+		}},
+		{":r", "[m|t]", "Drop the last response and send its prompt again, after picking a model (m) or a temperature (t)", (*interactiveModel).handleRegenerateCommand},
+		{":d", "", "Delete the last message and its response from the conversation and the context", func(m *interactiveModel, args []string) {
+			m.handleDeleteCommand()
+		}},
+		{":y", "", "Copy the whole last response to the clipboard", func(m *interactiveModel, args []string) {
+			m.handleCopyCommand()
+		}},
+		{":pin", "[off]", "Always send the last exchange as context however old it gets, ':pin off' unpins all messages (p pins responses in copy mode)", (*interactiveModel).handlePinCommand},
+		{":o", "[name|id]", "Pick a saved conversation to open, or open the given one", (*interactiveModel).handleOpenCommand},
+		{":resume", "[name|id]", "Continue the most recent other saved conversation, or the given one", (*interactiveModel).handleResumeCommand},
+		{":compare", "[providers|off]", "Send the next messages to several providers and compare the responses side by side", (*interactiveModel).handleCompareCommand},
+		{":params", "[<name> <value>]", "Show or set top_p, frequency_penalty and presence_penalty", (*interactiveModel).handleParamsCommand},
+		{":meta", "[<field> <value>]", "Show or edit the title, tags, notes and model of the conversation", (*interactiveModel).handleMetaCommand},
+		{":share", "", "Upload the conversation with secrets redacted to the configured paste service, after confirmation", func(m *interactiveModel, args []string) {
+			m.handleShareCommand()
+		}},
+		{":theme", "[name]", "List the color themes, or switch to the given one", (*interactiveModel).handleThemeCommand},
+		{":persona", "[name]", "Select a persona, or apply the given one for this session: its system prompt, provider, model and temperature", (*interactiveModel).handlePersonaCommand},
+		{":log", "level [module] <level>", "Change the log level (error, warn, info, debug, trace)", (*interactiveModel).handleLogCommand},
+	}
+}
+
+// findLineCommand returns the ':' command with the given name
+func findLineCommand(name string) (lineCommand, bool) {
+	for _, c := range lineCommands {
+		if c.Name == name {
+			return c, true
+		}
+	}
+	return lineCommand{}, false
+}
+
+// handleLineCommand runs ':' commands, which are confirmed with Enter and may take arguments
+// on the same line, e.g. ":t 0.3" or ":m gpt-4o-mini"
+// It returns false if the line is not a command and should be sent as a message, as is an
+// input of several lines such as pasted text
+func (m *interactiveModel) handleLineCommand(line string) bool {
+	fields := strings.Fields(line)
+	if len(fields) == 0 || !strings.HasPrefix(fields[0], ":") || isMultiline(line) {
+		return false
+	}
+
+	command, ok := findLineCommand(fields[0])
+	if !ok {
+		return false
+	}
+	// Cleared first, commands such as ':s' fill the input
+	m.input = []rune{}
+	m.cursor = 0
+	command.run(m, fields[1:])
+	m.scrollToBottom()
+	return true
+}
+
+// selectors returns the selector widgets, at most one of them is shown
+func (m *interactiveModel) selectors() []*selectorWidget {
+	return []*selectorWidget{&m.providerSelector, &m.modelSelector, &m.temperatureSelector, &m.sessionSelector, &m.personaSelector}
+}
+
+// activateSelector shows one of the selectors and hides the others
+func (m *interactiveModel) activateSelector(selector *selectorWidget) {
+	for _, s := range m.selectors() {
+		if s == selector {
+			s.activate()
+		} else {
+			s.deactivate()
+		}
+	}
+}
+
+// selectorActive returns true if one of the selectors is shown
+func (m interactiveModel) selectorActive() bool {
+	return m.activeSelector() != nil
+}
+
+// activeSelector returns the selector shown, or nil
+func (m *interactiveModel) activeSelector() *selectorWidget {
+	for _, s := range m.selectors() {
+		if s.isActive {
+			return s
+		}
+	}
+	return nil
+}
+
+// handleProviderCommand switches provider: ":p" opens the selector, ":p <name>" switches directly
+func (m *interactiveModel) handleProviderCommand(args []string) {
+	if len(args) == 0 {
+		m.activateSelector(&m.providerSelector)
+		return
+	}
+	if len(args) > 1 {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :p [provider]"})
+		return
+	}
+	if err := api.SetActiveProvider(args[0]); err != nil {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("%v (available: %s)", err, strings.Join(api.GetAvailableProviderNames(), ", ")),
+		})
+		return
+	}
+	refreshConfig(m)
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Switched to %s (model: %s)", args[0], api.GetCurrentModel()),
+	})
+}
+
+// handleProviderRestoreCommand switches back to the configured provider with ':p!', after
+// use_ready_provider replaced it at startup, asking for its API key if it still has none
+func (m *interactiveModel) handleProviderRestoreCommand(args []string) {
+	name := viper.GetString("provider")
+	if name == "" {
+		name = api.DefaultProvider
+	}
+	if name == api.GetActiveProviderName() {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: fmt.Sprintf("Already using the configured provider %s", name)})
+		return
+	}
+	if err := api.UseProvider(name); err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	unreadyProvider = ""
+	refreshConfig(m)
+	util.InfoLog(util.ModuleCLI, "Switched back to the configured provider %s", name)
+	if !api.GetActiveProvider().IsReady() {
+		m.enterSettingAPIKeyMode()
+		return
+	}
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Switched back to %s (model: %s)", name, api.GetCurrentModel()),
+	})
+}
+
+// handleModelCommand switches model: ":m" opens the selector, ":m <name>" switches directly
+func (m *interactiveModel) handleModelCommand(args []string) {
+	if len(args) == 0 {
+		m.activateSelector(&m.modelSelector)
+		return
+	}
+	if len(args) > 1 {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :m [model]"})
+		return
+	}
+	previousProvider := api.GetActiveProviderName()
+	if err := api.SetModel(args[0]); err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: withExtraModelsHint(err, api.GetActiveProviderName())})
+		return
+	}
+	refreshConfig(m)
+	content := fmt.Sprintf("Model set to %s", api.GetCurrentModel())
+	if api.GetActiveProviderName() != previousProvider {
+		content = fmt.Sprintf("Switched to %s, model set to %s", api.GetActiveProviderName(), api.GetCurrentModel())
+	}
+	if api.GetCurrentModel() != args[0] {
+		content += fmt.Sprintf(" (alias %s)", args[0])
+	}
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: content})
+}
+
+// withExtraModelsHint explains how to use a model chait does not know yet, e.g. a fine-tuned one
+func withExtraModelsHint(err error, providerName string) string {
+	return fmt.Sprintf("%v\nTo use a model that is not listed, such as a fine-tuned one, add it to providers.%s.extra_models in the config", err, providerName)
+}
+
+// toggleAnswerMode switches to an answer mode, or back to the usual answers if it is on
+func (m *interactiveModel) toggleAnswerMode(mode string) {
+	if util.AnswerMode() == mode {
+		util.SetAnswerMode("")
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: fmt.Sprintf("%s answers disabled", strings.ToUpper(mode[:1])+mode[1:])})
+		return
+	}
+	util.SetAnswerMode(mode)
+	content := "Brief answers enabled: responses are asked to be short and capped in length"
+	if mode == util.AnswerModeDetailed {
+		content = "Detailed answers enabled: responses are asked to explain their reasoning with examples"
+	}
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: content})
+}
+
+// handleTemperatureCommand sets the temperature: ":t" opens the selector, ":t <value>" sets it directly
+func (m *interactiveModel) handleTemperatureCommand(args []string) {
+	if len(args) == 0 {
+		m.activateSelector(&m.temperatureSelector)
+		return
+	}
+	temperature, err := strconv.ParseFloat(args[0], 64)
+	if err != nil || len(args) > 1 {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :t [temperature]"})
+		return
+	}
+	if err := api.SetProviderTemperature(api.GetActiveProvider(), temperature); err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	refreshConfig(m)
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Temperature set to %.1f", temperature),
+	})
+}
+
+// handleLogCommand shows or changes log levels: ":log", ":log level <level>", ":log level <module> <level>"
+func (m *interactiveModel) handleLogCommand(args []string) {
+	if len(args) == 0 {
+		var sb strings.Builder
+		sb.WriteString("Log levels:")
+		for _, module := range util.LogModules {
+			sb.WriteString(fmt.Sprintf("\n- %s: %s", module, util.GetLogLevel(module)))
+		}
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: sb.String()})
+		return
+	}
+
+	if args[0] != "level" || len(args) < 2 || len(args) > 3 {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: "Usage: :log level [module] <off|error|warn|info|debug|trace>",
+		})
+		return
+	}
+
+	module := ""
+	levelName := args[1]
+	if len(args) == 3 {
+		module, levelName = args[1], args[2]
+		if !isLogModule(module) {
+			m.messages = append(m.messages, Message{
+				Type:    MessageTypeError,
+				Content: fmt.Sprintf("Unknown log module %q (expected one of: %s)", module, strings.Join(util.LogModules, ", ")),
+			})
+			return
+		}
+	}
+
+	level, err := util.ParseLogLevel(levelName)
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+
+	util.SetLogLevel(module, level)
+	target := "all modules"
+	if module != "" {
+		target = module
+	}
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Log level for %s set to %s (logs are written to %s)", target, level, interactiveLogPath()),
+	})
+}
+
+// samplingParamNames lists the parameters accepted by :params in display order
+var samplingParamNames = []string{"top_p", "frequency_penalty", "presence_penalty"}
+
+// samplingParam returns a pointer to the named parameter
+func samplingParam(params *api.SamplingParams, name string) *float64 {
+	switch name {
+	case "top_p":
+		return &params.TopP
+	case "frequency_penalty":
+		return &params.FrequencyPenalty
+	case "presence_penalty":
+		return &params.PresencePenalty
+	}
+	return nil
+}
+
+// handleParamsCommand shows or changes sampling parameters: ":params", ":params <name> <value|default>", ":params reset"
+func (m *interactiveModel) handleParamsCommand(args []string) {
+	activeProvider := api.GetActiveProvider()
+	params := activeProvider.GetSamplingParams()
+
+	switch {
+	case len(args) == 0:
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("Sampling parameters for %s:", activeProvider.GetName()))
+		for _, name := range samplingParamNames {
+			value := "default"
+			if v := *samplingParam(&params, name); v != 0 {
+				value = strconv.FormatFloat(v, 'f', -1, 64)
+			}
+			sb.WriteString(fmt.Sprintf("\n- %s: %s", name, value))
+		}
+		sb.WriteString("\nChange with ':params <name> <value>', restore with ':params <name> default' or ':params reset'")
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: sb.String()})
+		return
+	case len(args) == 1 && args[0] == "reset":
+		params = api.SamplingParams{}
+	case len(args) == 2 && samplingParam(&params, args[0]) != nil:
+		value := 0.0
+		if args[1] != "default" {
+			v, err := strconv.ParseFloat(args[1], 64)
+			if err != nil {
+				m.messages = append(m.messages, Message{Type: MessageTypeError, Content: fmt.Sprintf("Invalid value %q for %s", args[1], args[0])})
+				return
+			}
+			value = v
+		}
+		*samplingParam(&params, args[0]) = value
+	default:
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("Usage: :params [<%s> <value|default>] or :params reset", strings.Join(samplingParamNames, "|")),
+		})
+		return
+	}
+
+	if err := api.SetProviderSamplingParams(activeProvider, params); err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Sampling parameters for %s updated", activeProvider.GetName()),
+	})
+}
+
+// isLogModule returns true if the name is a known log module
+func isLogModule(name string) bool {
+	for _, module := range util.LogModules {
+		if module == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,338 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/mattn/go-runewidth"
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the compare command
+var compareProviderNames []string
+
+// Columns narrower than this are stacked instead of shown side by side
+const minCompareColumnWidth = 24
+
+// compareColumnSeparator separates the responses shown side by side
+const compareColumnSeparator = " │ "
+
+// compareResult is the response of one provider to a compared prompt
+type compareResult struct {
+	Provider string
+	Model    string
+	Response string
+	Err      error
+	Elapsed  time.Duration
+	Usage    *api.Usage
+}
+
+// compareDoneMsg carries the results of a comparison started in interactive mode
+type compareDoneMsg struct {
+	ctx     context.Context // Context of the comparison, to ignore cancelled ones
+	results []compareResult
+}
+
+// compareCmd represents the compare command
+var compareCmd = &cobra.Command{
+	Use:   "compare [prompt]",
+	Short: "Send a prompt to several providers and compare the responses",
+	Long: `Send the same prompt to several providers concurrently and show their responses
+side by side, with the time each provider took. The prompt is read from the arguments
+or from standard input.
+
+Without --providers, the prompt is sent to every provider with an API key. Narrow
+terminals show the responses one after another instead of side by side.
+
+Example:
+  chait compare "Explain the CAP theorem in two sentences" --providers openai,deepseek`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		prompt := strings.Join(args, " ")
+		if prompt == "" {
+			if stat, err := os.Stdin.Stat(); err == nil && (stat.Mode()&os.ModeCharDevice) == 0 {
+				data, err := io.ReadAll(os.Stdin)
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "Error reading standard input: %v\n", err)
+					os.Exit(1)
+				}
+				prompt = strings.TrimSpace(string(data))
+			}
+		}
+		if prompt == "" {
+			fmt.Fprintln(os.Stderr, "Error: compare requires a prompt")
+			os.Exit(2)
+		}
+
+		providers, err := resolveCompareProviders(compareProviderNames)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		fmt.Fprintf(os.Stderr, "Sending the prompt to %s…\n", providerList(providers))
+		messages := append(systemChatMessages(), api.ChatMessage{Role: "user", Content: prompt})
+		results := runComparison(context.Background(), providers, messages)
+
+		width := 100
+		if w, _, err := term.GetSize(os.Stdout.Fd()); err == nil && w > 0 {
+			width = w
+		}
+		fmt.Println(renderComparison(results, width))
+
+		for _, r := range results {
+			if r.Err == nil {
+				return
+			}
+		}
+		os.Exit(1)
+	},
+}
+
+// resolveCompareProviders returns the named providers, or every provider with an API key
+func resolveCompareProviders(names []string) ([]provider.Provider, error) {
+	if len(names) == 0 {
+		providers := api.GetReadyProviders()
+		if len(providers) == 0 {
+			return nil, fmt.Errorf("no provider has an API key")
+		}
+		return providers, nil
+	}
+
+	var providers []provider.Provider
+	seen := map[string]bool{}
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		p, ok := api.GetProvider(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown provider %s (available: %s)", name, strings.Join(api.GetAvailableProviderNames(), ", "))
+		}
+		if !p.IsReady() {
+			return nil, fmt.Errorf("provider %s is not ready, please set its API key first", name)
+		}
+		providers = append(providers, p)
+	}
+	if len(providers) == 0 {
+		return nil, fmt.Errorf("no provider to compare")
+	}
+	return providers, nil
+}
+
+// providerList returns the names of the providers separated by commas
+func providerList(providers []provider.Provider) string {
+	names := make([]string, len(providers))
+	for i, p := range providers {
+		names[i] = p.GetName()
+	}
+	return strings.Join(names, ", ")
+}
+
+// runComparison sends the messages to every provider concurrently and records the usage
+// of each response. The results are in the order of the providers.
+func runComparison(ctx context.Context, providers []provider.Provider, messages []api.ChatMessage) []compareResult {
+	results := make([]compareResult, len(providers))
+	var wg sync.WaitGroup
+	for i, p := range providers {
+		results[i] = compareResult{Provider: p.GetName(), Model: p.GetCurrentModel()}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			results[i].Response, results[i].Usage, results[i].Err = collectResponse(ctx, p, messages)
+			results[i].Elapsed = time.Since(start)
+		}()
+	}
+	wg.Wait()
+
+	for _, r := range results {
+		if r.Usage != nil {
+			recordUsage(r.Provider, r.Model, r.Usage)
+		}
+	}
+	return results
+}
+
+// collectResponse sends the messages to a provider and returns the whole response
+func collectResponse(ctx context.Context, p provider.Provider, messages []api.ChatMessage) (string, *api.Usage, error) {
+	streamChan, err := p.SendStreamingChatRequest(ctx, messages)
+	if err != nil {
+		return "", nil, err
+	}
+
+	var response strings.Builder
+	var usage *api.Usage
+	for streamResp := range streamChan {
+		if streamResp.Error != nil {
+			return response.String(), usage, streamResp.Error
+		}
+		if streamResp.Usage != nil {
+			usage = streamResp.Usage
+		}
+		response.WriteString(streamResp.Content)
+	}
+	return response.String(), usage, ctx.Err()
+}
+
+// compareHeader returns the title and the details shown above a response
+func compareHeader(r compareResult) (string, string) {
+	details := r.Elapsed.Round(100 * time.Millisecond).String()
+	if r.Usage != nil {
+		details += fmt.Sprintf(" · %d tokens", r.Usage.TotalTokens)
+	}
+	return fmt.Sprintf("%s (%s)", r.Provider, r.Model), details
+}
+
+// compareBody returns the text shown for a result
+func compareBody(r compareResult) string {
+	body := strings.TrimSpace(strings.ReplaceAll(r.Response, "\t", "    "))
+	if r.Err != nil {
+		body = strings.TrimSpace(body + "\n\nError: " + r.Err.Error())
+	}
+	return body
+}
+
+// renderComparison lays the responses out side by side in the given width, or one after
+// another when the columns would be too narrow
+func renderComparison(results []compareResult, width int) string {
+	n := len(results)
+	columnWidth := (width - runewidth.StringWidth(compareColumnSeparator)*(n-1)) / max(n, 1)
+	if n <= 1 || columnWidth < minCompareColumnWidth {
+		var sb strings.Builder
+		for i, r := range results {
+			if i > 0 {
+				sb.WriteString("\n\n")
+			}
+			title, details := compareHeader(r)
+			fmt.Fprintf(&sb, "%s · %s\n%s\n%s", title, details, strings.Repeat("─", max(width, 1)), wrapText(compareBody(r), width, 0))
+		}
+		return sb.String()
+	}
+
+	columns := make([][]string, n)
+	height := 0
+	for i, r := range results {
+		title, details := compareHeader(r)
+		lines := []string{title, details, strings.Repeat("─", columnWidth)}
+		lines = append(lines, strings.Split(wrapText(compareBody(r), columnWidth, 0), "\n")...)
+		columns[i] = lines
+		height = max(height, len(lines))
+	}
+
+	rows := make([]string, height)
+	for row := range rows {
+		cells := make([]string, n)
+		for i, lines := range columns {
+			cell := ""
+			if row < len(lines) {
+				cell = runewidth.Truncate(lines[row], columnWidth, "…")
+			}
+			if i < n-1 {
+				cell = runewidth.FillRight(cell, columnWidth)
+			}
+			cells[i] = cell
+		}
+		rows[row] = strings.TrimRight(strings.Join(cells, compareColumnSeparator), " ")
+	}
+	return strings.Join(rows, "\n")
+}
+
+// handleCompareCommand turns compare mode on or off: ":compare openai,deepseek" sends the
+// next messages to these providers, ":compare" alone toggles it for every ready provider
+func (m *interactiveModel) handleCompareCommand(args []string) {
+	if len(args) > 1 {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :compare [providers|off]"})
+		return
+	}
+	if (len(args) == 0 && len(m.compareProviders) > 0) || (len(args) == 1 && args[0] == "off") {
+		m.compareProviders = nil
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "Compare mode disabled"})
+		return
+	}
+
+	var names []string
+	if len(args) == 1 {
+		names = strings.Split(args[0], ",")
+	}
+	providers, err := resolveCompareProviders(names)
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	m.compareProviders = providers
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Compare mode enabled: messages are sent to %s and the responses shown side by side. Comparisons are not added to the conversation. Use :compare off to go back.", providerList(providers)),
+	})
+}
+
+// startComparison sends a prompt with the current conversation to the providers of compare
+// mode, cancelled with Esc like a response
+func (m *interactiveModel) startComparison(prompt string) tea.Cmd {
+	ctx, cancel := context.WithCancel(context.Background())
+	m.cancelStream = cancel
+	m.compareCtx = ctx
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: prompt, Compare: []compareResult{}})
+
+	providers := m.compareProviders
+	messages := append(m.getRecentMessages(), api.ChatMessage{Role: "user", Content: prompt})
+	return func() tea.Msg {
+		return compareDoneMsg{ctx: ctx, results: runComparison(ctx, providers, messages)}
+	}
+}
+
+// handleCompareDone shows the results of the comparison in its message
+func (m *interactiveModel) handleCompareDone(msg compareDoneMsg) {
+	if msg.ctx != m.compareCtx {
+		return
+	}
+	m.compareCtx = nil
+	m.cancelStream = nil
+	m.enableInput = true
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Compare != nil {
+			m.messages[i].Compare = msg.results
+			break
+		}
+	}
+	if m.autoScrollBottom {
+		m.scrollToBottom()
+	}
+}
+
+// formatComparison renders a comparison message: the prompt, then the responses
+func (m interactiveModel) formatComparison(msg Message, index int) []messageWithType {
+	prompt := "> " + msg.Content
+	if m.textWidth() > 0 {
+		prompt = "> " + wrapText(msg.Content, m.textWidth(), 2)
+	}
+	lines := []messageWithType{{Type: MessageTypeUser, Content: prompt + "\n", Index: index}}
+
+	body := "Comparing responses…"
+	if len(msg.Compare) > 0 {
+		width := m.textWidth()
+		if width <= 0 {
+			width = 80
+		}
+		body = renderComparison(msg.Compare, width)
+	}
+	return append(lines, messageWithType{Type: MessageTypeAssistant, Content: body + "\n", Index: index})
+}
+
+func init() {
+	rootCmd.AddCommand(compareCmd)
+
+	compareCmd.Flags().StringSliceVar(&compareProviderNames, "providers", nil, "Comma-separated providers to compare (default: every provider with an API key)")
+}
@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"strings"
 
+	"github.com/plucury/chait/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
 )
@@ -43,7 +44,7 @@ func setConfig(key, value string) {
 		}
 	}
 
-	if err := viper.WriteConfig(); err != nil {
+	if err := util.WriteConfig(); err != nil {
 		fmt.Printf("Error writing config: %v\n", err)
 		return
 	}
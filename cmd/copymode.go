@@ -0,0 +1,187 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+)
+
+// Columns moved by each left/right key press in copy mode
+const horizontalScrollStep = 8
+
+// isCodeFence returns true if the line opens or closes a fenced code block
+func isCodeFence(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), "```")
+}
+
+// wrapMessageText wraps the text of a message like wrapText, except that lines inside code
+// blocks are kept whole when noWrap is set: they are clipped to the width instead, starting
+// offset columns from the left
+func wrapMessageText(text string, width, prefixLen int, noWrap bool, offset int) string {
+	if !noWrap || width <= 0 {
+		return wrapText(text, width, prefixLen)
+	}
+
+	lines := strings.Split(text, "\n")
+	inCode := false
+	for i, line := range lines {
+		switch {
+		case isCodeFence(line):
+			inCode = !inCode
+			lines[i] = wrapText(line, width, prefixLen)
+		case inCode:
+			lines[i] = clipLine(line, offset, width-prefixLen)
+		default:
+			lines[i] = wrapText(line, width, prefixLen)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// clipLine returns the part of a line that is visible from column offset in the given width
+func clipLine(line string, offset, width int) string {
+	var sb strings.Builder
+	col, used := 0, 0
+	for _, r := range line {
+		w := runewidth.RuneWidth(r)
+		if col < offset {
+			// Skip wide characters cut by the left edge as well
+			col += w
+			continue
+		}
+		if used+w > width {
+			break
+		}
+		sb.WriteRune(r)
+		used += w
+	}
+	return sb.String()
+}
+
+// codeBlockWidth returns the width of the longest code block line of a text
+func codeBlockWidth(text string) int {
+	widest := 0
+	inCode := false
+	for _, line := range strings.Split(text, "\n") {
+		if isCodeFence(line) {
+			inCode = !inCode
+			continue
+		}
+		if inCode {
+			widest = max(widest, runewidth.StringWidth(line))
+		}
+	}
+	return widest
+}
+
+// enterCopyMode focuses the last response so its wrapping can be toggled and its code
+// blocks scrolled horizontally
+func (m *interactiveModel) enterCopyMode() {
+	focus := m.nextResponse(len(m.messages), -1)
+	if focus < 0 {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "There is no response to focus yet."})
+		m.scrollToBottom()
+		return
+	}
+	m.copyMode = true
+	m.copyFocus = focus
+	m.autoScrollBottom = false
+	m.scrollToMessage(focus)
+}
+
+// nextResponse returns the index of the first assistant message from start in the given
+// direction, -1 if there is none
+func (m *interactiveModel) nextResponse(start, direction int) int {
+	for i := start + direction; i >= 0 && i < len(m.messages); i += direction {
+		if m.messages[i].Type == MessageTypeAssistant {
+			return i
+		}
+	}
+	return -1
+}
+
+// handleCopyModeKey handles the keys of copy mode, where up/down move between responses,
+// w toggles soft wrap of the focused response and left/right scroll its code blocks
+func (m interactiveModel) handleCopyModeKey(msg tea.KeyMsg) (tea.Model, tea.Cmd) {
+	if m.copyFocus >= len(m.messages) {
+		m.copyMode = false
+		return m, nil
+	}
+	focused := &m.messages[m.copyFocus]
+
+	key := msg.String()
+	if keyActionFor(key) == actionCopyMode {
+		key = "q" // The key of copy mode leaves it too
+	}
+	switch key {
+	case "esc", "ctrl+c", "q":
+		m.copyMode = false
+		m.scrollToBottom()
+		m.autoScrollBottom = true
+	case "up", "k":
+		if i := m.nextResponse(m.copyFocus, -1); i >= 0 {
+			m.copyFocus = i
+			m.scrollToMessage(i)
+		}
+	case "down", "j":
+		if i := m.nextResponse(m.copyFocus, 1); i >= 0 {
+			m.copyFocus = i
+			m.scrollToMessage(i)
+		}
+	case "w":
+		focused.NoWrap = !focused.NoWrap
+		focused.HScroll = 0
+	case "p":
+		focused.Pinned = !focused.Pinned
+		m.autoSave()
+	case "left", "h":
+		focused.HScroll = max(0, focused.HScroll-horizontalScrollStep)
+	case "right", "l":
+		if focused.NoWrap {
+			// Stop once the end of the longest line is visible
+			limit := max(0, codeBlockWidth(focused.Content)-(m.textWidth()-len(string(focused.Type))-2))
+			focused.HScroll = min(limit, focused.HScroll+horizontalScrollStep)
+		}
+	case "pgup":
+		m.scrollPageUp()
+	case "pgdown":
+		m.scrollPageDown()
+	}
+	return m, nil
+}
+
+// copyModeStatus describes the focused response and the keys of copy mode
+func (m interactiveModel) copyModeStatus() string {
+	responses, position := 0, 0
+	for i, msg := range m.messages {
+		if msg.Type == MessageTypeAssistant {
+			responses++
+			if i == m.copyFocus {
+				position = responses
+			}
+		}
+	}
+
+	wrap := "w: stop wrapping code"
+	if m.copyFocus < len(m.messages) && m.messages[m.copyFocus].NoWrap {
+		wrap = fmt.Sprintf("w: wrap code, ←/→: scroll (column %d)", m.messages[m.copyFocus].HScroll)
+	}
+	pin := "p: pin"
+	if m.copyFocus < len(m.messages) && m.messages[m.copyFocus].Pinned {
+		pin = "p: unpin"
+	}
+	return fmt.Sprintf("-- COPY -- response %d/%d  ↑/↓: move, %s, %s, esc: exit", position, responses, wrap, pin)
+}
+
+// scrollToMessage scrolls so the message with the given index starts at the top of the viewport
+func (m *interactiveModel) scrollToMessage(index int) {
+	for i, line := range m.getFormattedMessageLines() {
+		if line.Index == index {
+			m.scrollPos = i
+			break
+		}
+	}
+	m.scrollDown(0) // Clamp to the bottom of the conversation
+}
@@ -0,0 +1,6 @@
+//go:build debug
+
+package cmd
+
+// debugBuild enables developer tools such as the F10 layout overlay, build with -tags debug
+const debugBuild = true
@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// Flags for the doctor command
+var doctorTerminal bool
+
+// doctorCmd represents the doctor command
+var doctorCmd = &cobra.Command{
+	Use:   "doctor",
+	Short: "Diagnose the chait environment",
+	Long: `Diagnose the chait environment.
+
+Without flags, check each provider with an API key like "chait providers --check":
+send a minimal request and report whether it is reachable, whether it accepts the key,
+and its latency. The command exits with status 1 if a provider fails the check.
+
+With --terminal, detect the features of the current terminal (mouse, true color,
+OSC 52 clipboard, alternate screen, bracketed paste, image protocols) and record
+them so interactive mode can adapt to this terminal.`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if doctorTerminal {
+			runTerminalDoctor()
+			return
+		}
+		if !runProviderChecks() {
+			os.Exit(1)
+		}
+	},
+}
+
+// runTerminalDoctor prints and records the capabilities of the current terminal
+func runTerminalDoctor() {
+	caps := detectTerminalCapabilities()
+
+	fmt.Printf("Terminal: %s\n", caps.Terminal)
+	printCapability("Interactive TTY", caps.TTY)
+	printCapability("Mouse support", caps.Mouse)
+	printCapability("True color", caps.TrueColor)
+	printCapability("OSC 52 clipboard", caps.OSC52)
+	printCapability("Alternate screen", caps.AltScreen)
+	printCapability("Bracketed paste", caps.BracketedPaste)
+	graphics := caps.Graphics
+	if graphics == "" {
+		graphics = "none"
+	}
+	fmt.Printf("  %-18s %s\n", "Graphics protocol", graphics)
+
+	if err := recordTerminalCapabilities(caps); err != nil {
+		fmt.Fprintf(os.Stderr, "Error recording terminal capabilities: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("\nRecorded in %s, interactive mode will use these settings in this terminal.\n", terminalCapabilitiesPath())
+}
+
+// printCapability prints a single capability as yes/no
+func printCapability(name string, supported bool) {
+	status := "no"
+	if supported {
+		status = "yes"
+	}
+	fmt.Printf("  %-18s %s\n", name, status)
+}
+
+func init() {
+	rootCmd.AddCommand(doctorCmd)
+
+	doctorCmd.Flags().BoolVar(&doctorTerminal, "terminal", false, "Detect, print and record the capabilities of the current terminal")
+}
@@ -0,0 +1,185 @@
+package cmd
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/plucury/chait/api"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the embed command
+var (
+	embedFormat   string
+	embedModel    string
+	embedProvider string
+	embedLines    bool
+)
+
+// embedInput is a text to embed and where it was read from
+type embedInput struct {
+	Source string
+	Index  int
+	Text   string
+}
+
+// embedOutput is a JSON record of the embed command
+type embedOutput struct {
+	Source    string    `json:"source"`
+	Index     int       `json:"index"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// embedCmd represents the embed command
+var embedCmd = &cobra.Command{
+	Use:   "embed [file...]",
+	Short: "Compute text embeddings",
+	Long: `Compute embeddings of files, or of standard input when no file is given, and
+print the vectors as JSON or CSV.
+
+Each file is embedded as a whole, or line by line with --lines. Empty inputs are
+skipped. Embeddings are supported by the openai, together and zhipu providers.
+
+Examples:
+  chait embed notes.md
+  cat faq.txt | chait embed --lines --format csv
+  chait embed -p openai -m text-embedding-3-large docs/*.md`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if embedFormat != "json" && embedFormat != "csv" {
+			fmt.Fprintf(os.Stderr, "Error: invalid format %q, use json or csv\n", embedFormat)
+			os.Exit(2)
+		}
+
+		inputs, err := readEmbedInputs(args, embedLines)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(inputs) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: nothing to embed")
+			os.Exit(2)
+		}
+
+		if embedProvider != "" {
+			if err := api.UseProvider(embedProvider); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+		}
+		p := api.GetActiveProvider()
+		if !p.IsReady() {
+			fmt.Fprintf(os.Stderr, "Error: provider %s is not ready, please set its API key first\n", p.GetName())
+			os.Exit(1)
+		}
+
+		texts := make([]string, len(inputs))
+		for i, input := range inputs {
+			texts[i] = input.Text
+		}
+		vectors, _, err := api.Embeddings(context.Background(), p, texts, embedModel)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if embedFormat == "csv" {
+			err = writeEmbeddingsCSV(os.Stdout, inputs, vectors)
+		} else {
+			err = writeEmbeddingsJSON(os.Stdout, inputs, vectors)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// readEmbedInputs reads the texts to embed from the files, or from stdin without files
+func readEmbedInputs(files []string, perLine bool) ([]embedInput, error) {
+	if len(files) == 0 {
+		files = []string{"-"}
+	}
+
+	var inputs []embedInput
+	for _, file := range files {
+		var data []byte
+		var err error
+		if file == "-" {
+			data, err = io.ReadAll(os.Stdin)
+		} else {
+			data, err = os.ReadFile(file)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("error reading %s: %v", file, err)
+		}
+
+		source := file
+		if file == "-" {
+			source = "stdin"
+		}
+		if !perLine {
+			if text := strings.TrimSpace(string(data)); text != "" {
+				inputs = append(inputs, embedInput{Source: source, Text: text})
+			}
+			continue
+		}
+		for i, line := range strings.Split(string(data), "\n") {
+			if text := strings.TrimSpace(line); text != "" {
+				inputs = append(inputs, embedInput{Source: source, Index: i + 1, Text: text})
+			}
+		}
+	}
+	return inputs, nil
+}
+
+// writeEmbeddingsJSON writes the vectors as a JSON array of records
+func writeEmbeddingsJSON(w io.Writer, inputs []embedInput, vectors [][]float64) error {
+	records := make([]embedOutput, len(inputs))
+	for i, input := range inputs {
+		records[i] = embedOutput{Source: input.Source, Index: input.Index, Embedding: vectors[i]}
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(records)
+}
+
+// writeEmbeddingsCSV writes one row per vector, after a header naming the dimensions
+func writeEmbeddingsCSV(w io.Writer, inputs []embedInput, vectors [][]float64) error {
+	writer := csv.NewWriter(w)
+	header := []string{"source", "index"}
+	if len(vectors) > 0 {
+		for i := range vectors[0] {
+			header = append(header, "d"+strconv.Itoa(i))
+		}
+	}
+	if err := writer.Write(header); err != nil {
+		return err
+	}
+
+	for i, input := range inputs {
+		row := []string{input.Source, strconv.Itoa(input.Index)}
+		for _, v := range vectors[i] {
+			row = append(row, strconv.FormatFloat(v, 'g', -1, 64))
+		}
+		if err := writer.Write(row); err != nil {
+			return err
+		}
+	}
+	writer.Flush()
+	return writer.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(embedCmd)
+
+	embedCmd.Flags().StringVar(&embedFormat, "format", "json", "Output format: json or csv")
+	embedCmd.Flags().StringVarP(&embedModel, "model", "m", "", "Embedding model (default: the provider's default embedding model)")
+	embedCmd.Flags().StringVarP(&embedProvider, "provider", "p", "", "Provider to use (default: the active provider)")
+	embedCmd.Flags().BoolVar(&embedLines, "lines", false, "Embed each non-empty line separately")
+}
@@ -0,0 +1,392 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the eval command
+var (
+	evalDataset  string
+	evalJudge    string
+	evalProvider string
+	evalModel    string
+	evalOutput   string
+	evalJSON     bool
+)
+
+// evalCase is a single entry of an evaluation dataset
+type evalCase struct {
+	ID       string `json:"id,omitempty"`
+	System   string `json:"system,omitempty"`
+	Prompt   string `json:"prompt"`
+	Expected string `json:"expected,omitempty"`
+}
+
+// evalResult is the outcome of running a single evalCase
+type evalResult struct {
+	ID          string   `json:"id"`
+	Prompt      string   `json:"prompt"`
+	Response    string   `json:"response"`
+	Expected    string   `json:"expected,omitempty"`
+	Match       *bool    `json:"match,omitempty"`
+	Score       *float64 `json:"score,omitempty"`
+	JudgeReason string   `json:"judge_reason,omitempty"`
+	LatencyMs   int64    `json:"latency_ms"`
+	Error       string   `json:"error,omitempty"`
+}
+
+// evalSummary holds aggregate metrics for an evaluation run
+type evalSummary struct {
+	Provider     string   `json:"provider"`
+	Model        string   `json:"model"`
+	Judge        string   `json:"judge,omitempty"`
+	Total        int      `json:"total"`
+	Errors       int      `json:"errors"`
+	Matched      int      `json:"matched"`
+	WithExpected int      `json:"with_expected"`
+	MatchRate    *float64 `json:"match_rate,omitempty"`
+	MeanScore    *float64 `json:"mean_score,omitempty"`
+	MeanLatency  int64    `json:"mean_latency_ms"`
+}
+
+// judgePrompt asks the judge model for a structured verdict
+const judgePrompt = `You are grading an AI assistant's answer.
+
+Question:
+%s
+
+Reference answer (may be empty):
+%s
+
+Assistant's answer:
+%s
+
+Rate the assistant's answer from 0 to 10 for correctness and helpfulness.
+Respond with JSON only, in the form {"score": <number>, "reason": "<short explanation>"}.`
+
+// evalCmd represents the eval command
+var evalCmd = &cobra.Command{
+	Use:   "eval",
+	Short: "Evaluate prompts from a dataset",
+	Long: `Run every prompt of a JSONL dataset against the current model and report aggregate metrics.
+
+Each dataset line is a JSON object:
+  {"id": "q1", "prompt": "What is the capital of France?", "expected": "Paris"}
+
+When "expected" is present, the response is checked to contain it. With --judge,
+a second model scores each answer from 0 to 10.
+
+Example:
+  chait eval --dataset qa.jsonl --judge gpt-4o
+  chait eval --dataset qa.jsonl --judge openai:gpt-4o --output results.jsonl`,
+	Run: func(cmd *cobra.Command, args []string) {
+		if evalDataset == "" {
+			fmt.Fprintln(os.Stderr, "Error: eval requires a dataset (--dataset)")
+			os.Exit(2)
+		}
+
+		cases, err := loadEvalDataset(evalDataset)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error loading dataset: %v\n", err)
+			os.Exit(2)
+		}
+
+		if err := applyRequestOverrides(evalProvider, evalModel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		var judgeProvider provider.Provider
+		var judgeModel string
+		if evalJudge != "" {
+			judgeProvider, judgeModel, err = resolveModelSpec(evalJudge)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+		}
+
+		var output *os.File
+		if evalOutput != "" {
+			if err := util.CheckWriteAllowed("writing eval results"); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+			output, err = os.Create(evalOutput)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error creating output file: %v\n", err)
+				os.Exit(2)
+			}
+			defer output.Close()
+		}
+
+		results := make([]evalResult, 0, len(cases))
+		for i, c := range cases {
+			result := runEvalCase(c)
+			if result.ID == "" {
+				result.ID = fmt.Sprintf("%d", i+1)
+			}
+
+			if judgeProvider != nil && result.Error == "" {
+				score, reason, err := judgeEvalResult(judgeProvider, judgeModel, result)
+				if err != nil {
+					result.JudgeReason = fmt.Sprintf("judge error: %v", err)
+				} else {
+					result.Score = &score
+					result.JudgeReason = reason
+				}
+			}
+
+			if !evalJSON {
+				printEvalResult(result)
+			}
+			if output != nil {
+				line, _ := json.Marshal(result)
+				fmt.Fprintln(output, string(line))
+			}
+			results = append(results, result)
+		}
+
+		summary := summarizeEval(results)
+		if judgeProvider != nil {
+			summary.Judge = judgeProvider.GetName() + ":" + judgeModel
+		}
+
+		if evalJSON {
+			encoded, _ := json.MarshalIndent(summary, "", "  ")
+			fmt.Println(string(encoded))
+		} else {
+			printEvalSummary(summary)
+		}
+	},
+}
+
+// loadEvalDataset reads a JSONL dataset, skipping blank lines
+func loadEvalDataset(path string) ([]evalCase, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	var cases []evalCase
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 10*1024*1024)
+	lineNum := 0
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var c evalCase
+		if err := json.Unmarshal([]byte(line), &c); err != nil {
+			return nil, fmt.Errorf("line %d: %v", lineNum, err)
+		}
+		if c.Prompt == "" {
+			return nil, fmt.Errorf("line %d: missing prompt", lineNum)
+		}
+		cases = append(cases, c)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(cases) == 0 {
+		return nil, fmt.Errorf("dataset %s is empty", path)
+	}
+	return cases, nil
+}
+
+// resolveModelSpec parses "model", "provider:model" or a model alias into a ready provider and model name.
+// A bare model is used with the active provider if it lists it, or else with the first ready provider that does.
+func resolveModelSpec(spec string) (provider.Provider, string, error) {
+	providerName, model := api.ResolveModel(spec)
+	// Only a registered provider is taken as a prefix, model names like ft:gpt-4o-mini:org::id contain colons
+	if prefix, rest, found := strings.Cut(model, ":"); providerName == "" && found && slices.Contains(api.GetAvailableProviderNames(), prefix) {
+		providerName, model = prefix, rest
+	}
+
+	p := api.GetActiveProvider()
+	if providerName != "" {
+		var exists bool
+		p, exists = api.GetProvider(providerName)
+		if !exists {
+			return nil, "", fmt.Errorf("provider %s not found", providerName)
+		}
+	} else if model != "" && !slices.Contains(p.GetAvailableModels(), model) {
+		for _, ready := range api.GetReadyProviders() {
+			if slices.Contains(ready.GetAvailableModels(), model) {
+				p = ready
+				break
+			}
+		}
+	}
+
+	if !p.IsReady() {
+		return nil, "", fmt.Errorf("provider %s is not ready, please set its API key first", p.GetName())
+	}
+	if model == "" {
+		model = p.GetCurrentModel()
+	}
+	return p, model, nil
+}
+
+// runEvalCase sends a single dataset prompt and checks it against the expected answer
+func runEvalCase(c evalCase) evalResult {
+	result := evalResult{
+		ID:       c.ID,
+		Prompt:   c.Prompt,
+		Expected: c.Expected,
+	}
+
+	messages := []api.ChatMessage{}
+	if c.System != "" {
+		messages = append(messages, api.ChatMessage{Role: "system", Content: c.System})
+	}
+	messages = append(messages, api.ChatMessage{Role: "user", Content: c.Prompt})
+
+	start := time.Now()
+	response, err := api.SendChatRequest(context.Background(), messages)
+	result.LatencyMs = time.Since(start).Milliseconds()
+	result.Response = response
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	if c.Expected != "" {
+		match := strings.Contains(strings.ToLower(response), strings.ToLower(c.Expected))
+		result.Match = &match
+	}
+	return result
+}
+
+// judgeEvalResult asks the judge model to score a result
+func judgeEvalResult(p provider.Provider, model string, result evalResult) (float64, string, error) {
+	previousModel := p.GetCurrentModel()
+	if err := p.SetCurrentModel(model); err != nil {
+		return 0, "", err
+	}
+	defer p.SetCurrentModel(previousModel)
+
+	messages := []api.ChatMessage{
+		{Role: "user", Content: fmt.Sprintf(judgePrompt, result.Prompt, result.Expected, result.Response)},
+	}
+	response, err := api.SendChatRequestWith(context.Background(), p, messages)
+	if err != nil {
+		return 0, "", err
+	}
+	return parseJudgeVerdict(response)
+}
+
+// parseJudgeVerdict reads the score and reason of a judge response, which may wrap the JSON in a code fence
+func parseJudgeVerdict(response string) (float64, string, error) {
+	var verdict struct {
+		Score  float64 `json:"score"`
+		Reason string  `json:"reason"`
+	}
+	if err := json.Unmarshal([]byte(extractJSON(response)), &verdict); err != nil {
+		return 0, "", fmt.Errorf("invalid judge response: %v", err)
+	}
+	return verdict.Score, verdict.Reason, nil
+}
+
+// summarizeEval computes aggregate metrics over all results
+func summarizeEval(results []evalResult) evalSummary {
+	summary := evalSummary{
+		Provider: api.GetActiveProviderName(),
+		Model:    api.GetCurrentModel(),
+		Total:    len(results),
+	}
+
+	var totalLatency int64
+	var totalScore float64
+	scored := 0
+	for _, r := range results {
+		totalLatency += r.LatencyMs
+		if r.Error != "" {
+			summary.Errors++
+		}
+		if r.Match != nil {
+			summary.WithExpected++
+			if *r.Match {
+				summary.Matched++
+			}
+		}
+		if r.Score != nil {
+			totalScore += *r.Score
+			scored++
+		}
+	}
+
+	if summary.Total > 0 {
+		summary.MeanLatency = totalLatency / int64(summary.Total)
+	}
+	if summary.WithExpected > 0 {
+		rate := float64(summary.Matched) / float64(summary.WithExpected)
+		summary.MatchRate = &rate
+	}
+	if scored > 0 {
+		mean := totalScore / float64(scored)
+		summary.MeanScore = &mean
+	}
+	return summary
+}
+
+func printEvalResult(r evalResult) {
+	status := "done"
+	switch {
+	case r.Error != "":
+		status = "error: " + r.Error
+	case r.Match != nil && *r.Match:
+		status = "match"
+	case r.Match != nil:
+		status = "mismatch"
+	}
+
+	line := fmt.Sprintf("[%s] %s (%dms)", r.ID, status, r.LatencyMs)
+	if r.Score != nil {
+		line += fmt.Sprintf(" score=%.1f", *r.Score)
+	}
+	fmt.Println(line)
+}
+
+func printEvalSummary(s evalSummary) {
+	fmt.Println("-----------------------------------")
+	fmt.Printf("Provider: %s (Model: %s)\n", s.Provider, s.Model)
+	if s.Judge != "" {
+		fmt.Printf("Judge: %s\n", s.Judge)
+	}
+	fmt.Printf("Cases: %d, errors: %d\n", s.Total, s.Errors)
+	if s.MatchRate != nil {
+		fmt.Printf("Expected matches: %d/%d (%.1f%%)\n", s.Matched, s.WithExpected, *s.MatchRate*100)
+	}
+	if s.MeanScore != nil {
+		fmt.Printf("Mean judge score: %.2f/10\n", *s.MeanScore)
+	}
+	fmt.Printf("Mean latency: %dms\n", s.MeanLatency)
+}
+
+func init() {
+	rootCmd.AddCommand(evalCmd)
+
+	evalCmd.Flags().StringVar(&evalDataset, "dataset", "", "JSONL dataset of prompts to evaluate")
+	evalCmd.Flags().StringVar(&evalJudge, "judge", "", "Judge model used to score answers, as 'model' or 'provider:model'")
+	evalCmd.Flags().StringVar(&evalProvider, "provider", "", "Provider to evaluate (default is the configured provider)")
+	evalCmd.Flags().StringVar(&evalModel, "model", "", "Model to evaluate (default is the configured model)")
+	evalCmd.Flags().StringVarP(&evalOutput, "output", "o", "", "Write per-case results as JSONL to this file")
+	evalCmd.Flags().BoolVar(&evalJSON, "json", false, "Print the aggregate metrics as JSON")
+}
@@ -0,0 +1,157 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/plucury/chait/api"
+)
+
+func TestLoadEvalDataset(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		want    []evalCase
+		wantErr string
+	}{
+		{
+			name: "cases and blank lines",
+			data: `{"id": "q1", "prompt": "Capital of France?", "expected": "Paris"}
+
+{"system": "Be brief", "prompt": "2+2?"}
+`,
+			want: []evalCase{
+				{ID: "q1", Prompt: "Capital of France?", Expected: "Paris"},
+				{System: "Be brief", Prompt: "2+2?"},
+			},
+		},
+		{name: "missing prompt", data: `{"prompt": "a"}` + "\n" + `{"id": "q2"}`, wantErr: "line 2: missing prompt"},
+		{name: "invalid JSON", data: `{"prompt": `, wantErr: "line 1:"},
+		{name: "empty", data: "\n\n", wantErr: "is empty"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "dataset.jsonl")
+			if err := os.WriteFile(path, []byte(tt.data), 0600); err != nil {
+				t.Fatal(err)
+			}
+			got, err := loadEvalDataset(path)
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("cases = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSummarizeEval(t *testing.T) {
+	match, mismatch := true, false
+	score := func(v float64) *float64 { return &v }
+	tests := []struct {
+		name                          string
+		results                       []evalResult
+		errors, matched, withExpected int
+		matchRate, meanScore          *float64
+		meanLatency                   int64
+	}{
+		{name: "no results"},
+		{
+			name: "matches, scores and errors",
+			results: []evalResult{
+				{Match: &match, Score: score(8), LatencyMs: 100},
+				{Match: &mismatch, Score: score(4), LatencyMs: 200},
+				{Error: "timeout", LatencyMs: 300},
+				{LatencyMs: 400},
+			},
+			errors: 1, matched: 1, withExpected: 2,
+			matchRate: score(0.5), meanScore: score(6), meanLatency: 250,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := summarizeEval(tt.results)
+			if s.Total != len(tt.results) || s.Errors != tt.errors || s.Matched != tt.matched || s.WithExpected != tt.withExpected || s.MeanLatency != tt.meanLatency {
+				t.Errorf("summary = %+v", s)
+			}
+			if !reflect.DeepEqual(s.MatchRate, tt.matchRate) {
+				t.Errorf("match rate = %v, want %v", s.MatchRate, tt.matchRate)
+			}
+			if !reflect.DeepEqual(s.MeanScore, tt.meanScore) {
+				t.Errorf("mean score = %v, want %v", s.MeanScore, tt.meanScore)
+			}
+		})
+	}
+}
+
+func TestParseJudgeVerdict(t *testing.T) {
+	tests := []struct {
+		name      string
+		response  string
+		score     float64
+		reason    string
+		wantError bool
+	}{
+		{name: "plain JSON", response: `{"score": 7, "reason": "mostly right"}`, score: 7, reason: "mostly right"},
+		{name: "fenced JSON", response: "```json\n{\"score\": 9.5, \"reason\": \"correct\"}\n```", score: 9.5, reason: "correct"},
+		{name: "missing reason", response: `{"score": 3}`, score: 3},
+		{name: "not JSON", response: "I would give it a 7", wantError: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			score, reason, err := parseJudgeVerdict(tt.response)
+			if (err != nil) != tt.wantError {
+				t.Fatalf("error = %v, want error %v", err, tt.wantError)
+			}
+			if score != tt.score || reason != tt.reason {
+				t.Errorf("verdict = %v, %q, want %v, %q", score, reason, tt.score, tt.reason)
+			}
+		})
+	}
+}
+
+// Only a registered provider is taken as the prefix of a judge, and a bare model is used
+// with the ready provider that lists it
+func TestResolveModelSpec(t *testing.T) {
+	for _, name := range []string{"openai", "groq"} {
+		p, _ := api.GetProvider(name)
+		previous := p.GetAPIKey()
+		p.SetAPIKey("sk-test")
+		defer p.SetAPIKey(previous)
+	}
+	active := api.GetActiveProviderName()
+	if err := api.UseProvider("groq"); err != nil {
+		t.Fatal(err)
+	}
+	defer api.UseProvider(active)
+
+	tests := []struct {
+		spec, provider, model string
+	}{
+		{"gpt-4o", "openai", "gpt-4o"},
+		{"openai:gpt-4o-mini", "openai", "gpt-4o-mini"},
+		{"llama-3.1-8b-instant", "groq", "llama-3.1-8b-instant"},
+		{"ft:gpt-4o-mini:org::id", "groq", "ft:gpt-4o-mini:org::id"},
+		{"", "groq", "llama-3.3-70b-versatile"},
+	}
+	for _, tt := range tests {
+		p, model, err := resolveModelSpec(tt.spec)
+		if err != nil {
+			t.Errorf("%q: %v", tt.spec, err)
+			continue
+		}
+		if p.GetName() != tt.provider || model != tt.model {
+			t.Errorf("%q: %s:%s, want %s:%s", tt.spec, p.GetName(), model, tt.provider, tt.model)
+		}
+	}
+}
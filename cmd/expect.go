@@ -0,0 +1,247 @@
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/plucury/chait/api"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the expect command
+var (
+	expectPrompt    string
+	expectSystem    []string
+	expectProvider  string
+	expectModel     string
+	expectContains  []string
+	expectRegex     []string
+	expectJSONPaths []string
+	expectQuiet     bool
+)
+
+// expectCmd represents the expect command
+var expectCmd = &cobra.Command{
+	Use:   "expect",
+	Short: "Send a prompt and assert on the response",
+	Long: `Send a prompt and check the model output against simple assertions.
+Exits with a non-zero status when any assertion fails, so prompts can be
+used as regression tests in CI pipelines.
+
+Assertions:
+  --contains TEXT        the response must contain TEXT
+  --regex PATTERN        the response must match the regular expression
+  --json-path PATH       the response must be JSON and PATH must exist
+  --json-path PATH=VALUE the value at PATH must equal VALUE
+
+Example:
+  chait expect --prompt "What is 2+2? Answer with a number" --contains 4 --model gpt-4o-mini
+  chait expect --prompt "Return {\"ok\": true} as JSON" --json-path ok=true`,
+	Args: cobra.ArbitraryArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		prompt := expectPrompt
+		if prompt == "" {
+			prompt = strings.Join(args, " ")
+		}
+		if prompt == "" {
+			fmt.Fprintln(os.Stderr, "Error: expect requires a prompt (--prompt)")
+			os.Exit(2)
+		}
+
+		if len(expectContains) == 0 && len(expectRegex) == 0 && len(expectJSONPaths) == 0 {
+			fmt.Fprintln(os.Stderr, "Error: expect requires at least one assertion (--contains, --regex or --json-path)")
+			os.Exit(2)
+		}
+
+		if err := applyRequestOverrides(expectProvider, expectModel); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		messages := []api.ChatMessage{}
+		for _, system := range expectSystem {
+			messages = append(messages, api.ChatMessage{Role: "system", Content: system})
+		}
+		messages = append(messages, api.ChatMessage{Role: "user", Content: prompt})
+
+		DebugLog("Sending expect prompt to provider %s", api.GetActiveProviderName())
+		response, err := api.SendChatRequest(context.Background(), messages)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		if !expectQuiet {
+			fmt.Println(response)
+		}
+
+		failures := checkExpectations(response)
+		if len(failures) > 0 {
+			for _, failure := range failures {
+				fmt.Fprintf(os.Stderr, "FAIL: %s\n", failure)
+			}
+			os.Exit(1)
+		}
+	},
+}
+
+// applyRequestOverrides switches provider and model for this invocation only
+func applyRequestOverrides(providerName, model string) error {
+	// An alias for another provider switches to it unless a provider is given
+	if aliasProvider, _ := api.ResolveModel(model); aliasProvider != "" && providerName == "" {
+		providerName = aliasProvider
+	}
+	if providerName != "" {
+		if err := api.UseProvider(providerName); err != nil {
+			return err
+		}
+	}
+
+	p := api.GetActiveProvider()
+	if !p.IsReady() {
+		return fmt.Errorf("provider %s is not ready, please set its API key first", p.GetName())
+	}
+
+	if model != "" {
+		model, err := api.ModelFor(p, model)
+		if err != nil {
+			return err
+		}
+		if err := p.SetCurrentModel(model); err != nil {
+			return errors.New(withExtraModelsHint(err, p.GetName()))
+		}
+	}
+	return nil
+}
+
+// checkExpectations returns a description of every assertion the response fails
+func checkExpectations(response string) []string {
+	var failures []string
+
+	for _, text := range expectContains {
+		if !strings.Contains(response, text) {
+			failures = append(failures, fmt.Sprintf("response does not contain %q", text))
+		}
+	}
+
+	for _, pattern := range expectRegex {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			failures = append(failures, fmt.Sprintf("invalid regex %q: %v", pattern, err))
+			continue
+		}
+		if !re.MatchString(response) {
+			failures = append(failures, fmt.Sprintf("response does not match regex %q", pattern))
+		}
+	}
+
+	if len(expectJSONPaths) > 0 {
+		var data interface{}
+		if err := json.Unmarshal([]byte(extractJSON(response)), &data); err != nil {
+			return append(failures, fmt.Sprintf("response is not valid JSON: %v", err))
+		}
+
+		for _, assertion := range expectJSONPaths {
+			path, expected, hasValue := strings.Cut(assertion, "=")
+			value, ok := lookupJSONPath(data, path)
+			if !ok {
+				failures = append(failures, fmt.Sprintf("JSON path %q not found", path))
+				continue
+			}
+			if hasValue && !jsonValueEquals(value, expected) {
+				failures = append(failures, fmt.Sprintf("JSON path %q is %v, expected %s", path, value, expected))
+			}
+		}
+	}
+
+	return failures
+}
+
+// extractJSON strips a surrounding markdown code fence from a model response
+func extractJSON(response string) string {
+	trimmed := strings.TrimSpace(response)
+	if strings.HasPrefix(trimmed, "```") {
+		// Drop the opening fence line (which may carry a language tag)
+		if idx := strings.Index(trimmed, "\n"); idx >= 0 {
+			trimmed = trimmed[idx+1:]
+		}
+		trimmed = strings.TrimSuffix(strings.TrimSpace(trimmed), "```")
+	}
+	return strings.TrimSpace(trimmed)
+}
+
+// lookupJSONPath resolves a simple path like "$.items[0].name" or "items.0.name"
+func lookupJSONPath(data interface{}, path string) (interface{}, bool) {
+	path = strings.TrimPrefix(strings.TrimPrefix(path, "$"), ".")
+	path = strings.ReplaceAll(path, "[", ".")
+	path = strings.ReplaceAll(path, "]", "")
+
+	current := data
+	if path == "" {
+		return current, true
+	}
+
+	for _, key := range strings.Split(path, ".") {
+		switch node := current.(type) {
+		case map[string]interface{}:
+			value, ok := node[key]
+			if !ok {
+				return nil, false
+			}
+			current = value
+		case []interface{}:
+			index, err := strconv.Atoi(key)
+			if err != nil || index < 0 || index >= len(node) {
+				return nil, false
+			}
+			current = node[index]
+		default:
+			return nil, false
+		}
+	}
+	return current, true
+}
+
+// jsonValueEquals compares a decoded JSON value with the expected value given on the command line
+func jsonValueEquals(value interface{}, expected string) bool {
+	switch v := value.(type) {
+	case string:
+		return v == expected || strconv.Quote(v) == expected
+	case nil:
+		return expected == "null"
+	default:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return false
+		}
+		if string(encoded) == expected {
+			return true
+		}
+		// Compare numbers numerically so "1.0" matches 1
+		if num, ok := v.(float64); ok {
+			if parsed, err := strconv.ParseFloat(expected, 64); err == nil {
+				return num == parsed
+			}
+		}
+		return false
+	}
+}
+
+func init() {
+	rootCmd.AddCommand(expectCmd)
+
+	expectCmd.Flags().StringVar(&expectPrompt, "prompt", "", "Prompt to send to the model")
+	expectCmd.Flags().StringArrayVar(&expectSystem, "system", nil, "Optional system prompt, repeat for several system messages sent in order")
+	expectCmd.Flags().StringVar(&expectProvider, "provider", "", "Provider to use for this run (default is the configured provider)")
+	expectCmd.Flags().StringVar(&expectModel, "model", "", "Model to use for this run (default is the configured model)")
+	expectCmd.Flags().StringArrayVar(&expectContains, "contains", nil, "Assert that the response contains the given text (repeatable)")
+	expectCmd.Flags().StringArrayVar(&expectRegex, "regex", nil, "Assert that the response matches the regular expression (repeatable)")
+	expectCmd.Flags().StringArrayVar(&expectJSONPaths, "json-path", nil, "Assert that PATH exists in the JSON response, or PATH=VALUE matches (repeatable)")
+	expectCmd.Flags().BoolVarP(&expectQuiet, "quiet", "q", false, "Do not print the model response")
+}
@@ -0,0 +1,112 @@
+package cmd
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestCheckExpectations(t *testing.T) {
+	t.Cleanup(func() { expectContains, expectRegex, expectJSONPaths = nil, nil, nil })
+
+	tests := []struct {
+		name      string
+		response  string
+		contains  []string
+		regex     []string
+		jsonPaths []string
+		want      []string // Start of each failure
+	}{
+		{"contains", "The answer is 4.", []string{"4", "answer"}, nil, nil, nil},
+		{"missing text", "The answer is 4.", []string{"5"}, nil, nil, []string{`response does not contain "5"`}},
+		{"regex", "The answer is 4.", nil, []string{`^The answer is \d+\.$`}, nil, nil},
+		{"regex mismatch", "The answer is four.", nil, []string{`\d`}, nil, []string{`response does not match regex "\\d"`}},
+		{"invalid regex", "anything", nil, []string{`(`}, nil, []string{`invalid regex "("`}},
+		{"json path", `{"ok": true, "items": [{"name": "a"}, {"name": "b"}]}`, nil, nil, []string{"ok=true", "$.items[1].name=b", "items.0.name"}, nil},
+		{"fenced json", "```json\n{\"count\": 2}\n```", nil, nil, []string{"count=2.0"}, nil},
+		{"json value mismatch", `{"ok": false}`, nil, nil, []string{"ok=true"}, []string{`JSON path "ok" is false, expected true`}},
+		{"missing json path", `{"items": []}`, nil, nil, []string{"items[0]", "other"}, []string{`JSON path "items[0]" not found`, `JSON path "other" not found`}},
+		{"not json", "Sure! ok=true", []string{"Sure"}, nil, []string{"ok=true"}, []string{"response is not valid JSON"}},
+		{"several failures", "no", []string{"yes"}, []string{"^y"}, nil, []string{`response does not contain "yes"`, `response does not match regex "^y"`}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expectContains, expectRegex, expectJSONPaths = tt.contains, tt.regex, tt.jsonPaths
+			failures := checkExpectations(tt.response)
+			if len(failures) != len(tt.want) {
+				t.Fatalf("checkExpectations(%q) = %q, want %d failures", tt.response, failures, len(tt.want))
+			}
+			for i, failure := range failures {
+				if !strings.HasPrefix(failure, tt.want[i]) {
+					t.Errorf("failure %d = %q, want %q...", i, failure, tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestLookupJSONPath(t *testing.T) {
+	var data interface{}
+	if err := json.Unmarshal([]byte(`{"a": {"b": [10, {"c": "x"}, [true]]}, "n": null}`), &data); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		path   string
+		want   interface{}
+		wantOK bool
+	}{
+		{"a.b[0]", 10.0, true},
+		{"$.a.b[1].c", "x", true},
+		{"a.b.1.c", "x", true},
+		{"a.b[2][0]", true, true},
+		{"n", nil, true},
+		{"$", data, true},
+		{"a.b[3]", nil, false},
+		{"a.b[-1]", nil, false},
+		{"a.b[x]", nil, false},
+		{"a.missing", nil, false},
+		{"a.b[0].c", nil, false},
+		{"missing.b", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.path, func(t *testing.T) {
+			got, ok := lookupJSONPath(data, tt.path)
+			if ok != tt.wantOK || !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("lookupJSONPath(%q) = %v, %v, want %v, %v", tt.path, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestJSONValueEquals(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    interface{}
+		expected string
+		want     bool
+	}{
+		{"string", "hello", "hello", true},
+		{"quoted string", "hello", `"hello"`, true},
+		{"other string", "hello", "world", false},
+		{"number", 1.0, "1", true},
+		{"number with decimals", 1.0, "1.0", true},
+		{"float", 0.5, "0.5", true},
+		{"other number", 1.0, "2", false},
+		{"number and text", 1.0, "one", false},
+		{"true", true, "true", true},
+		{"false", false, "true", false},
+		{"null", nil, "null", true},
+		{"null and empty", nil, "", false},
+		{"array", []interface{}{1.0, "a"}, `[1,"a"]`, true},
+		{"object", map[string]interface{}{"k": "v"}, `{"k":"v"}`, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := jsonValueEquals(tt.value, tt.expected); got != tt.want {
+				t.Errorf("jsonValueEquals(%v, %q) = %v, want %v", tt.value, tt.expected, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import "strings"
+
+// codeFence opens and closes a code block in Markdown
+const codeFence = "```"
+
+// isFenceLine returns true if the line opens or closes a code block
+func isFenceLine(line string) bool {
+	return strings.HasPrefix(strings.TrimSpace(line), codeFence)
+}
+
+// insideFence returns true if the position is inside a code block of the text, i.e. after
+// an odd number of fence lines
+func insideFence(text []rune, pos int) bool {
+	fences := 0
+	for _, line := range strings.Split(string(text[:pos]), "\n") {
+		if isFenceLine(line) {
+			fences++
+		}
+	}
+	return fences%2 == 1
+}
+
+// currentLine returns the start and end positions of the line containing pos
+func currentLine(text []rune, pos int) (int, int) {
+	start, end := pos, pos
+	for start > 0 && text[start-1] != '\n' {
+		start--
+	}
+	for end < len(text) && text[end] != '\n' {
+		end++
+	}
+	return start, end
+}
+
+// insertAtCursor inserts runes at the cursor and moves the cursor after them
+func (m *interactiveModel) insertAtCursor(runes []rune) {
+	newInput := make([]rune, len(m.input)+len(runes))
+	copy(newInput, m.input[:m.cursor])
+	copy(newInput[m.cursor:], runes)
+	copy(newInput[m.cursor+len(runes):], m.input[m.cursor:])
+	m.input = newInput
+	m.cursor += len(runes)
+}
+
+// closeFence adds the closing fence below a code block opened by typing ``` at the cursor,
+// leaving the cursor after the opening fence so a language can be typed
+func (m *interactiveModel) closeFence() {
+	start, end := currentLine(m.input, m.cursor)
+	line := string(m.input[start:m.cursor])
+	if end != m.cursor || strings.TrimSpace(line) != codeFence || !insideFence(m.input, m.cursor) {
+		return
+	}
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	m.insertAtCursor([]rune("\n" + indent + codeFence))
+	m.cursor = end
+}
+
+// handleEnterInFence makes Enter insert a newline inside a code block instead of sending
+// the message. On an empty line just before the closing fence, Enter leaves the block
+// instead. It returns false if the cursor is not inside a code block.
+func (m *interactiveModel) handleEnterInFence() bool {
+	if !insideFence(m.input, m.cursor) {
+		return false
+	}
+
+	start, end := currentLine(m.input, m.cursor)
+	if start == end && end < len(m.input) {
+		nextStart, nextEnd := currentLine(m.input, end+1)
+		if isFenceLine(string(m.input[nextStart:nextEnd])) {
+			// Drop the empty line and move after the closing fence
+			m.input = append(m.input[:start], m.input[end+1:]...)
+			m.cursor = nextEnd - 1
+			return true
+		}
+	}
+
+	m.insertAtCursor([]rune{'\n'})
+	return true
+}
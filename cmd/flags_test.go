@@ -0,0 +1,133 @@
+package cmd
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/plucury/chait/api"
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// -p, -m and -t take an optional value, combined with other short flags they must still
+// select interactively rather than take the following letters as their value
+func TestOptionalValueShortFlags(t *testing.T) {
+	tests := []struct {
+		args                             []string
+		provider, model, temperature     string
+		interactive, continueLastSession bool
+		messages                         []string
+	}{
+		{args: []string{"-pi"}, provider: selectFlagValue, interactive: true},
+		{args: []string{"-ti"}, temperature: selectFlagValue, interactive: true},
+		{args: []string{"-mic"}, model: selectFlagValue, interactive: true, continueLastSession: true},
+		{args: []string{"-ip"}, provider: selectFlagValue, interactive: true},
+		{args: []string{"-p", "hi"}, provider: selectFlagValue, messages: []string{"hi"}},
+		{args: []string{"-p=groq", "-i"}, provider: "groq", interactive: true},
+		{args: []string{"--model=gpt-4o-mini", "--temperature=0.2"}, model: "gpt-4o-mini", temperature: "0.2"},
+	}
+	for _, tt := range tests {
+		providerFlag, modelFlag, temperatureFlag = "", "", ""
+		interactiveMode, lastSessionFlag = false, false
+		rootCmd.Flags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+
+		if err := rootCmd.Flags().Parse(tt.args); err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if providerFlag != tt.provider || modelFlag != tt.model || temperatureFlag != tt.temperature {
+			t.Errorf("%v: provider %q, model %q, temperature %q, want %q, %q, %q", tt.args, providerFlag, modelFlag, temperatureFlag, tt.provider, tt.model, tt.temperature)
+		}
+		if interactiveMode != tt.interactive || lastSessionFlag != tt.continueLastSession {
+			t.Errorf("%v: interactive %v, continue %v, want %v, %v", tt.args, interactiveMode, lastSessionFlag, tt.interactive, tt.continueLastSession)
+		}
+		if got := rootCmd.Flags().Args(); len(got) != len(tt.messages) || (len(got) > 0 && got[0] != tt.messages[0]) {
+			t.Errorf("%v: arguments %v, want %v", tt.args, got, tt.messages)
+		}
+	}
+	providerFlag, modelFlag, temperatureFlag = "", "", ""
+	interactiveMode, lastSessionFlag = false, false
+}
+
+// Selecting a temperature or model interactively saves only that setting, not the model or
+// temperature given with --model=<name> or --temperature=<value> for the same invocation
+func TestSelectedSettingKeepsOverridesOutOfConfig(t *testing.T) {
+	tests := []struct {
+		name               string
+		model, temperature string
+		key                string
+		value              interface{}
+		wantModel          string
+		wantTemperature    float64
+	}{
+		{name: "temperature with --model", model: "gpt-4o-mini", key: "temperature", value: 0.2, wantModel: "gpt-4o", wantTemperature: 0.2},
+		{name: "model with --temperature", temperature: "0.2", key: "model", value: "gpt-4o-mini", wantModel: "gpt-4o-mini", wantTemperature: 0.7},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configFile := filepath.Join(t.TempDir(), "config.json")
+			if err := os.WriteFile(configFile, []byte(`{"providers": {"openai": {"model": "gpt-4o", "temperature": 0.7}}}`), 0600); err != nil {
+				t.Fatal(err)
+			}
+			viper.Reset()
+			defer viper.Reset()
+			viper.SetConfigFile(configFile)
+			if err := viper.ReadInConfig(); err != nil {
+				t.Fatal(err)
+			}
+			if err := api.LoadProviderConfig("openai", viper.GetStringMap("providers.openai")); err != nil {
+				t.Fatal(err)
+			}
+
+			providerFlag, modelFlag, temperatureFlag = "openai", tt.model, tt.temperature
+			defer func() { providerFlag, modelFlag, temperatureFlag = "", "", "" }()
+			if err := applyFlagOverrides(); err != nil {
+				t.Fatal(err)
+			}
+			if err := saveProviderSetting(api.GetActiveProvider(), tt.key, tt.value); err != nil {
+				t.Fatal(err)
+			}
+
+			saved := viper.New()
+			saved.SetConfigFile(configFile)
+			if err := saved.ReadInConfig(); err != nil {
+				t.Fatal(err)
+			}
+			if got := saved.GetString("providers.openai.model"); got != tt.wantModel {
+				t.Errorf("saved model = %q, want %q", got, tt.wantModel)
+			}
+			if got := saved.GetFloat64("providers.openai.temperature"); got != tt.wantTemperature {
+				t.Errorf("saved temperature = %v, want %v", got, tt.wantTemperature)
+			}
+		})
+	}
+}
+
+// --session cannot be combined with --continue or --last, which open another conversation
+func TestSessionFlagExclusive(t *testing.T) {
+	tests := []struct {
+		args    []string
+		wantErr bool
+	}{
+		{args: []string{"--session", "x"}},
+		{args: []string{"-c"}},
+		{args: []string{"--session", "x", "--continue"}, wantErr: true},
+		{args: []string{"--session", "x", "-c"}, wantErr: true},
+		{args: []string{"--session", "x", "--last"}, wantErr: true},
+	}
+	for _, tt := range tests {
+		sessionFlag, lastSessionFlag = "", false
+		rootCmd.Flags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+
+		if err := rootCmd.Flags().Parse(tt.args); err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if err := rootCmd.ValidateFlagGroups(); (err != nil) != tt.wantErr {
+			t.Errorf("%v: error %v, want error %v", tt.args, err, tt.wantErr)
+		}
+	}
+	sessionFlag, lastSessionFlag = "", false
+	rootCmd.Flags().VisitAll(func(f *pflag.Flag) { f.Changed = false })
+}
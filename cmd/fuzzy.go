@@ -0,0 +1,22 @@
+package cmd
+
+import (
+	"strings"
+	"unicode"
+)
+
+// fuzzyMatch returns true if the characters of the query appear in the text in order, not
+// necessarily next to each other, ignoring case
+func fuzzyMatch(query, text string) bool {
+	query = strings.ToLower(query)
+	for _, c := range strings.ToLower(text) {
+		if query == "" {
+			return true
+		}
+		r := []rune(query)[0]
+		if c == r || (unicode.IsSpace(r) && unicode.IsSpace(c)) {
+			query = string([]rune(query)[1:])
+		}
+	}
+	return query == ""
+}
@@ -0,0 +1,214 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/plucury/chait/api"
+	"github.com/spf13/cobra"
+)
+
+// configKey documents a setting of the config file
+type configKey struct {
+	Key         string
+	Description string
+}
+
+// configKeys lists the settings read from the config file, for the generated help
+var configKeys = []configKey{
+	{"provider", "Active provider, also set with -p or :p"},
+	{"providers.<name>.api_key", "API key of the provider"},
+	{"providers.<name>.model", "Model used by the provider"},
+	{"providers.<name>.temperature", "Temperature used by the provider"},
+	{"providers.<name>.user_agent", "Custom User-Agent header sent to the provider"},
+	{"providers.<name>.user", "Optional end-user identifier sent as the user field"},
+	{"providers.<name>.extra_models", "Additional model names offered and accepted for the provider"},
+	{"providers.<name>.proxy", "Proxy URL for this provider, overriding proxy"},
+	{"providers.<name>.timeout", "Seconds (or a duration like 90s) to wait for the provider to start responding, default 120"},
+	{"providers.<name>.top_p", "Sampling parameter sent with each request, also set with :params"},
+	{"providers.<name>.frequency_penalty", "Sampling parameter sent with each request, also set with :params"},
+	{"providers.<name>.presence_penalty", "Sampling parameter sent with each request, also set with :params"},
+	{"providers.<name>.system_role", "How the system prompt is sent: system, developer, user or top-level"},
+	{"providers.<name>.max_tokens", "Maximum number of tokens per response"},
+	{"providers.<name>.reasoning_effort", "low, medium or high, sent to OpenAI o-series models"},
+	{"providers.<name>.stream_idle_timeout", "Seconds without streamed data before a response is considered stalled, default 60"},
+	{"model_aliases", "Short names accepted wherever a model is, e.g. {\"fast\": \"gpt-4o-mini\", \"smart\": \"openai:o1\"}"},
+	{"save_conversations", "Save every interactive conversation after each response, default true"},
+	{"conversations_dir", "Directory where conversations are saved, e.g. a git repository or a synced folder shared by several machines"},
+	{"max_saved_conversations", "Saved conversations kept, the least recently updated are deleted when a new one is saved, default 0 (no limit)"},
+	{"max_conversation_age_days", "Days after which saved conversations not updated are deleted, default 0 (no limit), also see chait history prune"},
+	{"max_conversation_messages", "Messages above which the older half of a conversation is archived and summarized, default 200 (0 disables)"},
+	{"personas.<name>", "system_prompt, provider, model and temperature applied in one step with --persona or :persona"},
+	{"system_messages", "Instructions sent in order before each conversation: strings, or objects with a role (system or developer) and content or file"},
+	{"use_ready_provider", "Use the first ready provider for the run when the configured one has no API key, default false (:p! switches back)"},
+	{"fallback", "Providers tried in order when the active one fails with a transient or quota error, e.g. [\"groq\", \"deepseek\"]"},
+	{"share.service", "Where :share and chait share upload conversations: gist (default) or the URL of a paste service"},
+	{"share.github_token", "GitHub token used to create gists (defaults to GITHUB_TOKEN)"},
+	{"share.public", "Create public gists instead of secret ones, default false"},
+	{"proxy", "Proxy URL for all providers (defaults to HTTP_PROXY/HTTPS_PROXY)"},
+	{"retry.max_attempts", "Attempts for requests failing with transient errors, default 3"},
+	{"retry.backoff", "Seconds before the first retry, doubled for each following one, default 1"},
+	{"retry.max_backoff", "Maximum seconds between retries, default 30"},
+	{"retry.jitter", "Random fraction (0-1) applied to each retry delay, default 0.2"},
+	{"refusal_hints", "Show edit/switch-model hints after responses that look like refusals, default true"},
+	{"attach_max_tokens", "Estimated tokens above which a text file attached with :f or @path is refused, default 20000 (0 for no limit)"},
+	{"input_history", "Messages and commands recalled with up/down in the next sessions, default 500 (0 disables saving them)"},
+	{"status_bar", "Show the provider, model, temperature, tokens, cost and scroll position at the bottom of interactive mode, default true"},
+	{"keys.<action>", "Keys of an action, e.g. {\"select_model\": \"f6\"}, see chait help commands for the actions and their keys"},
+	{"keymap", "Keys of interactive mode: default, or vi for a normal mode (esc) with j/k, gg/G and ctrl+u/ctrl+d scrolling"},
+	{"inline", "Run interactive mode inline, leaving the conversation in the terminal scrollback (also --inline)"},
+	{"keep_transcript", "What is printed to the normal screen when interactive mode exits: off (default), last (the last exchange) or all"},
+	{"reduce_motion", "No cursor blinking, responses shown once complete instead of while they stream"},
+	{"syntax_highlighting", "Highlight code blocks of responses tagged with a language, default true"},
+	{"code_theme", "Chroma style of highlighted code blocks, e.g. monokai, dracula or github, default that of the theme"},
+	{"stream_render_interval_ms", "Time during which streamed chunks are rendered together, default 40 (0 renders every chunk)"},
+	{"scrollbar", "Show a scrollbar on the right edge of long conversations, default true"},
+	{"max_width", "Width the conversation wraps at on wider terminals, default 0 (the terminal width)"},
+	{"center_content", "Center the conversation when max_width is narrower than the terminal, default false"},
+	{"theme", "Colors of interactive mode: auto (default, depends on the terminal background), default, light, solarized, gruvbox or none, also :theme"},
+	{"background", "dark or light, skips detecting the terminal background for the auto theme"},
+	{"theme_colors.<key>", "Color replacing one of the theme, e.g. user, assistant, error or status_bar_background"},
+	{"lint_prompts", "Warn about pasted secrets, very long lines, invalid UTF-8 or a missing system prompt before sending, default true"},
+	{"stream_max_lines", "Only show the last N lines of a response while it streams, default 0 (everything)"},
+	{"resume_token_warning", "Context tokens per turn above which resuming offers to trim the session, default 4000"},
+	{"prices.<model>", "Price of a model in USD per million tokens, e.g. {\"input\": 2.5, \"output\": 10}"},
+	{"disable_metadata", "Never send the user field"},
+	{"safe_mode", "Disable API key display, config writes, file writes and shell commands (also --safe-mode)"},
+	{"log_level", "Log level: off, error, warn, info, debug or trace (also --log-level)"},
+	{"log_modules.<module>", "Log level for a single module: provider, tui, config or cli"},
+	{"log_file", "Write logs to this file"},
+}
+
+// helpEntry is a documented item of a help topic
+type helpEntry struct {
+	Term        string
+	Description string
+}
+
+// helpTopic is a subject of `chait help <topic>` and a section of the man page
+type helpTopic struct {
+	Name    string
+	Short   string
+	Title   string
+	Intro   string
+	entries func() []helpEntry
+}
+
+// helpTopics lists the topics in the order of the man page
+var helpTopics = []helpTopic{
+	{
+		Name:  "commands",
+		Short: "Commands and keys of interactive mode",
+		Title: "Interactive commands",
+		Intro: "Commands of interactive mode (chait -i) are typed in the input and run with Enter. Keys act immediately.",
+		entries: func() []helpEntry {
+			var entries []helpEntry
+			for _, c := range lineCommands {
+				entries = append(entries, helpEntry{c.Usage(), c.Summary})
+			}
+			for _, k := range keyBindings() {
+				entries = append(entries, helpEntry{k.Key, k.Summary})
+			}
+			return entries
+		},
+	},
+	{
+		Name:  "config",
+		Short: "Settings of the config file",
+		Title: "Configuration",
+		Intro: "Settings are stored in ~/.config/chait/config.json and can be changed with chait config <key> <value>.",
+		entries: func() []helpEntry {
+			var entries []helpEntry
+			for _, k := range configKeys {
+				entries = append(entries, helpEntry{k.Key, k.Description})
+			}
+			return entries
+		},
+	},
+	{
+		Name:  "providers",
+		Short: "Supported providers and their models",
+		Title: "Providers",
+		Intro: "Providers are selected with -p, chait config provider <name> or :p in interactive mode.",
+		entries: func() []helpEntry {
+			var entries []helpEntry
+			for _, p := range api.GetAvailableProviders() {
+				entries = append(entries, helpEntry{
+					p.GetName(),
+					fmt.Sprintf("Models: %s (default %s)", strings.Join(p.GetAvailableModels(), ", "), p.GetDefaultModel()),
+				})
+			}
+			sort.Slice(entries, func(i, j int) bool { return entries[i].Term < entries[j].Term })
+			return entries
+		},
+	},
+}
+
+// findHelpTopic returns the topic with the given name
+func findHelpTopic(name string) (helpTopic, bool) {
+	for _, t := range helpTopics {
+		if t.Name == name {
+			return t, true
+		}
+	}
+	return helpTopic{}, false
+}
+
+// formatHelpTopic renders a topic as text with aligned terms
+func formatHelpTopic(t helpTopic) string {
+	entries := t.entries()
+	width := 0
+	for _, e := range entries {
+		width = max(width, len(e.Term))
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "%s\n\n%s\n\n", t.Title, t.Intro)
+	for _, e := range entries {
+		fmt.Fprintf(&sb, "  %-*s  %s\n", width, e.Term, e.Description)
+	}
+	return sb.String()
+}
+
+// helpTopicNames returns the names of the help topics
+func helpTopicNames() []string {
+	names := make([]string, len(helpTopics))
+	for i, t := range helpTopics {
+		names[i] = t.Name
+	}
+	return names
+}
+
+// helpCmd replaces the default help command to also document topics that are not commands
+var helpCmd = &cobra.Command{
+	Use:   "help [command|topic]",
+	Short: "Help about any command or topic",
+	Run: func(cmd *cobra.Command, args []string) {
+		if len(args) == 1 {
+			if topic, ok := findHelpTopic(args[0]); ok {
+				fmt.Print(formatHelpTopic(topic))
+				return
+			}
+		}
+
+		// The root command accepts any arguments, so Find returns it for unknown names
+		target, _, err := rootCmd.Find(args)
+		if target == nil || err != nil || (target == rootCmd && len(args) > 0) {
+			cmd.Printf("Unknown help topic %q, expected a command or one of: %s\n", strings.Join(args, " "), strings.Join(helpTopicNames(), ", "))
+			return
+		}
+		target.InitDefaultHelpFlag()
+		target.Help()
+	},
+}
+
+func init() {
+	var sb strings.Builder
+	sb.WriteString("Help provides help for any command, or for one of these topics:\n")
+	for _, t := range helpTopics {
+		fmt.Fprintf(&sb, "\n  %-10s %s", t.Name, t.Short)
+	}
+	helpCmd.Long = sb.String()
+	rootCmd.SetHelpCommand(helpCmd)
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/alecthomas/chroma/v2"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/viper"
+)
+
+// defaultCodeTheme is the chroma style of code blocks of the default theme
+const defaultCodeTheme = "monokai"
+
+// maxHighlightCache bounds the highlighted lines kept between renders
+const maxHighlightCache = 4096
+
+// highlightCache holds the highlighted code lines by theme, language and content, so that
+// lines are only tokenized once while responses stream and scroll
+var highlightCache = map[string]string{}
+
+// syntaxHighlighting returns true unless highlighting of code blocks is disabled
+func syntaxHighlighting() bool {
+	return (!viper.IsSet("syntax_highlighting") || viper.GetBool("syntax_highlighting")) && codeTheme() != ""
+}
+
+// codeTheme returns the chroma style used to highlight code blocks, that of the color
+// theme unless code_theme is set
+func codeTheme() string {
+	if theme := viper.GetString("code_theme"); theme != "" {
+		return theme
+	}
+	return activeTheme.Code
+}
+
+// markCodeLines sets the language of the lines of a response that are inside a code block
+// with a language tag, the fence lines themselves are not marked. The first line starts
+// with the "Assistant: " prefix.
+func markCodeLines(lines []messageWithType) {
+	lang, inCode := "", false
+	for i := range lines {
+		text := lines[i].Content
+		if i == 0 {
+			_, text, _ = strings.Cut(text, ": ")
+		}
+		if isFenceLine(text) {
+			inCode = !inCode
+			lang = ""
+			if inCode {
+				if fields := strings.Fields(strings.TrimPrefix(strings.TrimSpace(text), codeFence)); len(fields) > 0 {
+					lang = strings.ToLower(fields[0])
+				}
+			}
+			continue
+		}
+		if inCode {
+			lines[i].Lang = lang
+		}
+	}
+}
+
+// renderLine styles a line of the conversation, highlighting the code lines of responses
+func renderLine(line messageWithType) string {
+	if line.Lang != "" && syntaxHighlighting() {
+		if highlighted, ok := highlightCodeLine(line.Content, line.Lang); ok {
+			return highlighted
+		}
+	}
+	return lineStyle(line.Type).Render(line.Content)
+}
+
+// codeLexer returns the lexer of the language of a code block, nil if it is unknown
+func codeLexer(lang string) chroma.Lexer {
+	lexer := lexers.Get(lang)
+	if lexer == nil {
+		return nil
+	}
+	return chroma.Coalesce(lexer)
+}
+
+// highlightCodeLine highlights a line of code in the given language, it returns false if the
+// language is unknown
+func highlightCodeLine(code, lang string) (string, bool) {
+	theme := codeTheme()
+	key := theme + "\x00" + lang + "\x00" + code
+	if highlighted, ok := highlightCache[key]; ok {
+		return highlighted, true
+	}
+
+	lexer := codeLexer(lang)
+	if lexer == nil {
+		return "", false
+	}
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return "", false
+	}
+	style := styles.Get(theme)
+
+	var sb strings.Builder
+	for _, token := range iterator.Tokens() {
+		text := strings.TrimRight(token.Value, "\n")
+		if text == "" {
+			continue
+		}
+		entry := style.Get(token.Type)
+		tokenStyle := lipgloss.NewStyle()
+		if entry.Colour.IsSet() {
+			tokenStyle = tokenStyle.Foreground(lipgloss.Color(entry.Colour.String()))
+		} else {
+			tokenStyle = assistantStyle
+		}
+		if entry.Bold == chroma.Yes {
+			tokenStyle = tokenStyle.Bold(true)
+		}
+		if entry.Italic == chroma.Yes {
+			tokenStyle = tokenStyle.Italic(true)
+		}
+		sb.WriteString(tokenStyle.Render(text))
+	}
+
+	if len(highlightCache) >= maxHighlightCache {
+		highlightCache = map[string]string{}
+	}
+	highlightCache[key] = sb.String()
+	return sb.String(), true
+}
@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/mattn/go-runewidth"
+	"github.com/plucury/chait/api"
+)
+
+// Keys of every selector, shown below its options
+const selectorKeysHint = "Type to filter, ↑/↓ to navigate, Enter to select, Esc to cancel"
+
+// isHintKey returns true if a key is the letter offered by a hint, typed alone in the
+// empty input
+func (m interactiveModel) isHintKey(msg tea.KeyMsg, key string) bool {
+	return msg.Type == tea.KeyRunes && !msg.Paste && string(msg.Runes) == key &&
+		len(m.input) == 0 && m.enableInput && !m.selectorActive()
+}
+
+// placeholder returns the dimmed text shown in the empty input, depending on what the
+// next message does
+func (m interactiveModel) placeholder() string {
+	switch {
+	case m.apiKeyInputMode:
+		return fmt.Sprintf("Paste the API key of %s and press Enter", api.GetActiveProviderName())
+	case m.temperatureInputMode:
+		return fmt.Sprintf("Type a temperature for %s and press Enter, Esc to cancel", api.GetActiveProviderName())
+	case m.systemPromptEditMode:
+		return "Type the system prompt, Enter saves it (an empty one removes it), Esc cancels"
+	case len(m.compareProviders) > 0:
+		return fmt.Sprintf("Ask %s… :compare off to stop comparing", providerList(m.compareProviders))
+	case len(m.pendingFiles) > 0:
+		return fmt.Sprintf("Ask about %s…", m.pendingFiles[len(m.pendingFiles)-1].Path)
+	case len(m.pendingImages) > 0:
+		return fmt.Sprintf("Ask about the attached %s…", imageCount(len(m.pendingImages)))
+	}
+	return "Ask anything… :h for help"
+}
+
+// commandHint returns the synopsis of the ':' command being typed, or the commands
+// starting with what was typed so far
+func commandHint(input string) string {
+	if !strings.HasPrefix(input, ":") || isMultiline(input) {
+		return ""
+	}
+	name, rest, hasArgs := strings.Cut(input, " ")
+	if command, ok := findLineCommand(name); ok {
+		if hasArgs && strings.TrimSpace(rest) != "" {
+			return "" // Arguments are being typed, the synopsis would be in the way
+		}
+		if command.Args == "" {
+			return command.Summary
+		}
+		return command.Args + " — " + command.Summary
+	}
+	if hasArgs {
+		return ""
+	}
+
+	var matches []string
+	for _, c := range lineCommands {
+		if strings.HasPrefix(c.Name, name) {
+			matches = append(matches, c.Name)
+		}
+	}
+	if len(matches) == 0 {
+		return "not a command, sent as a message (:h lists the commands)"
+	}
+	return strings.Join(matches, " ")
+}
+
+// inputHint returns the dimmed hint shown after the input: the keys of vi normal mode, the
+// placeholder of an empty input, the keys of a code block being typed, or help about the ':' command being typed
+// with the cursor at its end
+func (m interactiveModel) inputHint() string {
+	if m.viNormal {
+		return viNormalHint
+	}
+	if len(m.input) == 0 {
+		return m.placeholder()
+	}
+	if m.apiKeyInputMode {
+		return ""
+	}
+	if m.temperatureInputMode {
+		return "temperature: Enter sets it, Esc cancels"
+	}
+	if m.systemPromptEditMode {
+		return fmt.Sprintf("system prompt: Enter saves it, %s adds a line, Esc cancels", actionKey(actionNewline))
+	}
+	if insideFence(m.input, m.cursor) {
+		return "in a code block: Enter adds a line, Enter on an empty last line leaves the block"
+	}
+	if m.cursor != len(m.input) {
+		return ""
+	}
+	return commandHint(string(m.input))
+}
+
+// fitHint shortens a hint to the room left on the last line of the rendered input, and
+// drops it when there is almost none
+func fitHint(hint, renderedInput string, width int) string {
+	if hint == "" || width <= 0 {
+		return hint
+	}
+	lastLine := renderedInput[strings.LastIndex(renderedInput, "\n")+1:]
+	room := width - runewidth.StringWidth(lastLine) - 1
+	if room < 8 {
+		return ""
+	}
+	return runewidth.Truncate(hint, room, "…")
+}
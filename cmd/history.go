@@ -0,0 +1,194 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/plucury/chait/session"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// Flags for the history command
+var (
+	historyLimit       int
+	historyPruneKeep   int
+	historyPruneDays   int
+	historyPruneDryRun bool
+)
+
+// historyCmd represents the history command
+var historyCmd = &cobra.Command{
+	Use:   "history",
+	Short: "List saved conversations",
+	Long: `List the saved conversations, most recent first, with the time of their last
+message, their number of messages and their title.
+
+Continue one with chait --session <id>, or open it in interactive mode with :o.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		sessions, err := session.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(sessions) == 0 {
+			fmt.Printf("No saved conversations in %s.\n", session.Dir())
+			return
+		}
+		if historyLimit > 0 && len(sessions) > historyLimit {
+			sessions = sessions[:historyLimit]
+		}
+
+		fmt.Printf("%-18s %-16s %8s  %-30s %s\n", "ID", "UPDATED", "MESSAGES", "MODEL", "TITLE")
+		for _, s := range sessions {
+			model := s.Model
+			if s.Provider != "" {
+				model = s.Provider + "/" + s.Model
+			}
+			fmt.Printf("%-18s %-16s %8d  %-30s %s\n", s.ID, s.Updated.Format("2006-01-02 15:04"), len(s.Messages), model, sessionTitle(s))
+		}
+	},
+}
+
+// historyPruneCmd deletes the saved conversations beyond the retention limits
+var historyPruneCmd = &cobra.Command{
+	Use:   "prune",
+	Short: "Delete old saved conversations",
+	Long: `Delete the saved conversations beyond the retention limits: those not updated for
+more than max_conversation_age_days days, and the oldest ones above max_saved_conversations.
+The limits are also applied automatically when a new conversation is saved.
+
+--keep and --older-than override the configured limits.
+
+Example:
+  chait history prune --older-than 90 --dry-run`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		retention := retentionPolicy()
+		if cmd.Flags().Changed("keep") {
+			retention.MaxCount = historyPruneKeep
+		}
+		if cmd.Flags().Changed("older-than") {
+			retention.MaxAge = time.Duration(historyPruneDays) * 24 * time.Hour
+		}
+		if !retention.IsSet() {
+			fmt.Fprintf(os.Stderr, "Error: no retention limit, set max_saved_conversations or max_conversation_age_days, or use --keep or --older-than\n")
+			os.Exit(1)
+		}
+
+		var pruned []*session.Session
+		var err error
+		if historyPruneDryRun {
+			pruned, err = session.Expired(retention, time.Now())
+		} else {
+			pruned, err = session.Prune(retention)
+		}
+		for _, s := range pruned {
+			fmt.Printf("%-18s %-16s %s\n", s.ID, s.Updated.Format("2006-01-02 15:04"), sessionTitle(s))
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		switch {
+		case len(pruned) == 0:
+			fmt.Println("No conversations to delete.")
+		case historyPruneDryRun:
+			fmt.Printf("%d conversation(s) would be deleted.\n", len(pruned))
+		default:
+			fmt.Printf("Deleted %d conversation(s).\n", len(pruned))
+		}
+	},
+}
+
+// retentionPolicy returns the limits of the saved conversations set in the configuration
+func retentionPolicy() session.Retention {
+	return session.Retention{
+		MaxCount: viper.GetInt("max_saved_conversations"),
+		MaxAge:   time.Duration(viper.GetInt("max_conversation_age_days")) * 24 * time.Hour,
+	}
+}
+
+// pruneConversations deletes the saved conversations beyond the configured retention
+// limits, except the current one
+func pruneConversations(current string) {
+	retention := retentionPolicy()
+	if !retention.IsSet() {
+		return
+	}
+	if _, err := session.Prune(retention, current); err != nil {
+		DebugLog("Error pruning conversations: %v", err)
+	}
+}
+
+// sessionTitle returns the title of a session followed by its tags
+func sessionTitle(s *session.Session) string {
+	if len(s.Tags) == 0 {
+		return s.DisplayTitle()
+	}
+	return s.DisplayTitle() + "  [" + strings.Join(s.Tags, ", ") + "]"
+}
+
+// handleOpenCommand opens a saved conversation: ":o" shows the most recent ones in a
+// selector, ":o <name-or-id>" opens the given one
+func (m *interactiveModel) handleOpenCommand(args []string) {
+	if len(args) > 0 {
+		m.handleResumeCommand(args)
+		return
+	}
+
+	sessions, err := session.List()
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	if len(sessions) == 0 {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: fmt.Sprintf("No saved conversations in %s", session.Dir())})
+		return
+	}
+
+	// Only list what fits on the screen next to the title and the hint
+	limit := max(m.height-8, 5)
+	if len(sessions) > limit {
+		sessions = sessions[:limit]
+	}
+	options := make([]selectorOption, len(sessions))
+	for i, s := range sessions {
+		name := fmt.Sprintf("%s  %3d messages  %s", s.Updated.Format("2006-01-02 15:04"), len(s.Messages), sessionTitle(s))
+		if m.session != nil && s.ID == m.session.ID {
+			name += " (current)"
+		}
+		options[i] = selectorOption{name: name, value: s.ID}
+	}
+	m.sessionSelector.options = options
+	m.sessionSelector.currentIndex = 0
+	m.sessionSelector.hint = fmt.Sprintf("The %d most recent conversations, open others with :o <name-or-id>.", len(options))
+	m.activateSelector(&m.sessionSelector)
+}
+
+// openSelectedSession opens the conversation chosen in the session selector
+func (m *interactiveModel) openSelectedSession(id string) {
+	if m.session != nil && id == m.session.ID {
+		return
+	}
+	s, err := session.Load(id)
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	m.openSession(s)
+	m.scrollToBottom()
+}
+
+func init() {
+	rootCmd.AddCommand(historyCmd)
+	historyCmd.AddCommand(historyPruneCmd)
+
+	historyCmd.Flags().IntVarP(&historyLimit, "limit", "n", 0, "Only list the N most recent conversations")
+	historyPruneCmd.Flags().IntVar(&historyPruneKeep, "keep", 0, "Keep the N most recently updated conversations (0 for no limit)")
+	historyPruneCmd.Flags().IntVar(&historyPruneDays, "older-than", 0, "Delete the conversations not updated for more than N days (0 for no limit)")
+	historyPruneCmd.Flags().BoolVar(&historyPruneDryRun, "dry-run", false, "List the conversations that would be deleted without deleting them")
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// historySearchState is the state of the ctrl+r search of the input history
+type historySearchState struct {
+	active bool
+	query  []rune
+	skip   int // Newer matches skipped with ctrl+r
+}
+
+// startHistorySearch shows the search of the inputs of this and previous sessions
+func (m *interactiveModel) startHistorySearch() {
+	m.historySearch = historySearchState{active: true}
+}
+
+// historyMatches returns the distinct inputs of the history matching the query, newest first
+func (m interactiveModel) historyMatches() []string {
+	var matches []string
+	seen := map[string]bool{}
+	for i := len(m.inputHistory) - 1; i >= 0; i-- {
+		entry := m.inputHistory[i]
+		if !seen[entry] && fuzzyMatch(string(m.historySearch.query), entry) {
+			matches = append(matches, entry)
+			seen[entry] = true
+		}
+	}
+	return matches
+}
+
+// handleHistorySearchKey handles the keys of the history search: typing narrows the matches,
+// its key (ctrl+r) moves to the next older one, Enter puts it in the input and esc cancels
+func (m *interactiveModel) handleHistorySearchKey(msg tea.KeyMsg) {
+	if keyActionFor(msg.String()) == actionHistorySearch {
+		if m.historySearch.skip+1 < len(m.historyMatches()) {
+			m.historySearch.skip++
+		}
+		return
+	}
+	switch msg.Type {
+	case tea.KeyEsc, tea.KeyCtrlC, tea.KeyCtrlG:
+		m.historySearch = historySearchState{}
+	case tea.KeyEnter:
+		if matches := m.historyMatches(); len(matches) > 0 {
+			m.setInput(matches[min(m.historySearch.skip, len(matches)-1)])
+			m.historyPos, m.historyDraft = -1, nil
+		}
+		m.historySearch = historySearchState{}
+	case tea.KeyBackspace:
+		if len(m.historySearch.query) > 0 {
+			m.historySearch.query = m.historySearch.query[:len(m.historySearch.query)-1]
+			m.historySearch.skip = 0
+		}
+	case tea.KeySpace:
+		m.historySearch.query = append(m.historySearch.query, ' ')
+		m.historySearch.skip = 0
+	case tea.KeyRunes:
+		m.historySearch.query = append(m.historySearch.query, msg.Runes...)
+		m.historySearch.skip = 0
+	}
+}
+
+// historySearchStatus shows the query and the selected match on a single line
+func (m interactiveModel) historySearchStatus() string {
+	matches := m.historyMatches()
+	if len(matches) == 0 {
+		return fmt.Sprintf("(history search) %s: no match", string(m.historySearch.query))
+	}
+	match := strings.ReplaceAll(matches[min(m.historySearch.skip, len(matches)-1)], "\n", " ⏎ ")
+	return fmt.Sprintf("(history search %d/%d) %s: %s  [enter: use, %s: older, esc: cancel]",
+		min(m.historySearch.skip, len(matches)-1)+1, len(matches), string(m.historySearch.query), match, actionKey(actionHistorySearch))
+}
@@ -0,0 +1,177 @@
+package cmd
+
+import (
+	"fmt"
+	"html"
+	"regexp"
+	"strings"
+
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/plucury/chait/session"
+)
+
+// htmlStyle styles exported conversations, light or dark following the reader's preference
+const htmlStyle = `
+:root { --bg: #ffffff; --fg: #1f2328; --muted: #656d76; --user: #ddf4ff; --border: #d0d7de; --code: #f6f8fa; }
+@media (prefers-color-scheme: dark) {
+  :root { --bg: #0d1117; --fg: #e6edf3; --muted: #8d96a0; --user: #132d44; --border: #30363d; --code: #161b22; }
+}
+body { background: var(--bg); color: var(--fg); font: 16px/1.6 -apple-system, "Segoe UI", Helvetica, Arial, sans-serif; margin: 0; }
+main { max-width: 52rem; margin: 0 auto; padding: 2rem 1rem; }
+header { border-bottom: 1px solid var(--border); margin-bottom: 1.5rem; }
+header p, .note, .role { color: var(--muted); font-size: 0.875rem; }
+.message { margin: 1rem 0; padding: 0.75rem 1rem; border-radius: 8px; border: 1px solid var(--border); }
+.user { background: var(--user); }
+.system, .developer { border-style: dashed; }
+.role { font-weight: 600; text-transform: capitalize; margin-bottom: 0.25rem; }
+.note { text-align: center; font-style: italic; }
+pre { background: var(--code); border: 1px solid var(--border); border-radius: 6px; padding: 0.75rem; overflow-x: auto; }
+code { font: 0.875rem/1.5 ui-monospace, SFMono-Regular, Menlo, Consolas, monospace; }
+p code, li code { background: var(--code); padding: 0.1em 0.3em; border-radius: 4px; }
+pre .lang { display: block; color: var(--muted); font-size: 0.75rem; margin-bottom: 0.25rem; }
+`
+
+// Chroma styles of the code blocks of exported conversations, following the reader's preference
+const (
+	htmlLightCodeStyle = "github"
+	htmlDarkCodeStyle  = "github-dark"
+)
+
+// htmlCodeFormatter renders highlighted code as spans with CSS classes, inside the <pre> of the page
+var htmlCodeFormatter = chromahtml.New(chromahtml.WithClasses(true), chromahtml.PreventSurroundingPre(true))
+
+// htmlCodeCSS returns the CSS of the classes of highlighted code, light or dark following
+// the reader's preference
+func htmlCodeCSS() string {
+	var sb strings.Builder
+	htmlCodeFormatter.WriteCSS(&sb, styles.Get(htmlLightCodeStyle))
+	sb.WriteString("@media (prefers-color-scheme: dark) {\n")
+	htmlCodeFormatter.WriteCSS(&sb, styles.Get(htmlDarkCodeStyle))
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// Inline Markdown converted in the text of messages, applied to escaped HTML
+var (
+	htmlInlineCode = regexp.MustCompile("`([^`\n]+)`")
+	htmlBold       = regexp.MustCompile(`\*\*([^*\n]+)\*\*`)
+	htmlLink       = regexp.MustCompile(`\[([^\]\n]+)\]\((https?://[^)\s]+)\)`)
+	htmlHeading    = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+	htmlListItem   = regexp.MustCompile(`^\s*(?:[-*+]|\d+[.)])\s+(.*)$`)
+)
+
+// renderHTML renders a conversation as a standalone HTML page, with its messages formatted
+// from Markdown and the code blocks highlighted
+func renderHTML(s *session.Session) string {
+	var sb strings.Builder
+	title := html.EscapeString(s.DisplayTitle())
+	fmt.Fprintf(&sb, "<!DOCTYPE html>\n<html lang=\"en\">\n<head>\n<meta charset=\"utf-8\">\n<meta name=\"viewport\" content=\"width=device-width, initial-scale=1\">\n<title>%s</title>\n<style>%s%s</style>\n</head>\n<body>\n<main>\n", title, htmlStyle, htmlCodeCSS())
+
+	details := []string{s.Created.Format("2006-01-02 15:04")}
+	if s.Model != "" {
+		details = append(details, html.EscapeString(strings.TrimPrefix(s.Provider+"/"+s.Model, "/")))
+	}
+	if len(s.Tags) > 0 {
+		details = append(details, html.EscapeString(strings.Join(s.Tags, ", ")))
+	}
+	fmt.Fprintf(&sb, "<header>\n<h1>%s</h1>\n<p>%s</p>\n</header>\n", title, strings.Join(details, " · "))
+
+	for _, m := range s.Messages {
+		if m.Role == session.RoleNote {
+			fmt.Fprintf(&sb, "<p class=\"note\">%s</p>\n", html.EscapeString(m.Content))
+			continue
+		}
+		role := html.EscapeString(m.Role)
+		fmt.Fprintf(&sb, "<section class=\"message %s\">\n<div class=\"role\">%s</div>\n%s</section>\n", role, role, markdownToHTML(m.Content))
+	}
+
+	sb.WriteString("</main>\n</body>\n</html>\n")
+	return sb.String()
+}
+
+// markdownToHTML converts the Markdown used in responses: code blocks, headings, lists,
+// paragraphs, inline code, bold text and links
+func markdownToHTML(text string) string {
+	var sb strings.Builder
+	var paragraph, list []string
+	flush := func() {
+		if len(paragraph) > 0 {
+			sb.WriteString("<p>" + strings.Join(paragraph, "<br>\n") + "</p>\n")
+			paragraph = nil
+		}
+		if len(list) > 0 {
+			sb.WriteString("<ul>\n<li>" + strings.Join(list, "</li>\n<li>") + "</li>\n</ul>\n")
+			list = nil
+		}
+	}
+
+	lines := strings.Split(text, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := lines[i]
+		switch {
+		case isFenceLine(line):
+			flush()
+			lang := strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(line), codeFence))
+			var code []string
+			for i++; i < len(lines) && !isFenceLine(lines[i]); i++ {
+				code = append(code, lines[i])
+			}
+			// The language is the first word of the info string, as in interactive mode
+			codeLang := ""
+			if fields := strings.Fields(lang); len(fields) > 0 {
+				codeLang = strings.ToLower(fields[0])
+			}
+			sb.WriteString("<pre class=\"chroma\">")
+			if lang != "" {
+				fmt.Fprintf(&sb, "<span class=\"lang\">%s</span>", html.EscapeString(lang))
+			}
+			sb.WriteString("<code>" + highlightCode(strings.Join(code, "\n"), codeLang) + "</code></pre>\n")
+		case strings.TrimSpace(line) == "":
+			flush()
+		case htmlHeading.MatchString(line):
+			flush()
+			match := htmlHeading.FindStringSubmatch(line)
+			fmt.Fprintf(&sb, "<h%d>%s</h%d>\n", len(match[1])+1, inlineMarkdown(match[2]), len(match[1])+1)
+		case htmlListItem.MatchString(line):
+			if len(paragraph) > 0 {
+				flush()
+			}
+			list = append(list, inlineMarkdown(htmlListItem.FindStringSubmatch(line)[1]))
+		default:
+			if len(list) > 0 {
+				flush()
+			}
+			paragraph = append(paragraph, inlineMarkdown(line))
+		}
+	}
+	flush()
+	return sb.String()
+}
+
+// inlineMarkdown escapes a line and converts its inline code, bold text and links
+func inlineMarkdown(line string) string {
+	line = html.EscapeString(line)
+	line = htmlInlineCode.ReplaceAllString(line, "<code>$1</code>")
+	line = htmlBold.ReplaceAllString(line, "<strong>$1</strong>")
+	return htmlLink.ReplaceAllString(line, `<a href="$2">$1</a>`)
+}
+
+// highlightCode escapes code and highlights it with chroma, like the code blocks of
+// interactive mode, as plain text if the language is unknown
+func highlightCode(code, lang string) string {
+	lexer := codeLexer(lang)
+	if lexer == nil {
+		lexer = lexers.Fallback
+	}
+	iterator, err := lexer.Tokenise(nil, code)
+	if err != nil {
+		return html.EscapeString(code)
+	}
+	var sb strings.Builder
+	if err := htmlCodeFormatter.Format(&sb, styles.Get(htmlLightCodeStyle), iterator); err != nil {
+		return html.EscapeString(code)
+	}
+	return sb.String()
+}
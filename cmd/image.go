@@ -0,0 +1,113 @@
+package cmd
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/plucury/chait/api"
+)
+
+// maxImageSize is the largest image accepted as an attachment
+const maxImageSize = 20 * 1024 * 1024
+
+// isImageData returns true if the data looks like an image
+func isImageData(data []byte) bool {
+	return strings.HasPrefix(http.DetectContentType(data), "image/")
+}
+
+// imageDataURL encodes image bytes as a data URL for vision models
+func imageDataURL(data []byte) (string, error) {
+	if len(data) > maxImageSize {
+		return "", fmt.Errorf("image is too large (%d bytes, maximum is %d)", len(data), maxImageSize)
+	}
+	mimeType := http.DetectContentType(data)
+	if !strings.HasPrefix(mimeType, "image/") {
+		return "", fmt.Errorf("not a supported image (detected %s)", mimeType)
+	}
+	return "data:" + mimeType + ";base64," + base64.StdEncoding.EncodeToString(data), nil
+}
+
+// isImageURL returns true if the image is given as a URL or data URL, which are sent as is
+func isImageURL(path string) bool {
+	return strings.HasPrefix(path, "http://") || strings.HasPrefix(path, "https://") || strings.HasPrefix(path, "data:image/")
+}
+
+// loadImage reads an image from a file, or from stdin if the path is "-"
+// URLs are returned unchanged for the provider to fetch
+func loadImage(path string) (string, error) {
+	if isImageURL(path) {
+		return path, nil
+	}
+
+	var data []byte
+	var err error
+	if path == "-" {
+		data, err = io.ReadAll(io.LimitReader(os.Stdin, maxImageSize+1))
+	} else {
+		data, err = os.ReadFile(path)
+	}
+	if err != nil {
+		return "", fmt.Errorf("error reading image %s: %v", path, err)
+	}
+
+	dataURL, err := imageDataURL(data)
+	if err != nil {
+		return "", fmt.Errorf("%s: %v", path, err)
+	}
+	DebugLog("Loaded image %s (%d bytes)", path, len(data))
+	return dataURL, nil
+}
+
+// imageCount describes a number of images, e.g. "2 images"
+func imageCount(n int) string {
+	if n == 1 {
+		return "1 image"
+	}
+	return fmt.Sprintf("%d images", n)
+}
+
+// attachInitialImages attaches images given with --image to the initial message, or keeps
+// them for the next message if there is none
+func (m *interactiveModel) attachInitialImages(images []string) {
+	if len(images) == 0 {
+		return
+	}
+	if last := len(m.messages) - 1; m.messages[last].Type == MessageTypeUser {
+		m.messages[last].Images = append(m.messages[last].Images, images...)
+		return
+	}
+	m.pendingImages = append(m.pendingImages, images...)
+}
+
+// handleAttachCommand attaches an image file or URL, or a text file, to the next message: ":f <path>"
+func (m *interactiveModel) handleAttachCommand(path string) {
+	if path == "" {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :f <file or image URL>"})
+		return
+	}
+	if path == "-" {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Images cannot be read from stdin in interactive mode"})
+		return
+	}
+	if !isImageURL(path) && !isImageFile(expandHome(path)) {
+		m.handleAttachTextFile(path)
+		return
+	}
+
+	image, err := loadImage(expandHome(path))
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	m.pendingImages = append(m.pendingImages, image)
+
+	content := fmt.Sprintf("Attached %s, %s will be sent with the next message.", path, imageCount(len(m.pendingImages)))
+	if model := api.GetCurrentModel(); !api.SupportsVision(model) {
+		content += fmt.Sprintf("\nNote: model %s may not accept images, switch to a vision model with ':m'.", model)
+	}
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: content})
+}
@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/spf13/viper"
+)
+
+// inlineMode returns true if interactive mode runs inline instead of in the alternate
+// screen, set with --inline or the inline setting
+func inlineMode() bool {
+	return viper.GetBool("inline")
+}
+
+// lineStyle returns the style of the lines of a message type
+func lineStyle(t MessageType) lipgloss.Style {
+	switch t {
+	case MessageTypeUser:
+		return userStyle
+	case MessageTypeAssistant:
+		return assistantStyle
+	case MessageTypeSystem, MessageTypeDeveloper:
+		return systemStyle
+	case MessageTypeError:
+		return errorStyle
+	case MessageTypeUsage:
+		return usageStyle
+	default: // MessageTypeChait
+		return chaitStyle
+	}
+}
+
+// completeMessages returns the number of leading messages that no longer change: all of
+// them, except the last one while a response is pending
+func (m interactiveModel) completeMessages() int {
+	if !m.enableInput && len(m.messages) > 0 {
+		return len(m.messages) - 1
+	}
+	return len(m.messages)
+}
+
+// printInline prints the messages completed since the last call above the UI, into the
+// scrollback of the terminal, in inline mode. Only the messages still changing are rendered
+// by View.
+func (m interactiveModel) printInline() (interactiveModel, tea.Cmd) {
+	// Messages were dropped or replaced, e.g. by :c, those left were already printed
+	m.inlinePrinted = min(m.inlinePrinted, len(m.messages))
+
+	complete := m.completeMessages()
+	if complete <= m.inlinePrinted || m.width == 0 {
+		return m, nil
+	}
+	var lines []string
+	margin := strings.Repeat(" ", m.textMargin())
+	for _, line := range m.getFormattedMessageLines() {
+		if line.Index >= m.inlinePrinted && line.Index < complete {
+			lines = append(lines, margin+renderLine(line))
+		}
+	}
+	m.inlinePrinted = complete
+	if len(lines) == 0 {
+		return m, nil
+	}
+	return m, tea.Println(strings.Join(lines, "\n"))
+}
+
+// unprintedLines returns the lines of the messages not printed into the scrollback yet
+func (m interactiveModel) unprintedLines(lines []messageWithType) []messageWithType {
+	for i, line := range lines {
+		if line.Index >= m.inlinePrinted {
+			return lines[i:]
+		}
+	}
+	return nil
+}
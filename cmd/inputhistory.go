@@ -0,0 +1,94 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/plucury/chait/session"
+	"github.com/spf13/viper"
+)
+
+// defaultInputHistory is the number of inputs kept across sessions unless input_history is set
+const defaultInputHistory = 500
+
+// inputHistoryLimit returns the number of inputs recalled across sessions, 0 if they are not
+// saved: with input_history set to 0 or when conversations are not saved either
+func inputHistoryLimit() int {
+	if !saveConversationsEnabled() {
+		return 0
+	}
+	if !viper.IsSet("input_history") {
+		return defaultInputHistory
+	}
+	return max(0, viper.GetInt("input_history"))
+}
+
+// loadInputHistory returns the inputs sent in the previous sessions, oldest first
+func loadInputHistory() []string {
+	limit := inputHistoryLimit()
+	if limit == 0 {
+		return nil
+	}
+	history, err := session.LoadPrompts(limit)
+	if err != nil {
+		DebugLog("Error loading the input history: %v", err)
+	}
+	return history
+}
+
+// recordInput adds a sent message or command to the input history, unless it repeats the
+// last one or contains a secret such as an API key, and appends it to the saved history
+func (m *interactiveModel) recordInput(text string) {
+	m.historyPos, m.historyDraft = -1, nil
+	if strings.TrimSpace(text) == "" || (len(m.inputHistory) > 0 && m.inputHistory[len(m.inputHistory)-1] == text) {
+		return
+	}
+	if len(findSecrets(text)) > 0 {
+		return
+	}
+	m.inputHistory = append(m.inputHistory, text)
+
+	limit := inputHistoryLimit()
+	if limit == 0 {
+		return
+	}
+	if len(m.inputHistory) > limit {
+		m.inputHistory = m.inputHistory[len(m.inputHistory)-limit:]
+	}
+	if err := session.AppendPrompt(text); err != nil {
+		DebugLog("Error saving the input history: %v", err)
+	}
+}
+
+// recallInput replaces the input with an older (-1) or a newer (1) entry of the history
+// when the cursor is on the first or the last line of the input, it returns false otherwise.
+// Going past the newest entry restores the input typed before browsing.
+func (m *interactiveModel) recallInput(direction int) bool {
+	if !m.enableInput || m.apiKeyInputMode || m.temperatureInputMode || m.systemPromptEditMode {
+		return false
+	}
+	start, end := currentLine(m.input, m.cursor)
+	if (direction < 0 && start > 0) || (direction > 0 && end < len(m.input)) {
+		return false
+	}
+
+	pos := m.historyPos
+	if pos < 0 {
+		pos = len(m.inputHistory)
+	}
+	pos += direction
+	if pos < 0 || pos > len(m.inputHistory) || (direction > 0 && m.historyPos < 0) {
+		return false
+	}
+	if m.historyPos < 0 {
+		m.historyDraft = m.input
+	}
+
+	if pos == len(m.inputHistory) {
+		m.setInput(string(m.historyDraft))
+		m.historyPos, m.historyDraft = -1, nil
+	} else {
+		m.setInput(m.inputHistory[pos])
+		m.historyPos = pos
+	}
+	return true
+}
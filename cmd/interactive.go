@@ -1,16 +1,23 @@
 package cmd
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
 	"strings"
 	"time"
 
-	"github.com/atotto/clipboard"
 	tea "github.com/charmbracelet/bubbletea"
-	"github.com/charmbracelet/lipgloss"
 	"github.com/mattn/go-runewidth"
 	"github.com/plucury/chait/api"
 	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/cost"
+	"github.com/plucury/chait/session"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
 )
 
 // message type enum
@@ -18,35 +25,46 @@ type MessageType string
 
 const (
 	MessageTypeSystem    MessageType = "System"
+	MessageTypeDeveloper MessageType = "Developer" // Instruction sent with the developer role to models that support it
 	MessageTypeUser      MessageType = "User"
 	MessageTypeAssistant MessageType = "Assistant"
 	MessageTypeChait     MessageType = "Chait"
 	MessageTypeError     MessageType = "Error"
-)
-
-// Style definitions for different message types
-var (
-	userStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("#5e9aa4"))
-	assistantStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("#5ea46b"))
-	systemStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("#87CEEB"))
-	chaitStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#D3D3D3"))
-	errorStyle     = lipgloss.NewStyle().Foreground(lipgloss.Color("#a45e8b"))
+	MessageTypeUsage     MessageType = "Usage" // Footer rendered after a response, never stored as a message
 )
 
 type Message struct {
 	Type    MessageType
 	Content string
+	JSON    bool            // Whether the response was requested in JSON mode
+	Note    bool            // Annotation of a saved conversation, such as merge provenance, kept when saving
+	Usage   *provider.Usage // Token usage reported for the response
+	Cost    *cost.Record    // Estimated cost of the response, nil if no usage was reported
+	NoWrap  bool            // Keep code block lines whole instead of wrapping them
+	Pinned  bool            // Always sent as context, however old, see :pin
+	HScroll int             // Horizontal scroll offset of unwrapped code block lines
+	Images  []string        // Images attached to a user message, as URLs or data URLs
+
+	// Fallback provider and model that answered instead of the active provider
+	AnsweredBy    string
+	AnsweredModel string
+
+	// Responses of a ':compare' prompt, held in Content; empty while they are pending
+	Compare []compareResult
 }
 
 type messageWithType struct {
 	Type    MessageType
 	Content string
+	Index   int    // Position of the message in interactiveModel.messages
+	Lang    string // Language of the code block of a response the line is in, if tagged
 }
 
 func (m Message) ToChatMessage() provider.ChatMessage {
 	return provider.ChatMessage{
 		Role:    strings.ToLower(string(m.Type)),
 		Content: m.Content,
+		Images:  m.Images,
 	}
 }
 
@@ -71,8 +89,10 @@ type selectorOption struct {
 type selectorWidget struct {
 	title        string           // Title to display above the options
 	options      []selectorOption // List of available options
-	currentIndex int              // Currently selected option index
+	currentIndex int              // Currently selected option index, in options
 	isActive     bool             // Whether the selector is currently active/visible
+	hint         string           // Dimmed explanation shown below the options, before the keys
+	filter       []rune           // Typed text fuzzy-filtering the options
 }
 
 func (s *selectorWidget) getCurrentValue() interface{} {
@@ -82,36 +102,90 @@ func (s *selectorWidget) getCurrentValue() interface{} {
 // activate activates the selector widget
 func (s *selectorWidget) activate() {
 	s.isActive = true
+	s.filter = nil
 }
 
 // deactivate deactivates the selector widget
 func (s *selectorWidget) deactivate() {
 	s.isActive = false
+	s.filter = nil
 }
 
-// selectNext selects the next option in the list
-func (s *selectorWidget) selectNext() {
-	if len(s.options) == 0 {
-		return
+// matches returns the indices of the options matching the filter, all of them without one
+func (s *selectorWidget) matches() []int {
+	var indices []int
+	for i, option := range s.options {
+		if fuzzyMatch(string(s.filter), option.name) {
+			indices = append(indices, i)
+		}
 	}
-	s.currentIndex = (s.currentIndex + 1) % len(s.options)
+	return indices
 }
 
-// selectPrevious selects the previous option in the list
-func (s *selectorWidget) selectPrevious() {
-	if len(s.options) == 0 {
+// hasMatch returns true if an option matches the filter and can be confirmed
+func (s *selectorWidget) hasMatch() bool {
+	for _, i := range s.matches() {
+		if i == s.currentIndex {
+			return true
+		}
+	}
+	return false
+}
+
+// setFilter filters the options and selects the first one containing the filter, or the
+// first match, the selection is kept when the filter is cleared
+func (s *selectorWidget) setFilter(filter []rune) {
+	s.filter = filter
+	matches := s.matches()
+	if len(filter) == 0 || len(matches) == 0 {
 		return
 	}
-	s.currentIndex = (s.currentIndex - 1 + len(s.options)) % len(s.options)
+	s.currentIndex = matches[0]
+	for _, i := range matches {
+		if strings.Contains(strings.ToLower(s.options[i].name), strings.ToLower(string(filter))) {
+			s.currentIndex = i
+			return
+		}
+	}
 }
 
-// selectByIndex selects an option by its index
-func (s *selectorWidget) selectByIndex(index int) bool {
-	if index >= 0 && index < len(s.options) {
-		s.currentIndex = index
-		return true
+// typeFilter adds typed text to the filter
+func (s *selectorWidget) typeFilter(runes []rune) {
+	s.setFilter(append(append([]rune{}, s.filter...), []rune(strings.ReplaceAll(string(runes), "\n", ""))...))
+}
+
+// deleteFilter deletes the last character of the filter
+func (s *selectorWidget) deleteFilter() {
+	if len(s.filter) > 0 {
+		s.setFilter(s.filter[:len(s.filter)-1])
 	}
-	return false
+}
+
+// selectNext selects the next option matching the filter
+func (s *selectorWidget) selectNext() {
+	s.selectMatch(1)
+}
+
+// selectPrevious selects the previous option matching the filter
+func (s *selectorWidget) selectPrevious() {
+	s.selectMatch(-1)
+}
+
+// selectMatch moves the selection by step among the options matching the filter, wrapping
+// around
+func (s *selectorWidget) selectMatch(step int) {
+	matches := s.matches()
+	if len(matches) == 0 {
+		return
+	}
+	pos := 0
+	for j, i := range matches {
+		if i == s.currentIndex {
+			pos = (j + step + len(matches)) % len(matches)
+			break
+		}
+	}
+	s.currentIndex = matches[pos]
 }
 
 // confirm confirms the current selection and calls the callback
@@ -128,18 +202,32 @@ func (s *selectorWidget) render() string {
 
 	var sb strings.Builder
 
-	// Display title and instructions
-	sb.WriteString("\n " + s.title + " (↑/↓ to navigate, Enter to select, ESC to cancel):\n\n")
+	// Display title, and the filter above the options it matches
+	sb.WriteString("\n " + s.title + ":\n\n")
+	if len(s.filter) > 0 {
+		sb.WriteString(" Filter: " + string(s.filter) + "\n\n")
+	}
 
 	// Display options
-	for i, option := range s.options {
+	matches := s.matches()
+	for _, i := range matches {
 		if i == s.currentIndex {
 			// Highlight the selected option
-			sb.WriteString(fmt.Sprintf(" > [*] %s\n", option.name))
+			sb.WriteString(fmt.Sprintf(" > [*] %s\n", s.options[i].name))
 		} else {
-			sb.WriteString(fmt.Sprintf("   [ ] %s\n", option.name))
+			sb.WriteString(fmt.Sprintf("   [ ] %s\n", s.options[i].name))
 		}
 	}
+	if len(matches) == 0 {
+		sb.WriteString(" " + usageStyle.Render("No match") + "\n")
+	}
+
+	// Display the hint and the keys, dimmed
+	hint := selectorKeysHint
+	if s.hint != "" {
+		hint = s.hint + " " + hint
+	}
+	sb.WriteString("\n " + usageStyle.Render(hint) + "\n")
 
 	return sb.String()
 }
@@ -147,7 +235,10 @@ func (s *selectorWidget) render() string {
 func helloMessage() Message {
 	buf := strings.Builder{}
 	buf.WriteString("Welcome to chait interactive mode!")
-	buf.WriteString(fmt.Sprintf("\nProvider: %s (Model: %s, Temperature: %.1f)", api.GetActiveProvider().GetName(), api.GetActiveProvider().GetCurrentModel(), api.GetActiveProvider().GetCurrentTemperature()))
+	if !statusBarEnabled() {
+		// The status bar shows them otherwise
+		buf.WriteString(fmt.Sprintf("\nProvider: %s (Model: %s, Temperature: %.1f)", api.GetActiveProvider().GetName(), api.GetActiveProvider().GetCurrentModel(), api.GetActiveProvider().GetCurrentTemperature()))
+	}
 	buf.WriteString("\nType ':h' to see all available commands.")
 	buf.WriteString("\n-----------------------------------")
 	return Message{
@@ -159,15 +250,17 @@ func helloMessage() Message {
 func helpMessage() Message {
 	buf := strings.Builder{}
 	buf.WriteString("-----------------------------------")
-	buf.WriteString(fmt.Sprintf("\nProvider: %s (Model: %s, Temperature: %.1f)", api.GetActiveProvider().GetName(), api.GetActiveProvider().GetCurrentModel(), api.GetActiveProvider().GetCurrentTemperature()))
-	buf.WriteString("\nAvailable commands:\n")
-	buf.WriteString("- ':h' - Show this message\n")
-	buf.WriteString("- ':p' - select providers\n")
-	buf.WriteString("- ':m' - select models\n")
-	buf.WriteString("- ':t' - Set the temperature\n")
-	buf.WriteString("- ':k' - Set the API key\n")
-	buf.WriteString("- ':c' - Start a new conversation\n")
-	buf.WriteString("- 'ctrl+c' - Exit interactive mode\n")
+	if !statusBarEnabled() {
+		// The status bar shows them otherwise
+		buf.WriteString(fmt.Sprintf("\nProvider: %s (Model: %s, Temperature: %.1f)", api.GetActiveProvider().GetName(), api.GetActiveProvider().GetCurrentModel(), api.GetActiveProvider().GetCurrentTemperature()))
+	}
+	buf.WriteString("\nAvailable commands (press Enter to run them):\n")
+	for _, c := range lineCommands {
+		buf.WriteString(fmt.Sprintf("- '%s' - %s\n", c.Usage(), c.Summary))
+	}
+	for _, k := range keyBindings() {
+		buf.WriteString(fmt.Sprintf("- '%s' - %s\n", k.Key, k.Summary))
+	}
 	buf.WriteString("-----------------------------------")
 	return Message{
 		Type:    MessageTypeChait,
@@ -175,13 +268,6 @@ func helpMessage() Message {
 	}
 }
 
-func systemMessage() Message {
-	return Message{
-		Type:    MessageTypeSystem,
-		Content: "You are a helpful assistant.",
-	}
-}
-
 // Cursor blink tick message
 type cursorBlinkMsg struct{}
 
@@ -205,6 +291,12 @@ type interactiveModel struct {
 	// API key input mode
 	apiKeyInputMode bool
 
+	// Custom temperature input, chosen in the temperature selector, and system prompt
+	// editing with ':s'
+	temperatureInputMode bool
+	systemPromptEditMode bool
+	inputDraft           []rune // Input typed before, restored afterwards
+
 	// Text selection related fields
 	selecting      bool   // Whether we are currently selecting text
 	selectionStart point  // Start position of selection
@@ -218,25 +310,128 @@ type interactiveModel struct {
 	providerSelector    selectorWidget // Widget for selecting providers
 	modelSelector       selectorWidget // Widget for selecting models
 	temperatureSelector selectorWidget // Widget for selecting temperature presets
+	sessionSelector     selectorWidget // Widget for opening saved conversations with ':o'
+	personaSelector     selectorWidget // Widget for applying personas with ':persona'
 
 	autoScrollBottom bool
+
+	// Whether the last stream stalled and can be retried with 'r'
+	streamStalled bool
+
+	// Wrapped lines of the messages, shared by the copies of the model
+	layout *layoutCache
+
+	// Progress of the pending request, such as retries, shown until content arrives
+	streamStatus  string
+	streamRetryAt time.Time // Next attempt of a retried request, rendered as a countdown
+
+	// Tokens of the response being streamed, counted per chunk for the status bar
+	streamedTokens int
+
+	// When the pending request was sent and the frame of the spinner shown until its
+	// first token arrives
+	waitingSince time.Time
+	spinnerFrame int
+
+	// Whether to use the alternate screen, disabled for terminals recorded without support
+	altScreen bool
+
+	// Inline mode prints completed messages into the scrollback of the terminal instead of
+	// using the alternate screen, inlinePrinted is the number of messages printed so far
+	inline        bool
+	inlinePrinted int
+
+	// Cancels the HTTP request of the current stream
+	cancelStream context.CancelFunc
+
+	// Refusal hint state: 'e' edits the refused prompt, 'm' switches model and resends it
+	refusalHint               bool
+	resendOnModelSelect       bool
+	resendOnTemperatureSelect bool // ':r t' resends the last prompt once a temperature is picked
+	regenerate                bool // ':r' resends the last prompt once the command ran
+
+	// Problems found in the message being sent, kept until it is sent or fixed with ctrl+f
+	lintIssues  []lintIssue
+	lintedInput string
+
+	// Saved conversation being continued, nil until the conversation is saved
+	session   *session.Session
+	archiving bool // Whether the older half of the conversation is being summarized for archival
+
+	// Whether ':share' waits for the upload of the conversation to be confirmed
+	shareConfirm bool
+
+	// Copy mode: keys act on the focused response instead of the input
+	copyMode  bool
+	copyFocus int // Index of the focused response in messages
+
+	// Inputs sent in this and previous sessions recalled with up/down, oldest first, the
+	// position of the one shown (-1 when not browsing) and the input typed before browsing
+	inputHistory []string
+	historyPos   int
+	historyDraft []rune
+
+	// Fuzzy search of the input history started with ctrl+r
+	historySearch historySearchState
+
+	// Search of the conversation started with '/'
+	search searchState
+
+	// Normal mode of the vi keymap, where keys navigate instead of editing the input, and
+	// the first key of a two-key command such as gg
+	viNormal  bool
+	viPending string
+
+	// Images and text files attached with ':f', sent with the next message
+	pendingImages []string
+	pendingFiles  []attachedFile
+
+	// Providers messages are sent to in compare mode, nil outside compare mode
+	compareProviders []provider.Provider
+	compareCtx       context.Context // Context of the pending comparison
+
+	// Whether the layout overlay of debug builds is shown, toggled with F10
+	debugOverlay bool
+}
+
+// stopStream cancels the current request, if any, and forgets its channel
+func (m *interactiveModel) stopStream() {
+	if m.cancelStream != nil {
+		m.cancelStream()
+		m.cancelStream = nil
+	}
+	m.respChan = nil
 }
 
-func (m interactiveModel) getSystemMessage() provider.ChatMessage {
+// dropLastResponse removes everything after the last user message and returns its content
+func (m *interactiveModel) dropLastResponse() string {
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Type == MessageTypeUser {
+			m.messages = m.messages[:i+1]
+			return m.messages[i].Content
+		}
+	}
+	return ""
+}
+
+// getSystemMessages returns the system and developer messages of the conversation, in order
+func (m interactiveModel) getSystemMessages() []provider.ChatMessage {
+	var system []provider.ChatMessage
 	for _, msg := range m.messages {
-		if msg.Type == MessageTypeSystem {
-			return msg.ToChatMessage()
+		if isInstruction(msg.Type) {
+			system = append(system, msg.ToChatMessage())
 		}
 	}
-	// Return an empty chat message if no system message is found
-	return provider.ChatMessage{}
+	return system
 }
 
 func (m interactiveModel) getRecentMessages() []provider.ChatMessage {
 	chatMessages := []provider.ChatMessage{}
+	first := len(m.messages) // Oldest message of the recent history
 	for i := len(m.messages) - 1; i >= 0; i-- {
 		if m.messages[i].Type == MessageTypeAssistant || m.messages[i].Type == MessageTypeUser {
 			chatMessages = append(chatMessages, m.messages[i].ToChatMessage())
+			first = i
 			if len(chatMessages) >= 20 {
 				break
 			}
@@ -248,8 +443,8 @@ func (m interactiveModel) getRecentMessages() []provider.ChatMessage {
 		chatMessages[i], chatMessages[j] = chatMessages[j], chatMessages[i]
 	}
 
-	// Add system message at the beginning and return
-	return append([]provider.ChatMessage{m.getSystemMessage()}, chatMessages...)
+	// Add the system messages and the pinned messages older than the history at the beginning and return
+	return append(append(m.getSystemMessages(), m.pinnedMessagesBefore(first)...), chatMessages...)
 }
 
 func (m *interactiveModel) enterSettingAPIKeyMode() {
@@ -306,83 +501,65 @@ func (m *interactiveModel) updateSelectedText() {
 		line := allLines[i].Content
 		lineRunes := []rune(line)
 
-		// Handle single line selection
-		if start.line == end.line {
-			// Convert visual column positions to rune indices
-			startRuneIdx := visualColumnToRuneIndex(lineRunes, start.col)
-			endRuneIdx := visualColumnToRuneIndex(lineRunes, end.col)
-
-			// Ensure indices are within bounds
-			if startRuneIdx < 0 {
-				startRuneIdx = 0
-			}
-			if startRuneIdx > len(lineRunes) {
-				startRuneIdx = len(lineRunes)
-			}
-			if endRuneIdx < 0 {
-				endRuneIdx = 0
-			}
-			if endRuneIdx > len(lineRunes) {
-				endRuneIdx = len(lineRunes)
-			}
-
-			if startRuneIdx < endRuneIdx {
-				selectedText.WriteString(string(lineRunes[startRuneIdx:endRuneIdx]))
-			}
-		} else {
-			// Handle multi-line selection
-			if i == start.line {
-				// First line - from start column to end of line
-				startRuneIdx := visualColumnToRuneIndex(lineRunes, start.col)
-				if startRuneIdx < 0 {
-					startRuneIdx = 0
-				}
-				if startRuneIdx > len(lineRunes) {
-					startRuneIdx = len(lineRunes)
-				}
-
-				selectedText.WriteString(string(lineRunes[startRuneIdx:]))
-			} else if i == end.line {
-				// Last line - from beginning to end column
-				endRuneIdx := visualColumnToRuneIndex(lineRunes, end.col)
-				if endRuneIdx < 0 {
-					endRuneIdx = 0
-				}
-				if endRuneIdx > len(lineRunes) {
-					endRuneIdx = len(lineRunes)
-				}
+		// The first and last lines are selected from and up to the selection columns
+		startCol, endCol := 0, -1
+		if i == start.line {
+			startCol = start.col
+		}
+		if i == end.line {
+			endCol = end.col
+		}
+		startRuneIdx, endRuneIdx := selectedRunes(lineRunes, startCol, endCol)
 
-				selectedText.WriteString("\n")
-				selectedText.WriteString(string(lineRunes[:endRuneIdx]))
-			} else {
-				// Middle lines - entire line
-				selectedText.WriteString("\n")
-				selectedText.WriteString(line)
-			}
+		if i > start.line {
+			selectedText.WriteString("\n")
 		}
+		selectedText.WriteString(string(lineRunes[startRuneIdx:endRuneIdx]))
 	}
 
 	m.selectedText = selectedText.String()
 }
 
-// visualColumnToRuneIndex converts a visual column position to a rune index
-// This handles wide characters like Chinese characters correctly
+// visualColumnToRuneIndex converts a visual column position to the index of the rune displayed
+// at that column, so that the second cell of a wide character such as a full-width comma maps
+// to that character. Columns after the end of the line map to its length.
 func visualColumnToRuneIndex(lineRunes []rune, visualColumn int) int {
 	visualPos := 0
 	for i, r := range lineRunes {
-		// If we've reached or exceeded the visual column, return this index
-		if visualPos >= visualColumn {
+		visualPos += runewidth.RuneWidth(r)
+		if visualPos > visualColumn {
 			return i
 		}
-
-		// Add the width of this rune to our visual position
-		visualPos += runewidth.RuneWidth(r)
 	}
+	return len(lineRunes)
+}
 
-	// If we get here, the visual column is beyond the end of the string
+// visualColumnToRuneEnd converts the visual column ending a selection, which is excluded,
+// to the index after the last rune with a cell before it. A wide character is included as
+// soon as its first cell is, and combining marks stay with the character they follow.
+func visualColumnToRuneEnd(lineRunes []rune, visualColumn int) int {
+	visualPos := 0
+	for i, r := range lineRunes {
+		width := runewidth.RuneWidth(r)
+		if visualPos >= visualColumn && width > 0 {
+			return i
+		}
+		visualPos += width
+	}
 	return len(lineRunes)
 }
 
+// selectedRunes returns the range of runes of a line selected from the visual column startCol
+// up to endCol, excluded, or up to the end of the line if endCol is negative
+func selectedRunes(lineRunes []rune, startCol, endCol int) (int, int) {
+	start := visualColumnToRuneIndex(lineRunes, max(startCol, 0))
+	end := len(lineRunes)
+	if endCol >= 0 {
+		end = visualColumnToRuneEnd(lineRunes, endCol)
+	}
+	return start, max(start, end)
+}
+
 func refreshConfig(m *interactiveModel) {
 	activeProvider := api.GetActiveProvider()
 	availableProviders := api.GetAvailableProviders()
@@ -428,8 +605,8 @@ func refreshConfig(m *interactiveModel) {
 	m.modelSelector.currentIndex = currentModelIndex
 
 	// Find the current temperature preset index in the list
-	currentTemperatureIndex := 0
-	temperatureOptions := make([]selectorOption, len(temperaturePresets))
+	currentTemperatureIndex := -1
+	temperatureOptions := make([]selectorOption, len(temperaturePresets), len(temperaturePresets)+1)
 	for i, preset := range temperaturePresets {
 		temperatureOptions[i] = selectorOption{
 			name:  fmt.Sprintf("%s (%.1f) - %s", preset.Name, preset.Value, preset.Description),
@@ -439,6 +616,11 @@ func refreshConfig(m *interactiveModel) {
 			currentTemperatureIndex = i
 		}
 	}
+	// Other temperatures are entered in the input, the option is selected when one is set
+	temperatureOptions = append(temperatureOptions, customTemperatureOption(currentTemperature, currentTemperatureIndex >= 0))
+	if currentTemperatureIndex < 0 {
+		currentTemperatureIndex = len(temperatureOptions) - 1
+	}
 	m.temperatureSelector.options = temperatureOptions
 	m.temperatureSelector.currentIndex = currentTemperatureIndex
 }
@@ -447,7 +629,7 @@ func initialInteractiveModel(input string) (interactiveModel, tea.Cmd) {
 	hello := helloMessage()
 
 	model := interactiveModel{
-		messages:    []Message{hello, systemMessage()},
+		messages:    append([]Message{hello}, systemMessagesWithProblems()...),
 		input:       []rune{},
 		cursor:      0,
 		respChan:    nil,
@@ -465,6 +647,10 @@ func initialInteractiveModel(input string) (interactiveModel, tea.Cmd) {
 		// Initialize cursor blinking state
 		cursorVisible: true,
 
+		// Recall the inputs of the previous sessions with up/down
+		inputHistory: loadInputHistory(),
+		historyPos:   -1,
+
 		// Initialize provider selector widget
 		providerSelector: selectorWidget{
 			title:    "Select a provider",
@@ -480,13 +666,46 @@ func initialInteractiveModel(input string) (interactiveModel, tea.Cmd) {
 		// Initialize temperature selector widget
 		temperatureSelector: selectorWidget{
 			title:    "Select a temperature preset",
+			hint:     "Lower values give focused answers, higher values more varied ones.",
+			isActive: false,
+		},
+		sessionSelector: selectorWidget{
+			title:    "Open a conversation",
+			isActive: false,
+		},
+		personaSelector: selectorWidget{
+			title:    "Select a persona",
+			hint:     "Its system prompt, provider, model and temperature replace the current ones.",
 			isActive: false,
 		},
 		autoScrollBottom: true,
+		altScreen:        true,
+		layout:           &layoutCache{},
 	}
 
 	refreshConfig(&model)
 
+	if err := loadTheme(); err != nil {
+		model.messages = append(model.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("theme: %v", err),
+		})
+	}
+
+	if problems := keymapProblems(); len(problems) > 0 {
+		model.messages = append(model.messages, Message{
+			Type:    MessageTypeError,
+			Content: "Key settings problems, defaults are used instead:\n- " + strings.Join(problems, "\n- "),
+		})
+	}
+
+	if unreadyProvider != "" {
+		model.messages = append(model.messages, Message{
+			Type:    MessageTypeChait,
+			Content: fmt.Sprintf("%s has no API key, using %s for now. :p! switches back to %s.", unreadyProvider, api.GetActiveProviderName(), unreadyProvider),
+		})
+	}
+
 	if input != "" {
 		model.messages = append(model.messages, Message{
 			Type:    MessageTypeUser,
@@ -501,13 +720,15 @@ func initialInteractiveModel(input string) (interactiveModel, tea.Cmd) {
 func (m interactiveModel) Init() tea.Cmd {
 	// Request the terminal dimensions on startup
 	var cmds []tea.Cmd
-	cmds = append(cmds, tea.EnterAltScreen)
+	if m.altScreen {
+		cmds = append(cmds, tea.EnterAltScreen)
+	}
 
 	// Start the cursor blink timer
 	cmds = append(cmds, cursorBlinker())
 
 	// If there's a user message, automatically start streaming
-	if len(m.messages) > 2 && m.messages[len(m.messages)-1].Type == MessageTypeUser {
+	if len(m.messages) > 0 && m.messages[len(m.messages)-1].Type == MessageTypeUser {
 		cmds = append(cmds, func() tea.Msg {
 			return startStreamingMsg{}
 		})
@@ -519,27 +740,62 @@ func (m interactiveModel) Init() tea.Cmd {
 // Custom message types for streaming responses
 type startStreamingMsg struct{}
 type streamResponseMsg struct {
+	source  <-chan provider.StreamResponse // Channel the response was read from
 	Content string
 	Done    bool
 	Error   error
+	Status  string
+	RetryAt time.Time
+	Usage   *provider.Usage
+
+	AnsweredBy    string
+	AnsweredModel string
+
+	pending *provider.StreamResponse // Response read after the merged content, handled next
 }
 
-// Command to process streaming responses
-func processStreamResponse(respChan <-chan provider.StreamResponse) tea.Cmd {
+// Command to process streaming responses, merging the chunks streamed during the interval
+func processStreamResponse(respChan <-chan provider.StreamResponse, interval time.Duration) tea.Cmd {
 	return func() tea.Msg {
 		resp, ok := <-respChan
 		if !ok {
-			return streamResponseMsg{Done: true}
-		}
-		return streamResponseMsg{
-			Content: resp.Content,
-			Done:    resp.Done,
-			Error:   resp.Error,
+			return streamResponseMsg{source: respChan, Done: true}
 		}
+		return mergeStreamResponses(respChan, resp, interval)
 	}
 }
 
+// newStreamResponseMsg returns the message of a response read from a channel
+func newStreamResponseMsg(respChan <-chan provider.StreamResponse, resp provider.StreamResponse) streamResponseMsg {
+	return streamResponseMsg{
+		source:  respChan,
+		Content: resp.Content,
+		Done:    resp.Done,
+		Error:   resp.Error,
+		Status:  resp.Status,
+		RetryAt: resp.RetryAt,
+		Usage:   resp.Usage,
+
+		AnsweredBy:    resp.AnsweredBy,
+		AnsweredModel: resp.AnsweredModel,
+	}
+}
+
+// Update handles a message and, in inline mode, prints the messages it completed
 func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	model, cmd := m.update(msg)
+	updated, ok := model.(interactiveModel)
+	if !ok || !updated.inline {
+		return model, cmd
+	}
+	updated, printCmd := updated.printInline()
+	if printCmd == nil {
+		return updated, cmd
+	}
+	return updated, tea.Sequence(printCmd, cmd)
+}
+
+func (m interactiveModel) update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	var (
 		cmd tea.Cmd
 	)
@@ -547,15 +803,33 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	// Handle cursor blink tick
 	case cursorBlinkMsg:
-		// Toggle cursor visibility
-		m.cursorVisible = !m.cursorVisible
+		// Toggle cursor visibility, the ticks keep refreshing countdowns when it does not blink
+		m.cursorVisible = !m.cursorVisible || reduceMotion()
 		// Continue the blinking
 		return m, cursorBlinker()
 
+	// Animate the spinner until the first token arrives
+	case spinnerMsg:
+		return m, m.handleSpinner(msg)
+
+	// Save or reject the API key entered with :k once it is checked
+	case keyCheckedMsg:
+		m.handleKeyChecked(msg)
+		return m, nil
+
+	// Apply changes made to the config file while chait is running
+	case configChangedMsg:
+		m.applyConfigChange(msg)
+		return m, nil
+
 	// Handle window resize events
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
 		m.height = msg.Height
+	case compareDoneMsg:
+		m.handleCompareDone(msg)
+		return m, nil
+
 	case startStreamingMsg:
 		// Check if the current provider is ready
 		if !api.GetActiveProvider().IsReady() {
@@ -564,11 +838,13 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			return m, nil
 		}
 
-		// Start streaming chat request
-		respChan, err := api.SendStreamingChatRequest(m.getRecentMessages())
+		// Start streaming chat request, cancelled with ESC
+		ctx, cancel := context.WithCancel(context.Background())
+		respChan, err := api.SendStreamingChatRequest(ctx, m.getRecentMessages())
 		m.messages = append(m.messages, Message{
 			Type:    MessageTypeAssistant,
 			Content: "",
+			JSON:    util.IsJSONMode(),
 		})
 
 		if err != nil {
@@ -579,13 +855,20 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Content: err.Error(),
 			}
 			m.enableInput = true
+			cancel()
 			return m, nil
 		}
 		// Store the response channel in the model
 		m.respChan = respChan
-		return m, processStreamResponse(respChan)
+		m.cancelStream = cancel
+		return m, tea.Batch(processStreamResponse(respChan, streamRenderInterval()), m.startWaiting())
 
 	case streamResponseMsg:
+		// Ignore responses of a stream that was cancelled
+		if msg.source != m.respChan {
+			return m, nil
+		}
+
 		// Handle streaming response
 		lastIdx := len(m.messages) - 1
 
@@ -595,26 +878,87 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				Type:    MessageTypeError,
 				Content: msg.Error.Error(),
 			}
+			m.enableInput = true
+			m.stopStream()
+			m.streamStatus = ""
+			if errors.Is(msg.Error, provider.ErrStreamStalled) {
+				// Let the user retry the request instead of waiting forever
+				m.messages[lastIdx].Content += " - press r to retry"
+				m.streamStalled = true
+			}
+			m.autoSave()
 			return m, nil
 		}
 
+		if msg.AnsweredBy != "" {
+			// A fallback provider answers instead of the active one
+			m.messages[lastIdx].AnsweredBy = msg.AnsweredBy
+			m.messages[lastIdx].AnsweredModel = msg.AnsweredModel
+			return m, processStreamResponse(m.respChan, streamRenderInterval())
+		}
+
+		if msg.Status != "" {
+			// Show the progress until the response starts
+			m.streamStatus = msg.Status
+			m.streamRetryAt = msg.RetryAt
+			return m, processStreamResponse(m.respChan, streamRenderInterval())
+		}
+		m.streamStatus = ""
+		if m.messages[lastIdx].Content == "" {
+			m.streamedTokens = 0
+		}
+		m.streamedTokens += currentTokenizer().Count(msg.Content)
+
 		// Update the last message with new content
 		m.messages[lastIdx] = Message{
-			Type:    MessageTypeAssistant,
-			Content: m.messages[lastIdx].Content + msg.Content,
+			Type:          MessageTypeAssistant,
+			Content:       m.messages[lastIdx].Content + msg.Content,
+			JSON:          m.messages[lastIdx].JSON,
+			Usage:         msg.Usage,
+			AnsweredBy:    m.messages[lastIdx].AnsweredBy,
+			AnsweredModel: m.messages[lastIdx].AnsweredModel,
 		}
 
-		// Auto-scroll if enabled
-		if m.autoScrollBottom {
+		// Auto-scroll if enabled, only once the response is shown when reducing motion
+		if m.autoScrollBottom && (msg.Done || !reduceMotion()) {
 			m.scrollToBottom()
 		}
 
 		// If not done, continue processing the stream
 		if !msg.Done {
 			// Continue processing the stream with the channel stored in the model
-			return m, processStreamResponse(m.respChan)
+			return m, continueStream(msg, streamRenderInterval())
 		}
 		m.enableInput = true
+		m.stopStream()
+
+		// Record the usage and estimated cost of the response
+		if msg.Usage != nil {
+			providerName, model := api.GetActiveProviderName(), api.GetCurrentModel()
+			if answered := m.messages[lastIdx]; answered.AnsweredBy != "" {
+				providerName, model = answered.AnsweredBy, answered.AnsweredModel
+			}
+			record := recordUsage(providerName, model, msg.Usage)
+			m.messages[lastIdx].Cost = &record
+		}
+
+		// Offer one-keystroke alternatives when the model refused to answer
+		if refusalHintsEnabled() && isRefusal(m.messages[lastIdx].Content) {
+			m.messages = append(m.messages, refusalHintMessage())
+			m.refusalHint = true
+			if m.autoScrollBottom {
+				m.scrollToBottom()
+			}
+		}
+		m.autoSave()
+		return m, m.archiveIfTooLong()
+
+	case archiveDoneMsg:
+		m.handleArchiveDone(msg)
+		return m, nil
+
+	case shareDoneMsg:
+		m.handleShareDone(msg)
 		return m, nil
 
 	case tea.MouseMsg:
@@ -629,16 +973,8 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.MouseButtonWheelDown:
 			m.scrollDown(3) // Scroll down 3 lines per wheel tick
 
-			// Check if we've scrolled to the bottom
-			allLines := m.getFormattedMessageLines()
-			visibleHeight := m.height - 3 // Reserve space for input area
-			maxScroll := len(allLines) - visibleHeight
-			if maxScroll < 0 {
-				maxScroll = 0
-			}
-
 			// Only re-enable auto-scrolling if we've manually scrolled all the way to the bottom
-			if m.scrollPos >= maxScroll {
+			if isAtBottom(m.scrollPos, len(m.getFormattedMessageLines()), m.height) {
 				m.autoScrollBottom = true
 			}
 
@@ -653,7 +989,7 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				// Calculate the position in the text based on mouse coordinates
 				// Adjust for scroll position
 				linePos := mouseEvent.Y + m.scrollPos
-				m.selectionStart = point{line: linePos, col: mouseEvent.X}
+				m.selectionStart = point{line: linePos, col: max(0, mouseEvent.X-m.textMargin())}
 				m.selectionEnd = m.selectionStart
 				return m, nil
 
@@ -662,7 +998,7 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.selecting {
 					// Update the end point of the selection
 					linePos := mouseEvent.Y + m.scrollPos
-					m.selectionEnd = point{line: linePos, col: mouseEvent.X}
+					m.selectionEnd = point{line: linePos, col: max(0, mouseEvent.X-m.textMargin())}
 
 					// Extract the selected text
 					m.updateSelectedText()
@@ -674,14 +1010,14 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				if m.selecting {
 					// Update the end point of the selection
 					linePos := mouseEvent.Y + m.scrollPos
-					m.selectionEnd = point{line: linePos, col: mouseEvent.X}
+					m.selectionEnd = point{line: linePos, col: max(0, mouseEvent.X-m.textMargin())}
 
 					// Extract the selected text
 					m.updateSelectedText()
 
 					// Copy selected text to clipboard if not empty
 					if m.selectedText != "" {
-						err := clipboard.WriteAll(m.selectedText)
+						err := copyToClipboard(m.selectedText)
 						if err != nil {
 							// If clipboard fails, we still want to keep the selection visible
 							// but we don't want to reset the selecting state
@@ -701,28 +1037,105 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		}
 
 	case tea.KeyMsg:
-		switch msg.String() {
-		case "ctrl+p":
+		if m.copyMode {
+			return m.handleCopyModeKey(msg)
+		}
+		if m.search.active && m.handleSearchKey(msg) {
+			return m, nil
+		}
+		if m.historySearch.active {
+			m.handleHistorySearchKey(msg)
+			return m, nil
+		}
+		if m.streamStalled {
+			// 'r' retries the stalled request right after it, any other key leaves it
+			m.streamStalled = false
+			if m.isHintKey(msg, "r") && m.messages[len(m.messages)-1].Type == MessageTypeError {
+				return m, m.resendLastPrompt()
+			}
+		}
+		if m.refusalHint {
+			// 'e' and 'm' act on the refusal right after the hint, any other key leaves it
+			m.refusalHint = false
+			onHint := m.messages[len(m.messages)-1].Content == refusalHintMessage().Content
+			switch {
+			case onHint && m.isHintKey(msg, "e"): // Edit the refused prompt and resend it with Enter
+				prompt := m.dropLastResponse()
+				m.messages = m.messages[:len(m.messages)-1]
+				m.input = []rune(prompt)
+				m.cursor = len(m.input)
+				m.scrollToBottom()
+				return m, nil
+			case onHint && m.isHintKey(msg, "m"): // Switch model, the prompt is resent once a model is selected
+				m.resendOnModelSelect = true
+				m.modelSelector.activate()
+				m.providerSelector.deactivate()
+				m.temperatureSelector.deactivate()
+				return m, nil
+			}
+		}
+		if viKeymap() && m.handleViKey(msg) {
+			return m, nil
+		}
+		action := keyActionFor(msg.String())
+		if m.handleEditingKey(action) {
+			return m, nil
+		}
+		if m.shareConfirm {
+			// Only 'y' confirms the upload of the conversation, any other key cancels it
+			m.shareConfirm = false
+			if msg.String() == "y" {
+				return m, m.startShare()
+			}
+			m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "Nothing was uploaded."})
+			m.scrollToBottom()
+			return m, nil
+		}
+		switch action {
+		case actionApplyFixes:
+			// Apply the quick fixes of the problems found in the message being sent
+			if len(m.lintIssues) > 0 {
+				m.applyLintFixes()
+				return m, nil
+			}
+		case actionCopyMode:
+			// Focus responses to toggle wrapping and scroll code blocks
+			m.enterCopyMode()
+			return m, nil
+		case actionHistorySearch:
+			// Search the messages and commands sent before
+			if m.enableInput && !m.apiKeyInputMode && !m.temperatureInputMode && !m.systemPromptEditMode && !m.selectorActive() {
+				m.startHistorySearch()
+				return m, nil
+			}
+		case actionSelectProvider:
 			// Enter provider switching mode
-			m.providerSelector.activate()
-			// Deactivate other selectors
-			m.modelSelector.deactivate()
-			m.temperatureSelector.deactivate()
+			m.activateSelector(&m.providerSelector)
 			return m, nil
-		case "ctrl+m":
+		case actionSelectModel:
 			// Enter model switching mode
-			m.modelSelector.activate()
-			// Deactivate other selectors
-			m.providerSelector.deactivate()
-			m.temperatureSelector.deactivate()
+			m.activateSelector(&m.modelSelector)
 			return m, nil
-		case "ctrl+t":
+		case actionSelectTemperature:
 			// Enter temperature switching mode
-			m.temperatureSelector.activate()
-			// Deactivate other selectors
-			m.providerSelector.deactivate()
-			m.modelSelector.deactivate()
+			m.activateSelector(&m.temperatureSelector)
 			return m, nil
+		case actionNewline:
+			m.insertAtCursor([]rune{'\n'})
+			return m, nil
+		}
+		switch msg.String() {
+		case "f10":
+			if debugBuild {
+				m.debugOverlay = !m.debugOverlay
+				return m, nil
+			}
+		case "/":
+			// Search the conversation, in inline mode the terminal searches its scrollback
+			if len(m.input) == 0 && !m.inline && !m.apiKeyInputMode && !m.temperatureInputMode && !m.systemPromptEditMode && !m.selectorActive() {
+				m.startSearch()
+				return m, nil
+			}
 		case "pgup":
 			m.scrollPageUp()
 			m.autoScrollBottom = false
@@ -730,45 +1143,27 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case "pgdown":
 			m.scrollPageDown()
 
-			// Check if we've scrolled to the bottom
-			allLines := m.getFormattedMessageLines()
-			visibleHeight := m.height - 3 // Reserve space for input area
-			maxScroll := len(allLines) - visibleHeight
-			if maxScroll < 0 {
-				maxScroll = 0
-			}
-
 			// Only re-enable auto-scrolling if we've manually scrolled all the way to the bottom
-			if m.scrollPos >= maxScroll {
+			if isAtBottom(m.scrollPos, len(m.getFormattedMessageLines()), m.height) {
 				m.autoScrollBottom = true
 			}
 
 			return m, nil
 		case "up":
 			// Handle Up key for all selectors
-			if m.providerSelector.isActive {
-				m.providerSelector.selectPrevious()
-				return m, nil
-			} else if m.modelSelector.isActive {
-				m.modelSelector.selectPrevious()
-				return m, nil
-			} else if m.temperatureSelector.isActive {
-				m.temperatureSelector.selectPrevious()
+			if s := m.activeSelector(); s != nil {
+				s.selectPrevious()
 				return m, nil
 			}
+			m.recallInput(-1)
 			return m, nil
 		case "down":
 			// Handle Down key for all selectors
-			if m.providerSelector.isActive {
-				m.providerSelector.selectNext()
-				return m, nil
-			} else if m.modelSelector.isActive {
-				m.modelSelector.selectNext()
-				return m, nil
-			} else if m.temperatureSelector.isActive {
-				m.temperatureSelector.selectNext()
+			if s := m.activeSelector(); s != nil {
+				s.selectNext()
 				return m, nil
 			}
+			m.recallInput(1)
 			return m, nil
 		case "home":
 			m.scrollToTop()
@@ -779,14 +1174,6 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 			// Re-enable auto-scrolling when manually scrolling to the bottom
 			m.autoScrollBottom = true
 			return m, nil
-		case "alt+enter":
-			newInput := make([]rune, len(m.input)+1)
-			copy(newInput, m.input[:m.cursor])
-			newInput[m.cursor] = '\n'
-			copy(newInput[m.cursor+1:], m.input[m.cursor:])
-			m.input = newInput
-			m.cursor++
-			return m, nil
 		}
 		// Handle keyboard shortcuts using string comparison to avoid conflicts
 
@@ -800,15 +1187,30 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				return m, nil
 			} else if m.modelSelector.isActive {
 				m.modelSelector.deactivate()
+				m.resendOnModelSelect = false
 				refreshConfig(&m)
 				return m, nil
 			} else if m.temperatureSelector.isActive {
 				m.temperatureSelector.deactivate()
+				m.resendOnTemperatureSelect = false
 				refreshConfig(&m)
 				return m, nil
+			} else if m.sessionSelector.isActive {
+				m.sessionSelector.deactivate()
+				return m, nil
+			} else if m.personaSelector.isActive {
+				m.personaSelector.deactivate()
+				return m, nil
+			} else if m.temperatureInputMode {
+				m.cancelTemperatureInput()
+				return m, nil
+			} else if m.systemPromptEditMode {
+				m.stopSystemPromptEdit()
+				return m, nil
 			} else if !m.enableInput {
-				// If streaming is in progress, cancel it and reset
-				m.respChan = nil
+				// If streaming is in progress, cancel the request and reset
+				m.stopStream()
+				m.streamStatus = ""
 				m.enableInput = true
 				return m, nil
 			}
@@ -816,7 +1218,10 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 		case tea.KeyEnter:
 			// Handle Enter key based on current state
 			// If in any selector mode, confirm selection and exit that mode
-			if m.providerSelector.isActive {
+			if s := m.activeSelector(); s != nil && !s.hasMatch() {
+				// Nothing matches the filter
+				return m, nil
+			} else if m.providerSelector.isActive {
 				v := m.providerSelector.confirm()
 				_ = api.SetActiveProvider(v.(string))
 				refreshConfig(&m)
@@ -825,11 +1230,39 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				v := m.modelSelector.confirm()
 				_ = api.SetProviderModel(api.GetActiveProvider(), v.(string))
 				refreshConfig(&m)
+				if m.resendOnModelSelect {
+					// Retry the refused or regenerated prompt with the new model
+					m.resendOnModelSelect = false
+					return m, m.resendLastPrompt()
+				}
 				return m, nil
 			} else if m.temperatureSelector.isActive {
 				v := m.temperatureSelector.confirm()
+				if v == customTemperature {
+					m.startTemperatureInput()
+					return m, nil
+				}
 				_ = api.SetProviderTemperature(api.GetActiveProvider(), v.(float64))
 				refreshConfig(&m)
+				if m.resendOnTemperatureSelect {
+					m.resendOnTemperatureSelect = false
+					return m, m.resendLastPrompt()
+				}
+				return m, nil
+			} else if m.sessionSelector.isActive {
+				v := m.sessionSelector.confirm()
+				m.openSelectedSession(v.(string))
+				return m, nil
+			} else if m.personaSelector.isActive {
+				v := m.personaSelector.confirm()
+				m.usePersona(v.(string))
+				m.scrollToBottom()
+				return m, nil
+			} else if m.temperatureInputMode {
+				return m, m.enterCustomTemperature()
+			} else if m.systemPromptEditMode {
+				m.saveSystemPrompt()
+				m.scrollToBottom()
 				return m, nil
 			} else if m.apiKeyInputMode {
 				// Handle API key input
@@ -838,50 +1271,81 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 					return m, nil
 				}
 
-				// Set the API key
-				err := api.SetAPIKey(apiKey)
-				if err != nil {
-					m.messages = append(m.messages, Message{
-						Type:    MessageTypeError,
-						Content: fmt.Sprintf("Error setting API key: %v", err),
-					})
-				} else {
-					m.messages = append(m.messages, Message{
-						Type:    MessageTypeChait,
-						Content: fmt.Sprintf("API key for '%s' has been set successfully.", api.GetActiveProvider().GetName()),
-					})
-				}
+				// Check the key with a minimal request before saving it
+				m.messages = append(m.messages, Message{
+					Type:    MessageTypeChait,
+					Content: fmt.Sprintf("Checking the API key with %s…", api.GetActiveProvider().GetName()),
+				})
+				m.enableInput = false
+				cmd = checkAPIKey(api.GetActiveProvider(), apiKey)
 
 				// Exit API key input mode
 				m.apiKeyInputMode = false
 				m.input = []rune{}
 				m.cursor = 0
-				return m, nil
+				return m, cmd
 			} else {
 				m.scrollToBottom()
 				if !m.enableInput {
 					m.autoScrollBottom = true
 					return m, nil
 				}
+				// Enter continues a code block instead of sending it unfinished
+				if m.handleEnterInFence() {
+					return m, nil
+				}
+
 				// Handle normal Enter key press for sending messages
 				userMsg := string(m.input)
 
-				if userMsg == "" {
+				if userMsg == "" && len(m.pendingImages) == 0 && len(m.pendingFiles) == 0 {
 					// Don't add empty messages to avoid API errors
 					// Just return the current model without changes
 					return m, nil
 				}
 
-				// Add user message to the messages list
+				// Handle ':' commands that take arguments
+				if m.handleLineCommand(userMsg) {
+					m.recordInput(userMsg)
+					return m, m.takeRegenerate()
+				}
+				// Include the files attached with ':f' or referenced as @path, keeping the input
+				// if one cannot be read
+				content, err := m.withAttachedFiles(userMsg)
+				if err != nil {
+					m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+					m.scrollToBottom()
+					return m, nil
+				}
+				// Warn about problems such as pasted secrets, in the input or the attached files,
+				// before the first attempt to send
+				if m.lintBeforeSending(userMsg, content) {
+					return m, nil
+				}
+				m.pendingFiles = nil
+				m.recordInput(userMsg)
+				userMsg = content
+
+				m.input = []rune{}
+				m.cursor = 0
+				m.autoScrollBottom = true
+				if len(m.compareProviders) > 0 && userMsg != "" {
+					// Compare mode sends the message to several providers instead
+					m.enableInput = false
+					return m, m.startComparison(userMsg)
+				}
+
+				// Add user message to the messages list, with the images attached with ':f'
 				m.messages = append(m.messages, Message{
 					Type:    MessageTypeUser,
 					Content: userMsg,
+					Images:  m.pendingImages,
 				})
-				m.input = []rune{}
-				m.cursor = 0
+				m.pendingImages = nil
 
-				m.autoScrollBottom = true
 				m.enableInput = false
+				m.streamStalled = false
+				m.refusalHint = false
 
 				// Return command to start streaming chat request
 				return m, func() tea.Msg {
@@ -906,7 +1370,9 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.input = newInput
 			}
 		case tea.KeyBackspace:
-			if m.cursor > 0 {
+			if s := m.activeSelector(); s != nil {
+				s.deleteFilter()
+			} else if m.cursor > 0 {
 				// Delete character before cursor position
 				newInput := make([]rune, len(m.input)-1)
 				copy(newInput, m.input[:m.cursor-1])
@@ -915,157 +1381,150 @@ func (m interactiveModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 				m.cursor--
 			}
 		case tea.KeySpace:
-			m.input = append(m.input, ' ')
-			m.cursor++
+			if s := m.activeSelector(); s != nil {
+				s.typeFilter([]rune{' '})
+				return m, nil
+			}
+			m.insertAtCursor([]rune{' '})
 
 		case tea.KeyRunes:
 
-			// Handle number key selection for all selectors
-			if len(m.input) == 1 && m.input[0] >= '1' && m.input[0] <= '9' {
-				// Convert the character to an index (0-based)
-				selectedIndex := int(m.input[0] - '1')
+			// Typed text filters the options of a selector
+			if s := m.activeSelector(); s != nil {
+				s.typeFilter(msg.Runes)
+				return m, nil
+			}
 
-				// Apply to the active selector
-				if m.providerSelector.isActive {
-					if m.providerSelector.selectByIndex(selectedIndex) {
-						m.providerSelector.confirm()
-					}
-					return m, nil
-				} else if m.modelSelector.isActive {
-					if m.modelSelector.selectByIndex(selectedIndex) {
-						m.modelSelector.confirm()
-					}
-					return m, nil
-				} else if m.temperatureSelector.isActive {
-					if m.temperatureSelector.selectByIndex(selectedIndex) {
-						m.temperatureSelector.confirm()
-					}
-					return m, nil
-				}
+			// Text pasted at once is inserted verbatim, newlines included, and never
+			// taken for key presses
+			if msg.Paste {
+				m.insertAtCursor(pastedText(msg.Runes, m.apiKeyInputMode))
+				return m, nil
 			}
 
 			// Normal text input handling
-			newInput := make([]rune, len(m.input)+len(msg.Runes))
-			copy(newInput, m.input[:m.cursor])
-			copy(newInput[m.cursor:], msg.Runes)
-			copy(newInput[m.cursor+len(msg.Runes):], m.input[m.cursor:])
-
-			// Handle help keys for selectors
-			if len(newInput) > 0 && newInput[0] == ':' {
-				switch string(newInput[1:]) {
-				case "p": // :p - Switch provider
-					// Enter provider switching mode
-					m.providerSelector.activate()
-					// Deactivate other selectors
-					m.modelSelector.deactivate()
-					m.temperatureSelector.deactivate()
-					m.input = []rune{}
-					m.cursor = 0
-					return m, nil
-				case "m": // :m - Switch model
-					// Enter model switching mode
-					m.modelSelector.activate()
-					// Deactivate other selectors
-					m.providerSelector.deactivate()
-					m.temperatureSelector.deactivate()
-					m.input = []rune{}
-					m.cursor = 0
-					return m, nil
-				case "t": // :t - Switch temperature
-					// Enter temperature switching mode
-					m.temperatureSelector.activate()
-					// Deactivate other selectors
-					m.providerSelector.deactivate()
-					m.modelSelector.deactivate()
-					m.input = []rune{}
-					m.cursor = 0
-					return m, nil
-				case "h": // :h - Show help
-					m.messages = append(m.messages, helpMessage())
-					m.input = []rune{}
-					m.cursor = 0
-					m.scrollToBottom()
-					return m, nil
-				case "k": // :k - Set API key
-					m.enterSettingAPIKeyMode()
-					return m, nil
-				case "c": // :c - Start a new conversation
-					m.messages = []Message{systemMessage()}
-					m.input = []rune{}
-					m.cursor = 0
-					m.scrollToBottom()
-					return m, nil
-				}
-			}
+			m.insertAtCursor(msg.Runes)
 
-			m.input = newInput
-			m.cursor += len(msg.Runes)
+			// Typing ``` opens a code block
+			if string(msg.Runes) == "`" && !m.apiKeyInputMode {
+				m.closeFence()
+			}
 		}
 	}
 
 	return m, cmd
 }
 
-// Format messages with proper wrapping for the viewport
-func (m interactiveModel) formatMessages() []messageWithType {
-	var messages []messageWithType = make([]messageWithType, 0, len(m.messages))
-	for _, msg := range m.messages {
-
-		prefixLen := 0
-		typeStr := ""
-		var content string
+// formatMessage wraps a message for the viewport. streaming is true for the response being
+// streamed, shownTotal is the cost of the conversation shown after the cost of a response.
+func (m interactiveModel) formatMessage(i int, msg Message, streaming bool, shownTotal float64) []messageWithType {
+	if msg.Compare != nil {
+		return m.formatComparison(msg, i)
+	}
 
-		// Format content based on message type
-		switch msg.Type {
-		case MessageTypeUser:
-			typeStr = "> "
-			prefixLen = len(typeStr)
-			// Handle text wrapping for the content
-			if m.width > 0 {
-				content = typeStr + wrapText(msg.Content, m.width, prefixLen)
-			} else {
-				content = typeStr + msg.Content
-			}
-		case MessageTypeAssistant:
-			typeStr = string(msg.Type) + ": "
-			prefixLen = len(typeStr)
-			// Handle text wrapping for the content
-			if m.width > 0 {
-				content = typeStr + wrapText(msg.Content, m.width, prefixLen)
-			} else {
-				content = typeStr + msg.Content
-			}
-			content += "\n"
-		case MessageTypeSystem:
-			typeStr = string(msg.Type) + ": "
-			prefixLen = len(typeStr)
-			// Handle text wrapping for the content
-			if m.width > 0 {
-				content = typeStr + wrapText(msg.Content, m.width, prefixLen)
-			} else {
-				content = typeStr + msg.Content
+	var messages []messageWithType
+	prefixLen := 0
+	typeStr := ""
+	var content string
+
+	// Format content based on message type
+	switch msg.Type {
+	case MessageTypeUser:
+		typeStr = "> "
+		prefixLen = len(typeStr)
+		text := msg.Content
+		if len(msg.Images) > 0 {
+			text += fmt.Sprintf(" [%s]", imageCount(len(msg.Images)))
+		}
+		if msg.Pinned {
+			text += " [pinned]"
+		}
+		// Handle text wrapping for the content
+		if m.textWidth() > 0 {
+			content = typeStr + wrapText(text, m.textWidth(), prefixLen)
+		} else {
+			content = typeStr + text
+		}
+	case MessageTypeAssistant:
+		typeStr = string(msg.Type) + ": "
+		if msg.Pinned {
+			typeStr = string(msg.Type) + " [pinned]: "
+		}
+		prefixLen = len(typeStr)
+		text := msg.Content
+		if msg.JSON {
+			// Pretty-print JSON responses, completing them while they are streaming
+			text = formatJSONPreview(text, streaming)
+		}
+		if streaming && text == "" && m.streamStatus != "" {
+			// The countdown is refreshed by the cursor blink ticks
+			text = provider.FormatRetryStatus(m.streamStatus, m.streamRetryAt)
+		} else if streaming && text == "" {
+			text = m.waitingIndicator()
+		} else if streaming && reduceMotion() {
+			text = reduceMotionPlaceholder
+		}
+		// Only render the end of long responses while they stream, if configured
+		pinHeader := ""
+		limit := streamMaxLines()
+		if streaming && limit > 0 {
+			text, pinHeader = pinStreamingText(text, limit)
+		}
+		// Handle text wrapping for the content, code blocks may be kept unwrapped
+		if m.textWidth() > 0 {
+			content = wrapMessageText(text, m.textWidth(), prefixLen, msg.NoWrap, msg.HScroll)
+		} else {
+			content = text
+		}
+		if pinHeader != "" {
+			content = typeStr + pinHeader + "\n" + lastLines(content, limit)
+		} else {
+			content = typeStr + content
+		}
+		if (msg.Usage != nil || msg.AnsweredBy != "") && !streaming {
+			// Show the token usage and the fallback provider as a dim footer below the response
+			messages = append(messages, messageWithType{Type: msg.Type, Content: content, Index: i})
+			var footer []string
+			if msg.AnsweredBy != "" {
+				footer = append(footer, fmt.Sprintf("answered by %s (%s) after a fallback", msg.AnsweredBy, msg.AnsweredModel))
 			}
-		case MessageTypeChait:
-			// Chait messages don't have a prefix
-			if m.width > 0 {
-				content = wrapText(msg.Content, m.width, 0)
-			} else {
-				content = msg.Content
+			if msg.Usage != nil {
+				footer = append(footer, formatUsage(msg.Usage))
 			}
-		case MessageTypeError:
-			typeStr = string(msg.Type) + ": "
-			prefixLen = len(typeStr)
-			// Handle text wrapping for the content
-			if m.width > 0 {
-				content = typeStr + wrapText(msg.Content, m.width, prefixLen)
-			} else {
-				content = typeStr + msg.Content
+			if msg.Cost != nil {
+				footer = append(footer, formatCost(*msg.Cost, shownTotal))
 			}
+			msg.Type, content = MessageTypeUsage, strings.Join(footer, " · ")
+		}
+		content += "\n"
+	case MessageTypeSystem, MessageTypeDeveloper:
+		typeStr = string(msg.Type) + ": "
+		prefixLen = len(typeStr)
+		// Handle text wrapping for the content
+		if m.textWidth() > 0 {
+			content = typeStr + wrapText(msg.Content, m.textWidth(), prefixLen)
+		} else {
+			content = typeStr + msg.Content
+		}
+	case MessageTypeChait:
+		// Chait messages don't have a prefix
+		if m.textWidth() > 0 {
+			content = wrapText(msg.Content, m.textWidth(), 0)
+		} else {
+			content = msg.Content
+		}
+	case MessageTypeError:
+		typeStr = string(msg.Type) + ": "
+		prefixLen = len(typeStr)
+		// Handle text wrapping for the content
+		if m.textWidth() > 0 {
+			content = typeStr + wrapText(msg.Content, m.textWidth(), prefixLen)
+		} else {
+			content = typeStr + msg.Content
 		}
-
-		messages = append(messages, messageWithType{Type: msg.Type, Content: content})
 	}
-	return messages
+
+	return append(messages, messageWithType{Type: msg.Type, Content: content, Index: i})
 }
 
 // Wrap text to fit within the terminal width
@@ -1144,16 +1603,45 @@ func findBreakPoint(runes []rune, width int) int {
 
 // Get the total number of lines in the formatted messages along with their message types
 func (m interactiveModel) getFormattedMessageLines() []messageWithType {
-	messages := m.formatMessages()
-	splittedMessages := make([]messageWithType, 0)
+	lines := make([]messageWithType, 0, m.layout.size())
+	// Running total of the estimated cost, shown once there is more than one response
+	sessionCost, pricedResponses := 0.0, 0
+	for i, msg := range m.messages {
+		streaming := i == len(m.messages)-1 && !m.enableInput
+		shownTotal := 0.0
+		if msg.Type == MessageTypeAssistant && msg.Compare == nil && (msg.Usage != nil || msg.AnsweredBy != "") && !streaming && msg.Cost != nil && msg.Cost.Priced {
+			sessionCost += msg.Cost.Cost
+			if pricedResponses++; pricedResponses > 1 {
+				shownTotal = sessionCost
+			}
+		}
 
-	for _, msg := range messages {
-		for _, line := range strings.Split(msg.Content, "\n") {
-			splittedMessages = append(splittedMessages, messageWithType{Type: msg.Type, Content: line})
+		// Only the response being streamed and comparisons change between renders, other
+		// messages are wrapped again when they or the width change
+		cacheable := !streaming && msg.Compare == nil
+		key := newLayoutKey(msg, m.textWidth(), shownTotal)
+		if cached, ok := m.layout.get(i, key); ok && cacheable {
+			lines = append(lines, cached...)
+			continue
+		}
+		var messageLines []messageWithType
+		for _, formatted := range m.formatMessage(i, msg, streaming, shownTotal) {
+			first := len(messageLines)
+			for _, line := range strings.Split(formatted.Content, "\n") {
+				messageLines = append(messageLines, messageWithType{Type: formatted.Type, Content: line, Index: i})
+			}
+			if formatted.Type == MessageTypeAssistant {
+				markCodeLines(messageLines[first:])
+			}
+		}
+		if cacheable {
+			m.layout.put(i, key, messageLines)
 		}
+		lines = append(lines, messageLines...)
 	}
+	m.layout.truncate(len(m.messages), len(lines))
 
-	return splittedMessages
+	return lines
 }
 
 // Scroll handling methods
@@ -1168,27 +1656,18 @@ func (m *interactiveModel) scrollUp(lines int) {
 }
 
 func (m *interactiveModel) scrollDown(lines int) {
-	allLines := m.getFormattedMessageLines()
-	maxScroll := len(allLines) - (m.height - 3) // Reserve space for input area
-	if maxScroll < 0 {
-		maxScroll = 0
-	}
-
-	m.scrollPos += lines
-	if m.scrollPos > maxScroll {
-		m.scrollPos = maxScroll
-	}
+	m.scrollPos = clampScroll(m.scrollPos+lines, len(m.getFormattedMessageLines()), m.height)
 }
 
 func (m *interactiveModel) scrollPageUp() {
 	if !m.enableInput {
 		m.autoScrollBottom = false
 	}
-	m.scrollUp(m.height / 2)
+	m.scrollUp(pageStep(m.height))
 }
 
 func (m *interactiveModel) scrollPageDown() {
-	m.scrollDown(m.height / 2)
+	m.scrollDown(pageStep(m.height))
 }
 
 func (m *interactiveModel) scrollToTop() {
@@ -1199,16 +1678,7 @@ func (m *interactiveModel) scrollToTop() {
 }
 
 func (m *interactiveModel) scrollToBottom() {
-	allLines := m.getFormattedMessageLines()
-	visibleHeight := m.height - 3 // Reserve space for input area
-
-	// Only scroll if content exceeds visible area
-	if len(allLines) > visibleHeight {
-		m.scrollPos = len(allLines) - visibleHeight
-	} else {
-		// If content fits in window, don't scroll
-		m.scrollPos = 0
-	}
+	m.scrollPos = maxScroll(len(m.getFormattedMessageLines()), m.height)
 }
 
 func (m interactiveModel) View() string {
@@ -1216,36 +1686,46 @@ func (m interactiveModel) View() string {
 	var sb strings.Builder
 	var input strings.Builder
 
+	// Rendering in a tiny terminal would only produce garbage, ask for more room instead
+	if isTerminalTooSmall(m.width, m.height) {
+		return m.tooSmallScreen()
+	}
+
 	// Check if we're in provider selection mode
 	if m.providerSelector.isActive {
 		// Use the provider selector widget to render the UI
 		return m.providerSelector.render()
 	} else if m.modelSelector.isActive {
 		// Use the model selector widget to render the UI
-		return m.modelSelector.render()
+		selector := m.modelSelector
+		if m.resendOnModelSelect {
+			selector.hint = "The last prompt is sent again to the selected model."
+		}
+		return selector.render()
 	} else if m.temperatureSelector.isActive {
 		// Use the temperature selector widget to render the UI
-		return m.temperatureSelector.render()
+		selector := m.temperatureSelector
+		if m.resendOnTemperatureSelect {
+			selector.hint = "The last prompt is sent again with the selected temperature."
+		}
+		return selector.render()
+	} else if m.sessionSelector.isActive {
+		// Use the session selector widget to render the UI
+		return m.sessionSelector.render()
+	} else if m.personaSelector.isActive {
+		return m.personaSelector.render()
 	}
 
 	// Get all lines from formatted messages
 	allLines := m.getFormattedMessageLines()
 
 	// Calculate visible portion based on scroll position
-	visibleHeight := m.height - 3 // Reserve space for input area
-	if visibleHeight < 1 {
-		visibleHeight = 1
-	}
-
-	startLine := m.scrollPos
-	endLine := startLine + visibleHeight
-
-	if startLine >= len(allLines) {
-		startLine = max(0, len(allLines)-1)
-	}
-
-	if endLine > len(allLines) {
-		endLine = len(allLines)
+	startLine, endLine := visibleRange(m.scrollPos, len(allLines), m.height)
+	atBottom := isAtBottom(m.scrollPos, len(allLines), m.height)
+	if m.inline {
+		// The terminal scrolls the printed messages, only the end of the others is shown
+		allLines = m.unprintedLines(allLines)
+		startLine, endLine, atBottom = max(0, len(allLines)-visibleHeight(m.height)), len(allLines), true
 	}
 
 	// Determine if we have an active selection
@@ -1260,23 +1740,19 @@ func (m interactiveModel) View() string {
 	}
 
 	// Render only the visible portion of messages
+	margin := strings.Repeat(" ", m.textMargin())
+	bar := m.scrollbar(startLine, len(allLines))
 	for i := startLine; i < endLine; i++ {
 		if i < len(allLines) {
 			line := allLines[i]
+			sb.WriteString(margin)
 
-			// Apply appropriate style based on the message type
-			var styledLine string
-			switch line.Type {
-			case MessageTypeUser:
-				styledLine = userStyle.Render(line.Content)
-			case MessageTypeAssistant:
-				styledLine = assistantStyle.Render(line.Content)
-			case MessageTypeSystem:
-				styledLine = systemStyle.Render(line.Content)
-			case MessageTypeError:
-				styledLine = errorStyle.Render(line.Content)
-			default: // MessageTypeChait
-				styledLine = chaitStyle.Render(line.Content)
+			// Apply appropriate style based on the message type, highlighting code blocks
+			styledLine := renderLine(line)
+			if m.search.active {
+				if highlighted, ok := m.renderSearchLine(line, i); ok {
+					styledLine = highlighted
+				}
 			}
 
 			// Check if this line is part of the selection
@@ -1285,42 +1761,17 @@ func (m interactiveModel) View() string {
 				lineRunes := []rune(line.Content) // Use the original unstyled line for selection
 
 				// Determine selection start and end rune indices for this line
-				startIdx, endIdx := 0, len(lineRunes)
+				startCol, endCol := 0, -1
 				if i == selStart.line {
-					startIdx = visualColumnToRuneIndex(lineRunes, selStart.col)
+					startCol = selStart.col
 				}
 				if i == selEnd.line {
-					endIdx = visualColumnToRuneIndex(lineRunes, selEnd.col)
-				}
-
-				// Ensure indices are within bounds
-				if startIdx < 0 {
-					startIdx = 0
-				}
-				if startIdx > len(lineRunes) {
-					startIdx = len(lineRunes)
-				}
-				if endIdx < 0 {
-					endIdx = 0
-				}
-				if endIdx > len(lineRunes) {
-					endIdx = len(lineRunes)
+					endCol = selEnd.col
 				}
+				startIdx, endIdx := selectedRunes(lineRunes, startCol, endCol)
 
 				// Get the appropriate style for this line
-				var style lipgloss.Style
-				switch line.Type {
-				case MessageTypeUser:
-					style = userStyle
-				case MessageTypeAssistant:
-					style = assistantStyle
-				case MessageTypeSystem:
-					style = systemStyle
-				case MessageTypeError:
-					style = errorStyle
-				default: // MessageTypeChait
-					style = chaitStyle
-				}
+				style := lineStyle(line.Type)
 
 				// Render the line with highlighted selection while preserving colors
 				if startIdx < endIdx {
@@ -1347,24 +1798,33 @@ func (m interactiveModel) View() string {
 				sb.WriteString(styledLine)
 			}
 
+			if row := i - startLine; row < len(bar) {
+				sb.WriteString(m.scrollbarPadding(line.Content, len(margin)) + bar[row])
+			}
 			sb.WriteString("\n")
 		}
 	}
 
-	// Calculate if we're at the bottom of the conversation
-	allLinesCount := len(allLines)
-	maxScroll := allLinesCount - visibleHeight
-	if maxScroll < 0 {
-		maxScroll = 0
-	}
-	isAtBottom := m.scrollPos >= maxScroll
-
-	// Only show input prompt when at the bottom of the conversation
-	if m.enableInput && isAtBottom {
+	if m.copyMode {
+		// Copy mode shows its keys instead of the input
+		sb.WriteString(usageStyle.Render(clipLine(m.copyModeStatus(), 0, m.width)))
+	} else if m.search.active {
+		// So does search mode
+		sb.WriteString(usageStyle.Render(clipLine(m.searchStatus(), 0, m.width)))
+	} else if m.historySearch.active {
+		sb.WriteString(userStyle.Render(clipLine(m.historySearchStatus(), 0, m.width)))
+	} else if m.enableInput && atBottom {
+		// Only show input prompt when at the bottom of the conversation
 
 		// Render the input with blinking cursor
 		inputBeforeCursor := string(m.input[:m.cursor])
 		inputAfterCursor := string(m.input[m.cursor:])
+
+		// Never display the API key being typed in safe mode
+		if m.apiKeyInputMode && util.IsSafeMode() {
+			inputBeforeCursor = strings.Repeat("*", m.cursor)
+			inputAfterCursor = strings.Repeat("*", len(m.input)-m.cursor)
+		}
 		input.WriteString(inputBeforeCursor)
 
 		// Show or hide cursor based on blink state
@@ -1372,8 +1832,9 @@ func (m interactiveModel) View() string {
 			input.WriteString("|")
 		} else {
 			// When cursor is invisible, we use a space to maintain consistent layout
-			// Only add space if we're not at the end of a line to avoid extra wrapping
-			if len(inputAfterCursor) > 0 {
+			// Only add space if we're not at the end of a line to avoid extra wrapping,
+			// or before the placeholder so it does not move
+			if len(inputAfterCursor) > 0 || len(m.input) == 0 {
 				input.WriteString(" ")
 			}
 		}
@@ -1383,25 +1844,112 @@ func (m interactiveModel) View() string {
 		// Apply userStyle to the input area to match user message color
 		inputText := "> " + wrapText(input.String(), m.width, 2)
 		sb.WriteString(userStyle.Render(inputText))
+
+		// Dimmed placeholder or help about the command being typed
+		if hint := fitHint(m.inputHint(), inputText, m.width); hint != "" {
+			sb.WriteString(" " + usageStyle.Render(hint))
+		}
 	}
 
-	return sb.String()
+	view := m.withStatusBar(sb.String(), len(allLines))
+	if m.debugOverlay {
+		return m.renderDebugOverlay(view, startLine, endLine, len(allLines))
+	}
+	return view
 }
 
-func StartInteractiveMode(input string) error {
+// tooSmallScreen asks the user to enlarge the terminal, normal rendering resumes on resize
+func (m interactiveModel) tooSmallScreen() string {
+	text := fmt.Sprintf("Please enlarge the terminal (%dx%d, need at least %dx%d)", m.width, m.height, minTerminalWidth, minTerminalHeight)
+	lines := strings.Split(wrapText(text, max(m.width, 1), 0), "\n")
+	if m.height > 0 && len(lines) > m.height {
+		lines = lines[:m.height]
+	}
+	return chaitStyle.Render(strings.Join(lines, "\n"))
+}
+
+// interactiveLogPath returns the file that receives log messages while the TUI is running
+func interactiveLogPath() string {
+	if logFile := viper.GetString("log_file"); logFile != "" {
+		return logFile
+	}
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "chait.log")
+}
+
+func StartInteractiveMode(input string, images []string) error {
 	// Get the initial model and commands
 	initialModel, _ := initialInteractiveModel(input)
+	initialModel.attachInitialImages(images)
+	return runInteractiveModel(initialModel)
+}
 
-	p := tea.NewProgram(
-		initialModel,
-		tea.WithAltScreen(),       // Use the full terminal in alternate screen mode
-		tea.WithMouseAllMotion(),  // Enable mouse support for all motion
-		tea.WithMouseCellMotion(), // Enable mouse cell motion events
-	)
+// StartInteractiveSession starts interactive mode with the messages of a resumed conversation
+// A non-empty input is sent as the next message
+func StartInteractiveSession(s *session.Session, messages []Message, input string, images []string) error {
+	initialModel, _ := initialInteractiveModel("")
+	initialModel.session = s
+	initialModel.messages = append([]Message{helloMessage()}, withSystemMessages(messages)...)
+	if input != "" {
+		initialModel.messages = append(initialModel.messages, Message{Type: MessageTypeUser, Content: input})
+	}
+	initialModel.attachInitialImages(images)
+	return runInteractiveModel(initialModel)
+}
 
-	if _, err := p.Run(); err != nil {
+// runInteractiveModel runs the TUI program with the given initial model
+func runInteractiveModel(initialModel interactiveModel) error {
+	// Redirect logs to a file so they don't corrupt the full-screen UI
+	var logFile *os.File
+	err := util.CheckWriteAllowed("writing logs")
+	if err == nil {
+		logFile, err = os.OpenFile(interactiveLogPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	}
+	if err == nil {
+		previous := util.SetLogOutput(logFile)
+		defer func() {
+			util.SetLogOutput(previous)
+			logFile.Close()
+		}()
+	} else {
+		previous := util.SetLogOutput(io.Discard)
+		defer util.SetLogOutput(previous)
+	}
+
+	// Adapt to the terminal capabilities recorded by "chait doctor --terminal"
+	caps, recorded := loadRecordedTerminalCapabilities()
+	var options []tea.ProgramOption
+	if inlineMode() {
+		// Leave the conversation in the scrollback, which the mouse keeps scrolling
+		initialModel.altScreen = false
+		initialModel.inline = true
+	} else if !recorded || caps.AltScreen {
+		options = append(options, tea.WithAltScreen()) // Use the full terminal in alternate screen mode
+	} else {
+		initialModel.altScreen = false
+	}
+	if recorded && !caps.BracketedPaste {
+		options = append(options, tea.WithoutBracketedPaste())
+	}
+	if !inlineMode() && (!recorded || caps.Mouse) {
+		options = append(options,
+			tea.WithMouseAllMotion(),  // Enable mouse support for all motion
+			tea.WithMouseCellMotion(), // Enable mouse cell motion events
+		)
+	}
+	if recorded {
+		util.DebugLog(util.ModuleTUI, "Using recorded capabilities for terminal %s: %+v", caps.Terminal, caps)
+	}
+
+	p := tea.NewProgram(initialModel, options...)
+	watchConfig(func(msg configChangedMsg) { p.Send(msg) })
+
+	final, err := p.Run()
+	if err != nil {
 		fmt.Printf("Alas, there's been an error: %v", err)
 		return err
 	}
+	if m, ok := final.(interactiveModel); ok {
+		printTranscript(m)
+	}
 	return nil
 }
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+
+	"github.com/plucury/chait/util"
+)
+
+// formatJSONPreview pretty-prints a JSON response, completing it while it is still streaming.
+// Content that cannot be parsed is returned unchanged, with an error marker once the stream is done.
+func formatJSONPreview(content string, streaming bool) string {
+	raw := extractJSON(content)
+	if raw == "" {
+		return content
+	}
+
+	var pretty bytes.Buffer
+	err := json.Indent(&pretty, []byte(raw), "", "  ")
+	if err == nil {
+		return pretty.String()
+	}
+
+	if streaming {
+		// Render the structure received so far
+		if completed, ok := util.CompletePartialJSON(raw); ok {
+			pretty.Reset()
+			if json.Indent(&pretty, []byte(completed), "", "  ") == nil {
+				return pretty.String() + "\n…"
+			}
+		}
+		return content
+	}
+
+	return fmt.Sprintf("%s\n[invalid JSON: %v]", content, err)
+}
+
+// validateJSONResponse returns an error if a response received in JSON mode is not valid JSON
+func validateJSONResponse(content string) error {
+	var v interface{}
+	if err := json.Unmarshal([]byte(extractJSON(content)), &v); err != nil {
+		return fmt.Errorf("invalid JSON response: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,81 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/util"
+)
+
+// keyCheckTimeout bounds the validation request of a new API key, retries included
+const keyCheckTimeout = 20 * time.Second
+
+// keyCheckedMsg carries the result of validating an API key entered with :k
+type keyCheckedMsg struct {
+	provider string
+	key      string
+	status   api.KeyStatus
+	err      error
+}
+
+// validateAPIKey checks a key of the provider with a minimal request
+func validateAPIKey(p provider.Provider, key string) (api.KeyStatus, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), keyCheckTimeout)
+	defer cancel()
+	return api.ValidateAPIKey(ctx, p, key)
+}
+
+// checkAPIKey validates a key in the background and reports the result as a keyCheckedMsg
+func checkAPIKey(p provider.Provider, key string) tea.Cmd {
+	return func() tea.Msg {
+		status, err := validateAPIKey(p, key)
+		return keyCheckedMsg{provider: p.GetName(), key: key, status: status, err: err}
+	}
+}
+
+// describeKeyCheck explains the result of a key validation and whether the key is saved
+func describeKeyCheck(providerName string, status api.KeyStatus, err error) string {
+	saved := "saved"
+	if util.IsSafeMode() || util.IsNoSave() {
+		saved = "kept for this run"
+	}
+	switch status {
+	case api.KeyInvalid:
+		return fmt.Sprintf("%s rejected the API key (%v). The key was not saved.", providerName, err)
+	case api.KeyUnverified:
+		return fmt.Sprintf("Could not verify the API key, %s did not answer (%v). The key was %s anyway; check your network or proxy settings.", providerName, err, saved)
+	}
+	if err != nil {
+		return fmt.Sprintf("API key for %s is valid and has been %s, but the provider reported: %v", providerName, saved, err)
+	}
+	return fmt.Sprintf("API key for %s is valid and has been %s.", providerName, saved)
+}
+
+// handleKeyChecked saves a key that was not rejected, or asks for the key again
+func (m *interactiveModel) handleKeyChecked(msg keyCheckedMsg) {
+	m.enableInput = true
+	if msg.status == api.KeyInvalid {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: describeKeyCheck(msg.provider, msg.status, msg.err)})
+		m.enterSettingAPIKeyMode()
+		return
+	}
+
+	if err := api.SetProviderAPIKey(msg.provider, msg.key); err != nil {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("Error setting API key: %v", err),
+		})
+	} else {
+		messageType := MessageTypeChait
+		if msg.status == api.KeyUnverified {
+			messageType = MessageTypeError
+		}
+		m.messages = append(m.messages, Message{Type: messageType, Content: describeKeyCheck(msg.provider, msg.status, msg.err)})
+	}
+	refreshConfig(m)
+	m.scrollToBottom()
+}
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// Actions of interactive mode bound to keys, remappable with keys.<action>
+const (
+	actionSelectProvider    = "select_provider"
+	actionSelectModel       = "select_model"
+	actionSelectTemperature = "select_temperature"
+	actionCopyMode          = "copy_mode"
+	actionHistorySearch     = "history_search"
+	actionApplyFixes        = "apply_fixes"
+	actionNewline           = "newline"
+	actionLineStart         = "line_start"
+	actionLineEnd           = "line_end"
+	actionWordBackward      = "word_backward"
+	actionWordForward       = "word_forward"
+	actionDeleteWord        = "delete_word"
+	actionDeleteToStart     = "delete_to_start"
+	actionDeleteToEnd       = "delete_to_end"
+)
+
+// keyAction is an action of interactive mode with its default keys
+type keyAction struct {
+	Name    string
+	Keys    []string
+	Summary string
+}
+
+// keyActions lists the remappable actions in the order of the help. ctrl+m is not a
+// default: terminals send the same byte for it and Enter.
+var keyActions = []keyAction{
+	{actionSelectProvider, []string{"f2", "ctrl+p"}, "Select a provider"},
+	{actionSelectModel, []string{"f3", "alt+m"}, "Select a model"},
+	{actionSelectTemperature, []string{"f4", "ctrl+t"}, "Select a temperature, or enter another one with Custom…"},
+	{actionCopyMode, []string{"ctrl+o"}, "Copy mode: focus responses, 'w' toggles code wrapping, left/right scroll code"},
+	{actionHistorySearch, []string{"ctrl+r"}, "Fuzzy search the previous messages and commands, Enter puts the match in the input"},
+	{actionLineStart, []string{"ctrl+a"}, "Move to the start of the input line"},
+	{actionLineEnd, []string{"ctrl+e"}, "Move to the end of the input line"},
+	{actionWordBackward, []string{"alt+b"}, "Move to the previous word"},
+	{actionWordForward, []string{"alt+f"}, "Move to the next word"},
+	{actionDeleteWord, []string{"ctrl+w"}, "Delete the word before the cursor"},
+	{actionDeleteToStart, []string{"ctrl+u"}, "Delete to the start of the input line"},
+	{actionDeleteToEnd, []string{"ctrl+k"}, "Delete to the end of the input line"},
+	{actionNewline, []string{"alt+enter"}, "Insert a newline"},
+	{actionApplyFixes, []string{"ctrl+f"}, "Apply the quick fixes suggested before sending a message"},
+}
+
+// reservedKeys cannot be bound to actions, ctrl+m is read as Enter
+var reservedKeys = map[string]bool{"enter": true, "ctrl+m": true, "esc": true, "ctrl+c": true}
+
+// boundKeys returns the keys of an action: those of keys.<action> when they are valid,
+// its default keys otherwise
+func boundKeys(action keyAction) []string {
+	keys, err := configuredKeys(action.Name)
+	if err != nil || len(keys) == 0 {
+		return action.Keys
+	}
+	return keys
+}
+
+// configuredKeys returns the keys set in keys.<action>, separated by commas or spaces
+func configuredKeys(name string) ([]string, error) {
+	var keys []string
+	for _, value := range viper.GetStringSlice("keys." + name) {
+		for _, key := range strings.Split(value, ",") {
+			key = strings.ToLower(strings.TrimSpace(key))
+			if key == "" {
+				continue
+			}
+			if reservedKeys[key] {
+				return nil, fmt.Errorf("%s cannot be rebound", key)
+			}
+			keys = append(keys, key)
+		}
+	}
+	return keys, nil
+}
+
+// actionKey returns the first key of an action, for hints
+func actionKey(name string) string {
+	for _, action := range keyActions {
+		if action.Name == name {
+			return boundKeys(action)[0]
+		}
+	}
+	return ""
+}
+
+// keyActionFor returns the action bound to a key, or an empty string
+func keyActionFor(key string) string {
+	for _, action := range keyActions {
+		for _, bound := range boundKeys(action) {
+			if bound == key {
+				return action.Name
+			}
+		}
+	}
+	return ""
+}
+
+// keymapProblems returns the invalid settings of keys, whose actions keep their defaults
+func keymapProblems() []string {
+	known := map[string]bool{}
+	var problems []string
+	for _, action := range keyActions {
+		known[action.Name] = true
+		if _, err := configuredKeys(action.Name); err != nil {
+			problems = append(problems, fmt.Sprintf("keys.%s: %v, using %s", action.Name, err, strings.Join(action.Keys, "/")))
+		}
+	}
+	var unknown []string
+	for name := range viper.GetStringMap("keys") {
+		if !known[name] {
+			unknown = append(unknown, name)
+		}
+	}
+	sort.Strings(unknown)
+	for _, name := range unknown {
+		problems = append(problems, fmt.Sprintf("keys.%s: unknown action", name))
+	}
+	return problems
+}
+
+// keyBinding documents a key of interactive mode
+type keyBinding struct {
+	Key     string
+	Summary string
+}
+
+// fixedKeyBindings lists the keys handled by interactiveModel.Update that cannot be remapped
+var fixedKeyBindings = []keyBinding{
+	{"/", "Search the conversation: Enter highlights the matches, n/N jump between them, esc closes"},
+	{"pgup/pgdown", "Scroll the conversation by half a screen"},
+	{"home/end", "Scroll to the top or the bottom of the conversation"},
+	{"up/down", "Recall the previous messages and commands, also those of previous sessions"},
+	{"esc", "Close the selector or cancel the response being streamed"},
+	{"ctrl+c", "Cancel the response being streamed, or exit interactive mode"},
+}
+
+// keyBindings lists the keys of interactive mode as configured, for the generated help
+func keyBindings() []keyBinding {
+	bindings := make([]keyBinding, 0, len(keyActions)+len(fixedKeyBindings))
+	for _, action := range keyActions {
+		bindings = append(bindings, keyBinding{strings.Join(boundKeys(action), "/"), fmt.Sprintf("%s (keys.%s)", action.Summary, action.Name)})
+	}
+	return append(bindings, fixedKeyBindings...)
+}
@@ -0,0 +1,55 @@
+package cmd
+
+// Scroll math of the interactive view. The functions are pure so the layout rules live in
+// one place and can be tested without a terminal.
+
+// inputAreaHeight is the number of lines reserved below the conversation for the input
+const inputAreaHeight = 3
+
+// Smallest terminal in which the conversation can be rendered
+const (
+	minTerminalWidth  = 20
+	minTerminalHeight = inputAreaHeight + 2
+)
+
+// isTerminalTooSmall returns true if the terminal cannot show the conversation and the input
+func isTerminalTooSmall(width, height int) bool {
+	return width < minTerminalWidth || height < minTerminalHeight
+}
+
+// visibleHeight returns how many conversation lines fit in a terminal of the given height
+func visibleHeight(height int) int {
+	return max(1, height-inputAreaHeight)
+}
+
+// maxScroll returns the largest scroll position, the one showing the last line at the bottom
+func maxScroll(totalLines, height int) int {
+	return max(0, totalLines-visibleHeight(height))
+}
+
+// clampScroll keeps a scroll position between the top and the bottom of the conversation
+func clampScroll(pos, totalLines, height int) int {
+	return min(max(0, pos), maxScroll(totalLines, height))
+}
+
+// isAtBottom returns true if the last line of the conversation is visible. Auto-scrolling
+// resumes once the user scrolls back to the bottom.
+func isAtBottom(pos, totalLines, height int) bool {
+	return pos >= maxScroll(totalLines, height)
+}
+
+// pageStep returns how many lines PageUp and PageDown scroll
+func pageStep(height int) int {
+	return max(1, height/2)
+}
+
+// visibleRange returns the lines [start, end) displayed at a scroll position
+func visibleRange(pos, totalLines, height int) (int, int) {
+	start := pos
+	if start >= totalLines {
+		start = max(0, totalLines-1)
+	}
+	start = max(0, start)
+	end := min(start+visibleHeight(height), totalLines)
+	return start, end
+}
@@ -0,0 +1,143 @@
+package cmd
+
+import "testing"
+
+func TestVisibleHeight(t *testing.T) {
+	tests := []struct {
+		height int
+		want   int
+	}{
+		{24, 21},
+		{4, 1},
+		{3, 1}, // Always show at least one line
+		{0, 1},
+	}
+	for _, tt := range tests {
+		if got := visibleHeight(tt.height); got != tt.want {
+			t.Errorf("visibleHeight(%d) = %d, want %d", tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestMaxScroll(t *testing.T) {
+	tests := []struct {
+		name       string
+		totalLines int
+		height     int
+		want       int
+	}{
+		{"fits", 10, 24, 0},
+		{"exactly fits", 21, 24, 0},
+		{"one line more", 22, 24, 1},
+		{"long conversation", 100, 24, 79},
+		{"tiny terminal", 5, 2, 4},
+		{"empty", 0, 24, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := maxScroll(tt.totalLines, tt.height); got != tt.want {
+				t.Errorf("maxScroll(%d, %d) = %d, want %d", tt.totalLines, tt.height, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestClampScroll(t *testing.T) {
+	tests := []struct {
+		name string
+		pos  int
+		want int
+	}{
+		{"negative", -5, 0},
+		{"top", 0, 0},
+		{"middle", 40, 40},
+		{"bottom", 79, 79},
+		{"past bottom", 90, 79},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := clampScroll(tt.pos, 100, 24); got != tt.want {
+				t.Errorf("clampScroll(%d, 100, 24) = %d, want %d", tt.pos, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestIsAtBottom(t *testing.T) {
+	tests := []struct {
+		name       string
+		pos        int
+		totalLines int
+		want       bool
+	}{
+		{"short conversation", 0, 10, true},
+		{"scrolled up", 78, 100, false},
+		{"at bottom", 79, 100, true},
+		{"conversation shrank", 79, 50, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := isAtBottom(tt.pos, tt.totalLines, 24); got != tt.want {
+				t.Errorf("isAtBottom(%d, %d, 24) = %v, want %v", tt.pos, tt.totalLines, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisibleRange(t *testing.T) {
+	tests := []struct {
+		name            string
+		pos, totalLines int
+		wantStart       int
+		wantEnd         int
+	}{
+		{"top", 0, 100, 0, 21},
+		{"bottom", 79, 100, 79, 100},
+		{"short conversation", 0, 5, 0, 5},
+		{"past the end", 120, 100, 99, 100},
+		{"negative", -3, 100, 0, 21},
+		{"empty", 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := visibleRange(tt.pos, tt.totalLines, 24)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("visibleRange(%d, %d, 24) = [%d, %d), want [%d, %d)", tt.pos, tt.totalLines, start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestScrollingKeepsViewInBounds(t *testing.T) {
+	// Walk the scroll position like the key handlers do and check every step
+	const totalLines, height = 60, 24
+	pos := 0
+	for _, delta := range []int{pageStep(height), pageStep(height), pageStep(height), pageStep(height), -3, -100, 3} {
+		pos = clampScroll(pos+delta, totalLines, height)
+		start, end := visibleRange(pos, totalLines, height)
+		if start < 0 || end > totalLines || end-start > visibleHeight(height) {
+			t.Fatalf("position %d shows [%d, %d) of %d lines", pos, start, end, totalLines)
+		}
+	}
+	if pos != 3 {
+		t.Errorf("final position = %d, want 3", pos)
+	}
+}
+
+func TestIsTerminalTooSmall(t *testing.T) {
+	tests := []struct {
+		width, height int
+		want          bool
+	}{
+		{80, 24, false},
+		{20, 5, false},
+		{19, 24, true},
+		{80, 4, true},
+		{0, 0, true},
+	}
+	for _, tt := range tests {
+		if got := isTerminalTooSmall(tt.width, tt.height); got != tt.want {
+			t.Errorf("isTerminalTooSmall(%d, %d) = %v, want %v", tt.width, tt.height, got, tt.want)
+		}
+	}
+}
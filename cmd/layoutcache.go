@@ -0,0 +1,96 @@
+package cmd
+
+import (
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/cost"
+)
+
+// layoutKey holds what the lines of a message depend on, the content is compared by
+// pointer first so unchanged messages are matched without reading them
+type layoutKey struct {
+	width         int
+	shownTotal    float64
+	msgType       MessageType
+	content       string
+	json          bool
+	noWrap        bool
+	pinned        bool
+	hScroll       int
+	images        int
+	usage         *provider.Usage
+	cost          *cost.Record
+	answeredBy    string
+	answeredModel string
+}
+
+// newLayoutKey returns the key of a message wrapped at the given width
+func newLayoutKey(msg Message, width int, shownTotal float64) layoutKey {
+	return layoutKey{
+		width:         width,
+		shownTotal:    shownTotal,
+		msgType:       msg.Type,
+		content:       msg.Content,
+		json:          msg.JSON,
+		noWrap:        msg.NoWrap,
+		pinned:        msg.Pinned,
+		hScroll:       msg.HScroll,
+		images:        len(msg.Images),
+		usage:         msg.Usage,
+		cost:          msg.Cost,
+		answeredBy:    msg.AnsweredBy,
+		answeredModel: msg.AnsweredModel,
+	}
+}
+
+// layoutEntry holds the lines of a message
+type layoutEntry struct {
+	key   layoutKey
+	lines []messageWithType
+	valid bool
+}
+
+// layoutCache holds the wrapped lines of each message by position, so that renders only
+// wrap the messages that changed. A nil cache keeps nothing.
+type layoutCache struct {
+	entries []layoutEntry
+	lines   int // Number of lines of the last layout, to size the next one
+}
+
+// get returns the lines of the message at a position if they were wrapped with the same key
+func (c *layoutCache) get(i int, key layoutKey) ([]messageWithType, bool) {
+	if c == nil || i >= len(c.entries) || !c.entries[i].valid || c.entries[i].key != key {
+		return nil, false
+	}
+	return c.entries[i].lines, true
+}
+
+// put keeps the lines of the message at a position
+func (c *layoutCache) put(i int, key layoutKey, lines []messageWithType) {
+	if c == nil {
+		return
+	}
+	for len(c.entries) <= i {
+		c.entries = append(c.entries, layoutEntry{})
+	}
+	c.entries[i] = layoutEntry{key: key, lines: lines, valid: true}
+}
+
+// truncate drops the entries of messages that no longer exist and records the number of
+// lines of the layout
+func (c *layoutCache) truncate(messages, lines int) {
+	if c == nil {
+		return
+	}
+	if len(c.entries) > messages {
+		c.entries = c.entries[:messages]
+	}
+	c.lines = lines
+}
+
+// size returns the number of lines of the last layout
+func (c *layoutCache) size() int {
+	if c == nil {
+		return 0
+	}
+	return c.lines
+}
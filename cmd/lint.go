@@ -0,0 +1,203 @@
+package cmd
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/plucury/chait/api"
+	"github.com/spf13/viper"
+)
+
+// maxLintLineLength is the length above which a single line is reported, typically minified
+// code or data pasted by mistake
+const maxLintLineLength = 4000
+
+// secretPatterns match credentials commonly pasted by mistake
+var secretPatterns = []*regexp.Regexp{
+	regexp.MustCompile(`-----BEGIN [A-Z ]*PRIVATE KEY-----[\s\S]*?(-----END [A-Z ]*PRIVATE KEY-----|$)`),
+	regexp.MustCompile(`\bsk-[A-Za-z0-9_-]{20,}`),              // OpenAI, Anthropic, Deepseek, Moonshot
+	regexp.MustCompile(`\b(gsk|xai|pplx)[_-][A-Za-z0-9]{20,}`), // Groq, Grok, Perplexity
+	regexp.MustCompile(`\bAKIA[0-9A-Z]{16}\b`),                 // AWS access key
+	regexp.MustCompile(`\bgh[pousr]_[A-Za-z0-9]{36,}`),         // GitHub token
+	regexp.MustCompile(`\bxox[abprs]-[A-Za-z0-9-]{10,}`),       // Slack token
+	regexp.MustCompile(`\bAIza[0-9A-Za-z_-]{35}`),              // Google API key
+}
+
+// redactedSecret replaces the secrets removed by the quick fix
+const redactedSecret = "[REDACTED]"
+
+// lintIssue is a problem found in a message before it is sent
+type lintIssue struct {
+	Warning string
+	Fix     string                  // What the quick fix does, empty if there is none
+	fix     func(*interactiveModel) // Applies the quick fix to the input or the conversation
+}
+
+// lintEnabled returns true unless prompt linting is disabled in the configuration
+func lintEnabled() bool {
+	return !viper.IsSet("lint_prompts") || viper.GetBool("lint_prompts")
+}
+
+// lintPrompt returns the problems of a prompt: pasted secrets, an extremely long single line
+// and bytes that are not valid UTF-8
+func lintPrompt(prompt string) []lintIssue {
+	var issues []lintIssue
+
+	if secrets := findSecrets(prompt); len(secrets) > 0 {
+		issues = append(issues, lintIssue{
+			Warning: fmt.Sprintf("the message seems to contain %d secret(s) such as API keys or private keys", len(secrets)),
+			Fix:     "redact the secrets",
+			fix: func(m *interactiveModel) {
+				m.setInput(redactSecrets(string(m.input)))
+			},
+		})
+	}
+
+	for _, line := range strings.Split(prompt, "\n") {
+		if n := utf8.RuneCountInString(line); n > maxLintLineLength {
+			issues = append(issues, lintIssue{
+				Warning: fmt.Sprintf("a single line has %s characters, which is often minified code or data and costs many tokens", formatCount(n)),
+				Fix:     fmt.Sprintf("cut long lines to %s characters", formatCount(maxLintLineLength)),
+				fix: func(m *interactiveModel) {
+					m.setInput(truncateLongLines(string(m.input), maxLintLineLength))
+				},
+			})
+			break
+		}
+	}
+
+	// Invalid bytes from pipes are read as replacement characters in interactive mode
+	if !utf8.ValidString(prompt) || strings.ContainsRune(prompt, utf8.RuneError) {
+		issues = append(issues, lintIssue{
+			Warning: "the message contains bytes that are not valid UTF-8, such as binary data from a pipe",
+			Fix:     "remove the invalid bytes",
+			fix: func(m *interactiveModel) {
+				m.setInput(strings.ReplaceAll(strings.ToValidUTF8(string(m.input), ""), string(utf8.RuneError), ""))
+			},
+		})
+	}
+	return issues
+}
+
+// lint returns the problems of a message about to be sent in the conversation: those of the
+// prompt, and a missing system prompt at the start of a conversation, e.g. after :c
+func (m *interactiveModel) lint(prompt string) []lintIssue {
+	issues := lintPrompt(prompt)
+
+	hasInstructions, hasMessages := false, false
+	for _, msg := range m.messages {
+		hasInstructions = hasInstructions || (isInstruction(msg.Type) && strings.TrimSpace(msg.Content) != "")
+		hasMessages = hasMessages || msg.Type == MessageTypeUser
+	}
+	if !hasInstructions && !hasMessages {
+		issues = append(issues, lintIssue{
+			Warning: "the conversation has no system prompt",
+			Fix:     "add the default system prompt",
+			fix: func(m *interactiveModel) {
+				m.messages = append([]Message{{Type: MessageTypeSystem, Content: defaultSystemPrompt}}, m.messages...)
+			},
+		})
+	}
+	return issues
+}
+
+// findSecrets returns the secrets found in a text: the API keys in the configuration and
+// credentials matching common formats
+func findSecrets(text string) []string {
+	var secrets []string
+	for _, name := range api.GetAvailableProviderNames() {
+		if key := viper.GetString("providers." + name + ".api_key"); len(key) >= 8 && strings.Contains(text, key) {
+			secrets = append(secrets, key)
+		}
+	}
+	for _, pattern := range secretPatterns {
+		for _, secret := range pattern.FindAllString(text, -1) {
+			if !strings.Contains(strings.Join(secrets, "\n"), secret) {
+				secrets = append(secrets, secret)
+			}
+		}
+	}
+	return secrets
+}
+
+// redactSecrets replaces the secrets found in a text
+func redactSecrets(text string) string {
+	for _, secret := range findSecrets(text) {
+		text = strings.ReplaceAll(text, secret, redactedSecret)
+	}
+	return text
+}
+
+// truncateLongLines cuts the lines longer than limit characters
+func truncateLongLines(text string, limit int) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if runes := []rune(line); len(runes) > limit {
+			lines[i] = string(runes[:limit]) + "…"
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// setInput replaces the input, with the cursor at its end
+func (m *interactiveModel) setInput(text string) {
+	m.input = []rune(text)
+	m.cursor = len(m.input)
+}
+
+// lintBeforeSending warns about the problems of a message the first time Enter sends it,
+// keeping it in the input. The content is the message with its attached files, which are
+// checked for secrets too. It returns false if the message can be sent: linting is disabled,
+// it has no problems, or it was already warned about.
+func (m *interactiveModel) lintBeforeSending(prompt, content string) bool {
+	if !lintEnabled() || content == "" || content == m.lintedInput {
+		m.lintIssues, m.lintedInput = nil, ""
+		return false
+	}
+	issues := m.lint(prompt)
+	// The attached files cannot be fixed from the input, they are only reported
+	if n := len(findSecrets(content)) - len(findSecrets(prompt)); content != prompt && n > 0 {
+		issues = append(issues, lintIssue{
+			Warning: fmt.Sprintf("the attached files seem to contain %d secret(s) such as API keys or private keys", n),
+		})
+	}
+	if len(issues) == 0 {
+		return false
+	}
+
+	m.lintIssues, m.lintedInput = issues, content
+	var sb strings.Builder
+	fixKey := actionKey(actionApplyFixes)
+	sb.WriteString("Before sending this message:")
+	for _, issue := range issues {
+		fmt.Fprintf(&sb, "\n- %s", issue.Warning)
+		if issue.Fix != "" {
+			fmt.Fprintf(&sb, " (%s: %s)", fixKey, issue.Fix)
+		}
+	}
+	fmt.Fprintf(&sb, "\nPress Enter to send it anyway, %s to apply the fixes, or edit it.", fixKey)
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: sb.String()})
+	m.scrollToBottom()
+	return true
+}
+
+// applyLintFixes applies the quick fixes of the problems found in the message being sent
+func (m *interactiveModel) applyLintFixes() {
+	var applied []string
+	for _, issue := range m.lintIssues {
+		if issue.fix != nil {
+			issue.fix(m)
+			applied = append(applied, issue.Fix)
+		}
+	}
+	m.lintIssues, m.lintedInput = nil, ""
+	if len(applied) > 0 {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeChait,
+			Content: fmt.Sprintf("Fixed: %s. Press Enter to send the message.", strings.Join(applied, ", ")),
+		})
+		m.scrollToBottom()
+	}
+}
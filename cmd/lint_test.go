@@ -0,0 +1,27 @@
+package cmd
+
+import (
+	"strings"
+	"testing"
+)
+
+// Secrets in files attached with @path or ':f' are reported, and the input is kept until
+// Enter is pressed again
+func TestLintBeforeSendingChecksAttachedFiles(t *testing.T) {
+	m := &interactiveModel{messages: []Message{{Type: MessageTypeSystem, Content: defaultSystemPrompt}}}
+	prompt := "explain @.env"
+	content := prompt + "\n\n.env:\n```\nOPENAI_API_KEY=sk-abcdefghijklmnopqrstuvwxyz123456\n```"
+
+	if !m.lintBeforeSending(prompt, content) {
+		t.Fatal("a secret in an attached file was not reported")
+	}
+	if warning := m.messages[len(m.messages)-1].Content; !strings.Contains(warning, "attached files seem to contain 1 secret") {
+		t.Errorf("warning = %q", warning)
+	}
+	if m.lintBeforeSending(prompt, content) {
+		t.Error("the message was not sent when Enter was pressed again")
+	}
+	if m.lintBeforeSending(prompt, prompt) {
+		t.Error("a message without attached secrets was reported")
+	}
+}
@@ -0,0 +1,141 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/plucury/chait/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+)
+
+// Flags for the man command
+var manOutput string
+
+// manCmd represents the man command
+var manCmd = &cobra.Command{
+	Use:   "man",
+	Short: "Generate the chait(1) man page",
+	Long: `Generate the chait(1) man page from the commands, flags, interactive commands,
+config keys and providers of this version, so it always matches the binary.
+
+Examples:
+  chait man | man -l -
+  chait man --output /usr/local/share/man/man1/chait.1`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		page := generateManPage()
+		if manOutput == "" {
+			fmt.Print(page)
+			return
+		}
+
+		if err := util.CheckWriteAllowed("writing the man page"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if err := os.WriteFile(manOutput, []byte(page), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Error writing man page: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Man page written to %s\n", manOutput)
+	},
+}
+
+// generateManPage renders the man page in roff
+func generateManPage() string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, ".TH CHAIT 1 \"\" \"chait %s\" \"User Commands\"\n", roffEscape(version))
+	fmt.Fprintf(&sb, ".SH NAME\nchait \\- %s\n", roffEscape(rootCmd.Short))
+	sb.WriteString(".SH SYNOPSIS\n\\fBchait\\fR [\\fIflags\\fR] [\\fImessage\\fR]\n.br\n\\fBchait\\fR \\fIcommand\\fR [\\fIflags\\fR]\n")
+	fmt.Fprintf(&sb, ".SH DESCRIPTION\n%s\n", roffText(rootCmd.Long))
+
+	sb.WriteString(".SH OPTIONS\n")
+	writeManFlags(&sb, rootCmd.NonInheritedFlags())
+
+	sb.WriteString(".SH COMMANDS\n")
+	for _, c := range rootCmd.Commands() {
+		if !c.IsAvailableCommand() || c.Name() == "help" {
+			continue
+		}
+		writeManCommand(&sb, c)
+	}
+
+	for _, t := range helpTopics {
+		fmt.Fprintf(&sb, ".SH %s\n%s\n", strings.ToUpper(t.Title), roffText(t.Intro))
+		for _, e := range t.entries() {
+			fmt.Fprintf(&sb, ".TP\n\\fB%s\\fR\n%s\n", roffEscape(e.Term), roffText(e.Description))
+		}
+	}
+
+	sb.WriteString(".SH FILES\n")
+	sb.WriteString(".TP\n\\fI~/.config/chait/config.json\\fR\nConfiguration, see \\fBchait help config\\fR\n")
+	sb.WriteString(".TP\n\\fI~/.local/share/chait/sessions\\fR\nSaved conversations\n")
+	sb.WriteString(".TP\n\\fI~/.local/share/chait/usage.jsonl\\fR\nToken usage of past requests\n")
+	return sb.String()
+}
+
+// writeManCommand documents a command and its subcommands
+func writeManCommand(sb *strings.Builder, c *cobra.Command) {
+	fmt.Fprintf(sb, ".SS \"%s\"\n", roffEscape(c.UseLine()))
+	description := c.Long
+	if description == "" {
+		description = c.Short
+	}
+	fmt.Fprintf(sb, "%s\n", roffText(description))
+	writeManFlags(sb, c.NonInheritedFlags())
+
+	for _, sub := range c.Commands() {
+		if sub.IsAvailableCommand() {
+			writeManCommand(sb, sub)
+		}
+	}
+}
+
+// writeManFlags documents the visible flags of a flag set
+func writeManFlags(sb *strings.Builder, flags *pflag.FlagSet) {
+	flags.VisitAll(func(f *pflag.Flag) {
+		if f.Hidden || f.Name == "help" {
+			return
+		}
+		name := "\\fB\\-\\-" + roffEscape(f.Name) + "\\fR"
+		if f.Shorthand != "" {
+			name = "\\fB\\-" + f.Shorthand + "\\fR, " + name
+		}
+		if varname, _ := pflag.UnquoteUsage(f); varname != "" {
+			name += " \\fI" + roffEscape(varname) + "\\fR"
+		}
+		fmt.Fprintf(sb, ".TP\n%s\n%s\n", name, roffText(f.Usage))
+	})
+}
+
+// roffEscape escapes the characters that roff interprets inside a line
+func roffEscape(s string) string {
+	s = strings.ReplaceAll(s, "\\", "\\e")
+	return strings.ReplaceAll(s, "-", "\\-")
+}
+
+// roffText escapes a paragraph, keeping its line breaks and indented examples
+func roffText(s string) string {
+	lines := strings.Split(strings.TrimSpace(s), "\n")
+	var out []string
+	for _, line := range lines {
+		switch {
+		case strings.TrimSpace(line) == "":
+			out = append(out, ".PP")
+		case strings.HasPrefix(line, "  "), strings.HasPrefix(line, "\t"):
+			// Examples keep their layout
+			out = append(out, ".nf", "\\&"+roffEscape(line), ".fi")
+		default:
+			out = append(out, "\\&"+roffEscape(line))
+		}
+	}
+	return strings.Join(out, "\n")
+}
+
+func init() {
+	rootCmd.AddCommand(manCmd)
+
+	manCmd.Flags().StringVarP(&manOutput, "output", "o", "", "Write the man page to this file instead of stdout")
+}
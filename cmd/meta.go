@@ -0,0 +1,107 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plucury/chait/api"
+)
+
+// metaFields lists the conversation metadata that can be edited with :meta
+var metaFields = []string{"title", "tags", "notes", "model"}
+
+// handleMetaCommand shows or edits the metadata of the conversation:
+// ":meta", ":meta title <text>", ":meta tags <a,b>", ":meta notes <text>", ":meta model <name>"
+// Editing saves the conversation, so it can be resumed with "chait sessions resume"
+func (m *interactiveModel) handleMetaCommand(args []string) {
+	if len(args) == 0 {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: m.metaScreen()})
+		return
+	}
+
+	field, value := args[0], strings.Join(args[1:], " ")
+	if !isMetaField(field) {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("Usage: :meta [<%s> <value>]", strings.Join(metaFields, "|")),
+		})
+		return
+	}
+
+	// Validate the model before changing the session
+	if field == "model" {
+		if value == "" {
+			m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :meta model <name>"})
+			return
+		}
+		model, err := api.ModelFor(api.GetActiveProvider(), value)
+		if err == nil {
+			err = api.GetActiveProvider().SetCurrentModel(model)
+		}
+		if err != nil {
+			m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+			return
+		}
+		value = model
+	}
+
+	m.ensureSession()
+	switch field {
+	case "title":
+		m.session.Title = value
+	case "tags":
+		m.session.Tags = nil
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				m.session.Tags = append(m.session.Tags, tag)
+			}
+		}
+	case "notes":
+		m.session.Notes = value
+	case "model":
+		m.session.Provider = api.GetActiveProviderName()
+		m.session.Model = value
+	}
+
+	if err := m.saveSession(); err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: fmt.Sprintf("Error saving conversation: %v", err)})
+		return
+	}
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: m.metaScreen()})
+}
+
+// metaScreen renders the metadata of the conversation
+func (m *interactiveModel) metaScreen() string {
+	var sb strings.Builder
+	if m.session == nil {
+		sb.WriteString("Conversation metadata (not saved yet):")
+		sb.WriteString(fmt.Sprintf("\n- model: %s/%s", api.GetActiveProviderName(), api.GetCurrentModel()))
+	} else {
+		s := m.session
+		sb.WriteString(fmt.Sprintf("Conversation metadata (session %s):", s.ID))
+		sb.WriteString(fmt.Sprintf("\n- title: %s", metaValue(s.Title)))
+		sb.WriteString(fmt.Sprintf("\n- tags: %s", metaValue(strings.Join(s.Tags, ", "))))
+		sb.WriteString(fmt.Sprintf("\n- notes: %s", metaValue(s.Notes)))
+		sb.WriteString(fmt.Sprintf("\n- model: %s", metaValue(strings.Trim(s.Provider+"/"+s.Model, "/"))))
+	}
+	sb.WriteString("\nEdit with ':meta title <text>', ':meta tags <a,b>', ':meta notes <text>' or ':meta model <name>'")
+	return sb.String()
+}
+
+// metaValue returns the value to display for a metadata field
+func metaValue(value string) string {
+	if value == "" {
+		return "(none)"
+	}
+	return value
+}
+
+// isMetaField returns true if the name is a field editable with :meta
+func isMetaField(name string) bool {
+	for _, field := range metaFields {
+		if field == name {
+			return true
+		}
+	}
+	return false
+}
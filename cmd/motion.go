@@ -0,0 +1,12 @@
+package cmd
+
+import "github.com/spf13/viper"
+
+// reduceMotionPlaceholder replaces a response while it streams when reduce_motion is set
+const reduceMotionPlaceholder = "Responding… the response is shown once it is complete"
+
+// reduceMotion returns whether the interface avoids movement: no cursor blinking and no
+// response growing while it streams, for users sensitive to flicker or using screen readers
+func reduceMotion() bool {
+	return viper.GetBool("reduce_motion")
+}
@@ -0,0 +1,46 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// renderDebugOverlay draws the boundaries of the viewport and input regions on the right
+// edge of the view, and the scroll metrics on its first line. The viewport shows the
+// conversation lines [start, end) of total.
+func (m interactiveModel) renderDebugOverlay(view string, start, end, total int) string {
+	width := max(m.width, 2)
+	lines := strings.Split(view, "\n")
+	viewportRows := min(end-start, len(lines))
+
+	lower := "input"
+	if m.copyMode || m.search.active || m.historySearch.active {
+		lower = "status"
+	}
+	for i, line := range lines {
+		style, first, last := overlayViewportStyle, 0, viewportRows-1
+		if i >= viewportRows {
+			style, first, last = overlayInputStyle, viewportRows, len(lines)-1
+		}
+		edge := "│"
+		switch {
+		case first == last:
+			edge = "┤"
+		case i == first:
+			edge = "┐"
+		case i == last:
+			edge = "┘"
+		}
+		// Keep the content left of the edge, padded so the edge lines up
+		content := ansi.Truncate(line, width-1, "")
+		lines[i] = content + strings.Repeat(" ", max(0, width-1-ansi.StringWidth(content))) + style.Render(edge)
+	}
+
+	header := fmt.Sprintf(" scroll %d/%d bottom=%v auto=%v │ lines %d showing %d-%d │ viewport 0-%d %s %d-%d │ %dx%d ",
+		m.scrollPos, maxScroll(total, m.height), isAtBottom(m.scrollPos, total, m.height), m.autoScrollBottom,
+		total, start, end, viewportRows-1, lower, viewportRows, len(lines)-1, m.width, m.height)
+	lines[0] = overlayHeaderStyle.Render(ansi.Truncate(header, width, "…"))
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
+)
+
+// selectFlagValue is the value of --provider, --model and --temperature given without a
+// value, which select and save a setting interactively
+const selectFlagValue = "?"
+
+// Provider, model and temperature given with --provider=<name>, --model=<name> and
+// --temperature=<value>, or selectFlagValue to select them interactively
+var (
+	providerFlag    string
+	modelFlag       string
+	temperatureFlag string
+)
+
+// isOverride returns true if a flag gives a value for this invocation rather than asking
+// to select one
+func isOverride(value string) bool {
+	return value != "" && value != selectFlagValue
+}
+
+// applyFlagOverrides uses the persona, provider, model and temperature given as flag values
+// for this invocation only, they are never written to the configuration
+func applyFlagOverrides() error {
+	if err := applyPersonaFlag(); err != nil {
+		return err
+	}
+	providerName := ""
+	if isOverride(providerFlag) {
+		providerName = providerFlag
+	}
+	// An alias for another provider switches to it unless a provider is given
+	if isOverride(modelFlag) {
+		if aliasProvider, _ := api.ResolveModel(modelFlag); aliasProvider != "" && providerName == "" {
+			providerName = aliasProvider
+		}
+	}
+	if providerName != "" {
+		if err := api.UseProvider(providerName); err != nil {
+			return fmt.Errorf("%v (available: %s)", err, strings.Join(api.GetAvailableProviderNames(), ", "))
+		}
+		DebugLog("Using provider %s for this invocation", providerName)
+	}
+
+	p := api.GetActiveProvider()
+	if isOverride(modelFlag) {
+		model, err := api.ModelFor(p, modelFlag)
+		if err != nil {
+			return err
+		}
+		if err := p.SetCurrentModel(model); err != nil {
+			return errors.New(withExtraModelsHint(err, p.GetName()))
+		}
+		DebugLog("Using model %s for this invocation", model)
+	}
+	if isOverride(temperatureFlag) {
+		temperature, err := strconv.ParseFloat(temperatureFlag, 64)
+		if err != nil {
+			return fmt.Errorf("invalid temperature %q", temperatureFlag)
+		}
+		if err := p.SetCurrentTemperature(temperature); err != nil {
+			return err
+		}
+		DebugLog("Using temperature %.1f for this invocation", temperature)
+	}
+	return nil
+}
+
+// saveProviderSetting writes a single setting of the provider to the configuration, so a
+// model or temperature overridden for this invocation is not saved along with it
+func saveProviderSetting(p provider.Provider, key string, value interface{}) error {
+	viper.Set(fmt.Sprintf("providers.%s.%s", p.GetName(), key), value)
+	return util.WriteConfig()
+}
@@ -0,0 +1,21 @@
+package cmd
+
+import "strings"
+
+// pastedText returns text pasted in the terminal as it is inserted in the input: with the
+// line endings of the terminal turned into newlines, and trimmed for a single-line input
+// such as an API key
+func pastedText(runes []rune, singleLine bool) []rune {
+	text := strings.ReplaceAll(string(runes), "\r\n", "\n")
+	text = strings.ReplaceAll(text, "\r", "\n")
+	if singleLine {
+		text = strings.TrimSpace(text)
+	}
+	return []rune(text)
+}
+
+// isMultiline returns true if the input has several lines, such as pasted text, which is
+// always sent as a message even when it starts with ':'
+func isMultiline(input string) bool {
+	return strings.Contains(strings.TrimSpace(input), "\n")
+}
@@ -0,0 +1,223 @@
+package cmd
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/plucury/chait/api"
+	"github.com/spf13/viper"
+)
+
+// personaFlag is the persona given with --persona
+var personaFlag string
+
+// personaSystemPrompt is the system prompt of the persona in use, starting new
+// conversations instead of system_messages
+var personaSystemPrompt string
+
+// persona is a named bundle of settings from personas.<name>, applied in one step with
+// ':persona' or --persona. Empty settings keep the current ones.
+type persona struct {
+	Name         string
+	SystemPrompt string
+	Provider     string
+	Model        string
+	Temperature  *float64
+}
+
+// personaNames returns the names of the configured personas in alphabetical order
+func personaNames() []string {
+	var names []string
+	for name := range viper.GetStringMap("personas") {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// loadPersona returns the persona configured with the given name, ignoring case
+func loadPersona(name string) (persona, error) {
+	name = strings.ToLower(name)
+	if _, ok := viper.GetStringMap("personas")[name]; !ok {
+		names := personaNames()
+		if len(names) == 0 {
+			return persona{}, fmt.Errorf("unknown persona %q, none is configured in personas", name)
+		}
+		return persona{}, fmt.Errorf("unknown persona %q (available: %s)", name, strings.Join(names, ", "))
+	}
+	key := "personas." + name + "."
+	p := persona{
+		Name:         name,
+		SystemPrompt: strings.TrimSpace(viper.GetString(key + "system_prompt")),
+		Provider:     viper.GetString(key + "provider"),
+		Model:        viper.GetString(key + "model"),
+	}
+	if viper.IsSet(key + "temperature") {
+		temperature := viper.GetFloat64(key + "temperature")
+		p.Temperature = &temperature
+	}
+	return p, nil
+}
+
+// summary describes the settings of the persona, for the selector
+func (p persona) summary() string {
+	var parts []string
+	if p.Provider != "" && p.Model != "" {
+		parts = append(parts, p.Provider+"/"+p.Model)
+	} else if p.Provider != "" || p.Model != "" {
+		parts = append(parts, p.Provider+p.Model)
+	}
+	if p.Temperature != nil {
+		parts = append(parts, fmt.Sprintf("temp %.1f", *p.Temperature))
+	}
+	if p.SystemPrompt != "" {
+		parts = append(parts, fmt.Sprintf("%q", runewidth.Truncate(strings.Join(strings.Fields(p.SystemPrompt), " "), 50, "…")))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// applyPersonaFlag uses the settings of the persona given with --persona for this
+// invocation, the provider, model and temperature flags take precedence
+func applyPersonaFlag() error {
+	if personaFlag == "" {
+		return nil
+	}
+	p, err := loadPersona(personaFlag)
+	if err != nil {
+		return err
+	}
+	personaSystemPrompt = p.SystemPrompt
+	if providerFlag == "" {
+		providerFlag = p.Provider
+	}
+	if modelFlag == "" {
+		modelFlag = p.Model
+	}
+	if temperatureFlag == "" && p.Temperature != nil {
+		temperatureFlag = strconv.FormatFloat(*p.Temperature, 'f', -1, 64)
+	}
+	DebugLog("Using persona %s for this invocation", p.Name)
+	return nil
+}
+
+// handlePersonaCommand opens the persona selector with ':persona', or applies the given
+// persona
+func (m *interactiveModel) handlePersonaCommand(args []string) {
+	if len(args) > 1 {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :persona [name]"})
+		return
+	}
+	if len(args) == 1 {
+		m.usePersona(args[0])
+		return
+	}
+
+	names := personaNames()
+	if len(names) == 0 {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeChait,
+			Content: "No personas are configured. Add them to personas in the config, e.g. {\"reviewer\": {\"system_prompt\": \"You review Go code.\", \"model\": \"gpt-4o\", \"temperature\": 0.2}}",
+		})
+		return
+	}
+	options := make([]selectorOption, 0, len(names))
+	for _, name := range names {
+		p, err := loadPersona(name)
+		if err != nil {
+			continue
+		}
+		option := name
+		if summary := p.summary(); summary != "" {
+			option += " - " + summary
+		}
+		options = append(options, selectorOption{name: option, value: name})
+	}
+	m.personaSelector.options = options
+	m.personaSelector.currentIndex = 0
+	m.activateSelector(&m.personaSelector)
+}
+
+// providerSettings holds the model and temperature of every provider and the active one,
+// to restore them when a persona cannot be applied
+type providerSettings struct {
+	active       string
+	models       map[string]string
+	temperatures map[string]float64
+}
+
+// saveProviderSettings returns the current provider settings
+func saveProviderSettings() providerSettings {
+	settings := providerSettings{
+		active:       api.GetActiveProviderName(),
+		models:       map[string]string{},
+		temperatures: map[string]float64{},
+	}
+	for _, p := range api.GetAvailableProviders() {
+		settings.models[p.GetName()] = p.GetCurrentModel()
+		settings.temperatures[p.GetName()] = p.GetCurrentTemperature()
+	}
+	return settings
+}
+
+// restore puts the saved provider settings back
+func (settings providerSettings) restore() {
+	for _, p := range api.GetAvailableProviders() {
+		_ = p.SetCurrentModel(settings.models[p.GetName()])
+		_ = p.SetCurrentTemperature(settings.temperatures[p.GetName()])
+	}
+	_ = api.UseProvider(settings.active)
+}
+
+// usePersona applies the system prompt, provider, model and temperature of a persona for
+// the rest of the session, like --persona: nothing is written to the configuration. The
+// persona is applied completely or not at all. Its system prompt replaces that of the
+// conversation and starts the next ones.
+func (m *interactiveModel) usePersona(name string) {
+	p, err := loadPersona(name)
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+
+	// The settings of the persona become those given for this invocation, which config
+	// reloads keep
+	settings := saveProviderSettings()
+	flags := []*string{&personaFlag, &providerFlag, &modelFlag, &temperatureFlag}
+	previous := []string{personaFlag, providerFlag, modelFlag, temperatureFlag}
+	previousPrompt := personaSystemPrompt
+	personaFlag = p.Name
+	if p.Provider != "" {
+		providerFlag = p.Provider
+	}
+	if p.Model != "" {
+		modelFlag = p.Model
+	}
+	if p.Temperature != nil {
+		temperatureFlag = strconv.FormatFloat(*p.Temperature, 'f', -1, 64)
+	}
+	if err := applyFlagOverrides(); err != nil {
+		for i, flag := range flags {
+			*flag = previous[i]
+		}
+		personaSystemPrompt = previousPrompt
+		settings.restore()
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("Persona %s not applied: %v", p.Name, err),
+		})
+		return
+	}
+	refreshConfig(m)
+	if p.SystemPrompt != "" {
+		m.replaceSystemPrompt(p.SystemPrompt)
+	}
+
+	content := fmt.Sprintf("Using the %s persona for this session: %s/%s, temperature %.1f", p.Name, api.GetActiveProviderName(), api.GetCurrentModel(), api.GetCurrentTemperature())
+	if p.SystemPrompt != "" {
+		content += ", with its system prompt"
+	}
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: content})
+}
@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/tokens"
+)
+
+// pinnedMessagesBefore returns the pinned messages of the conversation before the given
+// index, which are sent as context even once they are older than the recent history
+func (m interactiveModel) pinnedMessagesBefore(index int) []provider.ChatMessage {
+	var pinned []provider.ChatMessage
+	for _, msg := range m.messages[:min(index, len(m.messages))] {
+		if msg.Pinned && (msg.Type == MessageTypeUser || msg.Type == MessageTypeAssistant) {
+			pinned = append(pinned, msg.ToChatMessage())
+		}
+	}
+	return pinned
+}
+
+// handlePinCommand pins the last exchange with ':pin', so that a spec or a snippet stays in
+// the context however long the conversation gets, and unpins every message with ':pin off'
+func (m *interactiveModel) handlePinCommand(args []string) {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "off") {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :pin [off]"})
+		return
+	}
+
+	if len(args) == 1 {
+		unpinned := 0
+		for i := range m.messages {
+			if m.messages[i].Pinned {
+				m.messages[i].Pinned = false
+				unpinned++
+			}
+		}
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: fmt.Sprintf("Unpinned %d message(s)", unpinned)})
+		m.autoSave()
+		return
+	}
+
+	// The last exchange starts with the last message of the user
+	last := -1
+	for i := len(m.messages) - 1; i >= 0; i-- {
+		if m.messages[i].Type == MessageTypeUser {
+			last = i
+			break
+		}
+	}
+	if last < 0 {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "There is no message to pin yet."})
+		return
+	}
+	for i := last; i < len(m.messages); i++ {
+		if m.messages[i].Type == MessageTypeUser || m.messages[i].Type == MessageTypeAssistant {
+			m.messages[i].Pinned = true
+		}
+	}
+
+	var all []provider.ChatMessage
+	for _, msg := range m.messages {
+		if msg.Pinned {
+			all = append(all, msg.ToChatMessage())
+		}
+	}
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Pinned the last exchange, %d pinned message(s) (~%d tokens) are always sent as context. ':pin off' unpins them.", len(all), tokens.CountMessages(currentTokenizer(), all)),
+	})
+	m.autoSave()
+}
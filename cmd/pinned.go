@@ -0,0 +1,53 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// streamMaxLines returns how many lines of a streaming response are displayed,
+// 0 shows the whole response
+func streamMaxLines() int {
+	return max(0, viper.GetInt("stream_max_lines"))
+}
+
+// pinStreamingText keeps the last limit lines of a streaming response so that only they
+// are wrapped and rendered for each chunk. It returns the kept text and a header giving
+// the size of the whole response, or an empty header if the text fits.
+func pinStreamingText(text string, limit int) (string, string) {
+	total := strings.Count(text, "\n") + 1
+	if limit <= 0 || total <= limit {
+		return text, ""
+	}
+
+	start := len(text)
+	for i := 0; i < limit; i++ {
+		start = strings.LastIndexByte(text[:start], '\n')
+	}
+	return text[start+1:], fmt.Sprintf("…streaming (%s lines)", formatCount(total))
+}
+
+// lastLines returns the last n lines of a wrapped text
+func lastLines(text string, n int) string {
+	lines := strings.Split(text, "\n")
+	if len(lines) <= n {
+		return text
+	}
+	return strings.Join(lines[len(lines)-n:], "\n")
+}
+
+// formatCount formats a number with thousands separators, e.g. 1,042
+func formatCount(n int) string {
+	digits := strconv.Itoa(n)
+	var sb strings.Builder
+	for i, d := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			sb.WriteByte(',')
+		}
+		sb.WriteRune(d)
+	}
+	return sb.String()
+}
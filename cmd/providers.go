@@ -0,0 +1,134 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the providers command
+var providersCheck bool
+
+// providerCheck is the result of the health check of a provider
+type providerCheck struct {
+	Name    string
+	Model   string
+	Status  api.KeyStatus
+	Err     error
+	Latency time.Duration
+}
+
+// providersCmd represents the providers command
+var providersCmd = &cobra.Command{
+	Use:   "providers",
+	Short: "List providers and check their health",
+	Long: `List the providers with their current model and whether an API key is set.
+
+With --check, send a minimal request (a one-token completion) to each provider with
+an API key and report whether it is reachable, whether it accepts the key, and how long
+it took to answer. The command exits with status 1 if a provider fails the check.`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if providersCheck {
+			if !runProviderChecks() {
+				os.Exit(1)
+			}
+			return
+		}
+
+		for _, p := range sortedProviders() {
+			ready := "no API key"
+			if p.IsReady() {
+				ready = "ready"
+			}
+			active := " "
+			if p.GetName() == api.GetActiveProviderName() {
+				active = "*"
+			}
+			fmt.Printf("%s %-12s %-40s %s\n", active, p.GetName(), p.GetCurrentModel(), ready)
+		}
+	},
+}
+
+// sortedProviders returns the providers ordered by name
+func sortedProviders() []provider.Provider {
+	providers := api.GetAvailableProviders()
+	sort.Slice(providers, func(i, j int) bool { return providers[i].GetName() < providers[j].GetName() })
+	return providers
+}
+
+// checkProviders checks every provider with an API key concurrently
+func checkProviders() []providerCheck {
+	var checks []providerCheck
+	var ready []provider.Provider
+	for _, p := range sortedProviders() {
+		if p.IsReady() {
+			ready = append(ready, p)
+			checks = append(checks, providerCheck{Name: p.GetName(), Model: p.GetCurrentModel()})
+		}
+	}
+
+	var wg sync.WaitGroup
+	for i, p := range ready {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			start := time.Now()
+			checks[i].Status, checks[i].Err = validateAPIKey(p, p.GetAPIKey())
+			checks[i].Latency = time.Since(start)
+		}()
+	}
+	wg.Wait()
+	return checks
+}
+
+// runProviderChecks prints the health of the providers and returns false if one fails
+func runProviderChecks() bool {
+	checks := checkProviders()
+	if len(checks) == 0 {
+		fmt.Println("No provider has an API key, set one with chait -p or chait config providers.<name>.api_key <key>")
+		return false
+	}
+
+	healthy := true
+	fmt.Printf("%-12s %-40s %-10s %-8s %8s\n", "PROVIDER", "MODEL", "REACHABLE", "AUTH", "LATENCY")
+	for _, c := range checks {
+		reachable, auth, latency := "yes", "ok", c.Latency.Round(time.Millisecond).String()
+		switch c.Status {
+		case api.KeyInvalid:
+			auth = "invalid"
+			healthy = false
+		case api.KeyUnverified:
+			reachable, auth, latency = "no", "-", "-"
+			healthy = false
+		}
+		fmt.Printf("%-12s %-40s %-10s %-8s %8s\n", c.Name, c.Model, reachable, auth, latency)
+		if c.Err != nil {
+			fmt.Printf("  %s\n", strings.TrimSpace(c.Err.Error()))
+		}
+	}
+
+	var missing []string
+	for _, p := range sortedProviders() {
+		if !p.IsReady() {
+			missing = append(missing, p.GetName())
+		}
+	}
+	if len(missing) > 0 {
+		fmt.Printf("\nNot checked (no API key): %s\n", strings.Join(missing, ", "))
+	}
+	return healthy
+}
+
+func init() {
+	rootCmd.AddCommand(providersCmd)
+
+	providersCmd.Flags().BoolVar(&providersCheck, "check", false, "Send a minimal request to each provider with an API key and report its health")
+}
@@ -0,0 +1,45 @@
+package cmd
+
+import "unicode"
+
+// handleEditingKey handles the readline actions of the input, it returns false for other
+// actions. Line actions act on the line of a multiline input the cursor is on.
+func (m *interactiveModel) handleEditingKey(action string) bool {
+	start, end := currentLine(m.input, m.cursor)
+	switch action {
+	case actionLineStart:
+		m.cursor = start
+	case actionLineEnd:
+		m.cursor = end
+	case actionWordBackward:
+		m.cursor = previousWordStart(m.input, m.cursor)
+	case actionWordForward:
+		m.cursor = nextWordEnd(m.input, m.cursor)
+	case actionDeleteWord:
+		m.deleteInput(previousWordStart(m.input, m.cursor), m.cursor)
+	case actionDeleteToStart:
+		m.deleteInput(start, m.cursor)
+	case actionDeleteToEnd:
+		m.deleteInput(m.cursor, end)
+	default:
+		return false
+	}
+	return true
+}
+
+// deleteInput removes the input between two positions and moves the cursor to the first
+func (m *interactiveModel) deleteInput(from, to int) {
+	m.input = append(m.input[:from:from], m.input[to:]...)
+	m.cursor = from
+}
+
+// nextWordEnd returns the position of the end of the word after the cursor
+func nextWordEnd(text []rune, pos int) int {
+	for pos < len(text) && unicode.IsSpace(text[pos]) {
+		pos++
+	}
+	for pos < len(text) && !unicode.IsSpace(text[pos]) {
+		pos++
+	}
+	return pos
+}
@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/spf13/viper"
+)
+
+// refusalPhrases are openings typical of refusal or safety boilerplate
+var refusalPhrases = []string{
+	"i can't help with",
+	"i cannot help with",
+	"i can't assist with",
+	"i cannot assist with",
+	"i can't provide",
+	"i cannot provide",
+	"i'm sorry, but i can't",
+	"i'm sorry, but i cannot",
+	"i am sorry, but i cannot",
+	"i'm not able to help",
+	"i am not able to help",
+	"i'm unable to help",
+	"i won't be able to help",
+	"i must decline",
+	"as an ai language model, i cannot",
+	"抱歉，我无法",
+	"很抱歉，我无法",
+	"抱歉，我不能",
+	"很抱歉，我不能",
+	"我无法提供",
+}
+
+// refusalCheckLength is how many leading characters of a response are checked for refusal phrases
+const refusalCheckLength = 300
+
+// refusalHintsEnabled returns true unless refusal hints are disabled in the configuration
+func refusalHintsEnabled() bool {
+	if !viper.IsSet("refusal_hints") {
+		return true
+	}
+	return viper.GetBool("refusal_hints")
+}
+
+// isRefusal returns true if the response opens with refusal or safety boilerplate
+func isRefusal(response string) bool {
+	text := strings.ToLower(strings.TrimSpace(response))
+	text = strings.ReplaceAll(text, "’", "'") // Normalize curly apostrophes
+	if runes := []rune(text); len(runes) > refusalCheckLength {
+		text = string(runes[:refusalCheckLength])
+	}
+	for _, phrase := range refusalPhrases {
+		if strings.Contains(text, phrase) {
+			return true
+		}
+	}
+	return false
+}
+
+// refusalHintMessage is shown after a response that looks like a refusal
+func refusalHintMessage() Message {
+	return Message{
+		Type:    MessageTypeChait,
+		Content: "This looks like a refusal. Press 'e' to edit and resend the prompt, or 'm' to switch model and retry.",
+	}
+}
@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// handleRegenerateCommand drops the last response and sends its prompt again with ':r', or
+// once a model (':r m') or a temperature (':r t') is picked in the selector
+func (m *interactiveModel) handleRegenerateCommand(args []string) {
+	if len(args) > 1 || (len(args) == 1 && args[0] != "m" && args[0] != "t") {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: "Usage: :r [m|t]"})
+		return
+	}
+	if !m.hasPrompt() {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "There is no response to regenerate yet."})
+		return
+	}
+
+	switch {
+	case len(args) == 0:
+		m.regenerate = true
+	case args[0] == "m":
+		m.activateSelector(&m.modelSelector)
+		m.resendOnModelSelect = true
+	default:
+		m.activateSelector(&m.temperatureSelector)
+		m.resendOnTemperatureSelect = true
+	}
+}
+
+// handleDeleteCommand removes the last message of the user and what followed it, such as the
+// response, from the conversation and its context with ':d'
+func (m *interactiveModel) handleDeleteCommand() {
+	if !m.hasPrompt() {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "There is no exchange to delete yet."})
+		return
+	}
+	m.dropLastResponse()
+	m.messages = m.messages[:len(m.messages)-1]
+	m.streamStalled = false
+	m.refusalHint = false
+	m.autoSave()
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "Deleted the last exchange"})
+}
+
+// hasPrompt returns true if the conversation has a message of the user to send again
+func (m *interactiveModel) hasPrompt() bool {
+	for _, msg := range m.messages {
+		if msg.Type == MessageTypeUser {
+			return true
+		}
+	}
+	return false
+}
+
+// takeRegenerate resends the last prompt if ':r' asked for it
+func (m *interactiveModel) takeRegenerate() tea.Cmd {
+	if !m.regenerate {
+		return nil
+	}
+	m.regenerate = false
+	return m.resendLastPrompt()
+}
+
+// resendLastPrompt drops the last response and sends its prompt again
+func (m *interactiveModel) resendLastPrompt() tea.Cmd {
+	m.dropLastResponse()
+	m.autoScrollBottom = true
+	m.enableInput = false
+	m.streamStalled = false
+	m.refusalHint = false
+	return func() tea.Msg {
+		return startStreamingMsg{}
+	}
+}
@@ -0,0 +1,186 @@
+package cmd
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
+)
+
+// configChangedMsg is sent to the interactive model when the config file changes on disk
+type configChangedMsg struct {
+	data []byte
+}
+
+// lastConfigHash is the hash of the config content applied last, to skip duplicate events
+// and the writes of chait itself
+var lastConfigHash [sha256.Size]byte
+
+// watchConfig watches the config file and calls send with its new content when it is
+// changed by something other than chait. The content is only read here, it is parsed and
+// applied by the interactive model so that viper is only used from one goroutine.
+func watchConfig(send func(configChangedMsg)) {
+	path := filepath.Clean(viper.ConfigFileUsed())
+	if path == "." {
+		return
+	}
+	if data, err := os.ReadFile(path); err == nil {
+		lastConfigHash = sha256.Sum256(data)
+	}
+
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		util.DebugLog(util.ModuleConfig, "Error watching %s: %v", path, err)
+		return
+	}
+	// The directory is watched, editors replace files by renaming a new one over them
+	if err := watcher.Add(filepath.Dir(path)); err != nil {
+		util.DebugLog(util.ModuleConfig, "Error watching %s: %v", path, err)
+		watcher.Close()
+		return
+	}
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != path || !event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+					continue
+				}
+				data, err := os.ReadFile(path)
+				if err != nil {
+					util.DebugLog(util.ModuleConfig, "Error reading changed config %s: %v", path, err)
+					continue
+				}
+				if util.IsOwnConfigWrite(data) {
+					continue
+				}
+				send(configChangedMsg{data: data})
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				util.DebugLog(util.ModuleConfig, "Error watching %s: %v", path, err)
+			}
+		}
+	}()
+	util.DebugLog(util.ModuleConfig, "Watching %s for changes", path)
+}
+
+// temperatureProblem describes a configured temperature the provider did not use as is
+// because it is invalid or out of range, empty if there is none
+func temperatureProblem(p provider.Provider, config map[string]interface{}) string {
+	value, ok := config["temperature"]
+	if !ok || fmt.Sprint(value) == fmt.Sprint(p.GetCurrentTemperature()) {
+		return ""
+	}
+	return fmt.Sprintf("providers.%s.temperature: %v is invalid or out of range, using %.2g", p.GetName(), value, p.GetCurrentTemperature())
+}
+
+// reloadConfig applies the content of a changed config file. It returns an error, and
+// keeps the current settings, if the file cannot be parsed; otherwise it returns the
+// problems found in the new settings, which are replaced by defaults. changed is false
+// if the content was already applied. The provider, model and temperature given for this
+// invocation, with flags or a persona, are kept.
+func reloadConfig(data []byte) (changed bool, problems []string, err error) {
+	hash := sha256.Sum256(data)
+	if hash == lastConfigHash {
+		return false, nil, nil
+	}
+
+	lastConfigHash = hash
+
+	fresh := viper.New()
+	fresh.SetConfigType("json")
+	if err := fresh.ReadConfig(bytes.NewReader(data)); err != nil {
+		return true, nil, fmt.Errorf("invalid config: %v", err)
+	}
+
+	// Values set at runtime take precedence over the file in viper, they are cleared so
+	// that the keys removed from the file fall back to their defaults. Flags keep their
+	// precedence over the file.
+	previousProvider := viper.GetString("provider")
+	for _, key := range viper.AllKeys() {
+		viper.Set(strings.SplitN(key, ".", 2)[0], nil)
+	}
+	if err := viper.ReadConfig(bytes.NewReader(data)); err != nil {
+		return true, nil, fmt.Errorf("invalid config: %v", err)
+	}
+
+	if name := viper.GetString("log_level"); name != "" {
+		if _, err := util.ParseLogLevel(name); err != nil {
+			problems = append(problems, fmt.Sprintf("log_level: %v", err))
+		}
+	}
+
+	for _, p := range api.GetAvailableProviders() {
+		name := p.GetName()
+		config := viper.GetStringMap("providers." + name)
+		if err := api.LoadProviderConfig(name, config); err != nil {
+			problems = append(problems, fmt.Sprintf("providers.%s: %v", name, err))
+			continue
+		}
+		if model, ok := config["model"].(string); ok && model != p.GetCurrentModel() {
+			problems = append(problems, fmt.Sprintf("providers.%s.model: unknown model %s, using %s", name, model, p.GetCurrentModel()))
+		}
+		if problem := temperatureProblem(p, config); problem != "" {
+			problems = append(problems, problem)
+		}
+	}
+
+	if err := loadTheme(); err != nil {
+		problems = append(problems, fmt.Sprintf("theme: %v", err))
+	}
+	problems = append(problems, keymapProblems()...)
+
+	// Only a provider changed in the file is switched to, a ready provider used instead of
+	// the configured one is kept
+	if name := viper.GetString("provider"); name != "" && name != previousProvider && name != api.GetActiveProviderName() {
+		if err := api.UseProvider(name); err != nil {
+			problems = append(problems, fmt.Sprintf("provider: %v", err))
+		}
+	}
+	if err := applyFlagOverrides(); err != nil {
+		problems = append(problems, fmt.Sprintf("command-line overrides: %v", err))
+	}
+	return true, problems, nil
+}
+
+// applyConfigChange reloads the config and reports the result in the conversation
+func (m *interactiveModel) applyConfigChange(msg configChangedMsg) {
+	changed, problems, err := reloadConfig(msg.data)
+	if !changed {
+		return
+	}
+	if err != nil {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("Config not reloaded, keeping the current settings: %v", err),
+		})
+		m.scrollToBottom()
+		return
+	}
+
+	refreshConfig(m)
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Config reloaded from %s (provider: %s, model: %s)", viper.ConfigFileUsed(), api.GetActiveProviderName(), api.GetCurrentModel()),
+	})
+	if len(problems) > 0 {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: "Config problems, defaults are used instead:\n- " + strings.Join(problems, "\n- "),
+		})
+	}
+	m.scrollToBottom()
+}
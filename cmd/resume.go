@@ -0,0 +1,433 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/session"
+	"github.com/plucury/chait/tokens"
+	"github.com/spf13/viper"
+)
+
+const (
+	// defaultResumeTokenWarning is the context size per turn above which resuming offers to trim or summarize
+	defaultResumeTokenWarning = 4000
+	// resumeKeepMessages is the number of recent messages kept when trimming or summarizing
+	resumeKeepMessages = 10
+)
+
+// summarizePrompt asks the model to condense the earlier part of a conversation
+const summarizePrompt = `Summarize the following conversation between a user and an assistant.
+Keep every fact, decision, code identifier and open question needed to continue it.
+Answer with the summary only.`
+
+// summaryHeading introduces the summary of the earlier conversation in the system prompt
+const summaryHeading = "\n\nSummary of the earlier conversation:\n"
+
+// resumeSession continues a saved conversation in interactive mode, sending input and images
+// first if not empty
+func resumeSession(s *session.Session, input string, images []string) error {
+	useSessionSettings(s)
+	messages, reduced, ok := prepareResume(s)
+	if !ok {
+		return nil
+	}
+	if reduced {
+		// The saved conversation is kept whole, the trimmed or summarized one continues it
+		next, err := continueInNewPart(s, messages)
+		if err != nil {
+			return err
+		}
+		s, messages = next, messagesFromSession(next)
+	}
+	return StartInteractiveSession(s, messages, input, images)
+}
+
+// linkNextPart returns a new session continuing the conversation of the given one, which
+// becomes a numbered part linked to it. Neither session is saved.
+func linkNextPart(previous *session.Session) *session.Session {
+	title := previous.DisplayTitle()
+	next := session.New()
+	next.Title, next.Tags, next.Notes = title, previous.Tags, previous.Notes
+	previous.Part = max(previous.Part, 1)
+	next.Part, next.Previous = previous.Part+1, previous.ID
+	previous.Title, previous.Next = fmt.Sprintf("%s (part %d)", title, previous.Part), next.ID
+	return next
+}
+
+// continueInNewPart saves the trimmed or summarized messages of a resumed conversation as
+// a new part, so that the saved conversation is never replaced by them
+func continueInNewPart(s *session.Session, messages []Message) (*session.Session, error) {
+	next := linkNextPart(s)
+	next.Provider, next.Model, next.Temperature, next.Params = s.Provider, s.Model, s.Temperature, s.Params
+	messages = append(withSystemMessages(messages), Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Continued from %s, which keeps the whole conversation (open it with :o %s)", s.Title, s.ID),
+		Note:    true,
+	})
+	next.Messages = sessionMessages(messages)
+	if err := session.Save(next); err != nil {
+		return nil, err
+	}
+	if err := session.Save(s); err != nil {
+		return nil, err
+	}
+	return next, nil
+}
+
+// messagesFromSession converts a saved session into interactive mode messages
+func messagesFromSession(s *session.Session) []Message {
+	messages := make([]Message, 0, len(s.Messages))
+	for _, m := range s.Messages {
+		var msgType MessageType
+		switch m.Role {
+		case "system":
+			msgType = MessageTypeSystem
+		case "developer":
+			msgType = MessageTypeDeveloper
+		case "user":
+			msgType = MessageTypeUser
+		case "assistant":
+			msgType = MessageTypeAssistant
+		default:
+			// Provenance notes and other annotations are only displayed
+			messages = append(messages, Message{Type: MessageTypeChait, Content: m.Content, Note: true})
+			continue
+		}
+		messages = append(messages, Message{Type: msgType, Content: m.Content, Pinned: m.Pinned})
+	}
+	return messages
+}
+
+// sessionMessages converts the conversation of interactive mode into saved messages
+// Only the system prompt, the exchanges and session notes are kept
+func sessionMessages(messages []Message) []session.Message {
+	var saved []session.Message
+	for _, m := range messages {
+		switch {
+		case isInstruction(m.Type) || m.Type == MessageTypeUser || m.Type == MessageTypeAssistant:
+			saved = append(saved, session.Message{Role: strings.ToLower(string(m.Type)), Content: m.Content, Pinned: m.Pinned})
+		case m.Note:
+			saved = append(saved, session.Message{Role: session.RoleNote, Content: m.Content})
+		}
+	}
+	return saved
+}
+
+// useSessionSettings continues with the provider, model, temperature and parameters the
+// conversation was held with, if available
+func useSessionSettings(s *session.Session) {
+	if s.Provider == "" {
+		return
+	}
+	if err := api.UseProvider(s.Provider); err != nil {
+		DebugLog("Could not switch to session provider %s: %v", s.Provider, err)
+		return
+	}
+	p := api.GetActiveProvider()
+	if s.Model != "" {
+		if err := p.SetCurrentModel(s.Model); err != nil {
+			DebugLog("Could not switch to session model %s: %v", s.Model, err)
+		}
+	}
+	if s.Temperature != 0 {
+		if err := p.SetCurrentTemperature(s.Temperature); err != nil {
+			DebugLog("Could not use session temperature %.1f: %v", s.Temperature, err)
+		}
+	}
+	if s.Params != nil {
+		params := provider.SamplingParams{TopP: s.Params.TopP, FrequencyPenalty: s.Params.FrequencyPenalty, PresencePenalty: s.Params.PresencePenalty}
+		if err := p.SetSamplingParams(params); err != nil {
+			DebugLog("Could not use session parameters: %v", err)
+		}
+	}
+}
+
+// sessionParams returns the sampling settings of the active provider to save with a
+// conversation, nil when they are the provider defaults
+func sessionParams() *session.Params {
+	params := api.GetActiveProvider().GetSamplingParams()
+	if params == (provider.SamplingParams{}) {
+		return nil
+	}
+	return &session.Params{TopP: params.TopP, FrequencyPenalty: params.FrequencyPenalty, PresencePenalty: params.PresencePenalty}
+}
+
+// saveConversationsEnabled returns whether conversations are saved automatically, true
+// unless save_conversations is false
+func saveConversationsEnabled() bool {
+	return !viper.IsSet("save_conversations") || viper.GetBool("save_conversations")
+}
+
+// autoSave saves the conversation once it has a message, keeping the provider, model and
+// temperature in use for resuming it
+func (m *interactiveModel) autoSave() {
+	if !saveConversationsEnabled() {
+		return
+	}
+	hasMessage := false
+	for _, msg := range m.messages {
+		if msg.Type == MessageTypeUser {
+			hasMessage = true
+			break
+		}
+	}
+	// A saved conversation is updated even once its messages are deleted with ':d'
+	if !hasMessage && m.session == nil {
+		return
+	}
+
+	m.ensureSession()
+	m.session.Provider = api.GetActiveProviderName()
+	m.session.Model = api.GetCurrentModel()
+	m.session.Temperature = api.GetCurrentTemperature()
+	m.session.Params = sessionParams()
+	if err := m.saveSession(); err != nil {
+		DebugLog("Error saving conversation: %v", err)
+	}
+}
+
+// handleResumeCommand replaces the conversation with a saved one: ":resume" opens the most
+// recent other conversation, ":resume <name-or-id>" the given one
+func (m *interactiveModel) handleResumeCommand(args []string) {
+	var s *session.Session
+	var err error
+	if len(args) == 0 {
+		s, err = latestOtherSession(m.session)
+	} else {
+		s, err = session.Load(strings.Join(args, " "))
+	}
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return
+	}
+	m.openSession(s)
+}
+
+// latestOtherSession returns the most recently updated session other than the current one
+func latestOtherSession(current *session.Session) (*session.Session, error) {
+	sessions, err := session.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range sessions {
+		if current == nil || s.ID != current.ID {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no other saved conversation in %s", session.Dir())
+}
+
+// openSession saves the current conversation and continues a saved one instead
+func (m *interactiveModel) openSession(s *session.Session) {
+	m.autoSave()
+	useSessionSettings(s)
+	refreshConfig(m)
+
+	m.stopStream()
+	m.session = s
+	m.streamStalled = false
+	m.refusalHint = false
+	m.pendingImages = nil
+	m.pendingFiles = nil
+	messages := withSystemMessages(messagesFromSession(s))
+	m.messages = append(messages, Message{
+		Type: MessageTypeChait,
+		Content: fmt.Sprintf("Resumed %s (%s, %d messages) with %s (model: %s), ~%d tokens of context are sent with each new message",
+			s.DisplayTitle(), s.ID, len(s.Messages), api.GetActiveProviderName(), api.GetCurrentModel(), contextTokensPerTurn(messages)),
+	})
+	m.autoScrollBottom = true
+}
+
+// ensureSession associates the conversation with a new session if it has none
+func (m *interactiveModel) ensureSession() {
+	if m.session == nil {
+		m.session = session.New()
+		m.session.Provider = api.GetActiveProviderName()
+		m.session.Model = api.GetCurrentModel()
+		m.session.Temperature = api.GetCurrentTemperature()
+		m.session.Params = sessionParams()
+	}
+}
+
+// saveSession saves the conversation, creating a new session if it was not saved before
+func (m *interactiveModel) saveSession() error {
+	m.ensureSession()
+	firstSave := !session.Exists(m.session.ID)
+	now := time.Now()
+
+	// Keep the times and sources of messages that were saved before
+	previous := make(map[string]session.Message)
+	for _, msg := range m.session.Messages {
+		previous[msg.Role+"\x00"+msg.Content] = msg
+	}
+	saved := sessionMessages(m.messages)
+	for i, msg := range saved {
+		if old, ok := previous[msg.Role+"\x00"+msg.Content]; ok {
+			saved[i].Time, saved[i].Source = old.Time, old.Source
+		} else {
+			saved[i].Time = now
+		}
+	}
+
+	m.session.Messages = saved
+	m.session.Updated = now
+	if err := session.Save(m.session); err != nil {
+		return err
+	}
+	// Keep the new conversation within the retention limits once it is counted among the saved ones
+	if firstSave {
+		pruneConversations(m.session.ID)
+	}
+	return nil
+}
+
+// contextTokensPerTurn estimates the tokens of context sent with each new message of the conversation
+func contextTokensPerTurn(messages []Message) int {
+	return tokens.CountMessages(currentTokenizer(), interactiveModel{messages: messages}.getRecentMessages())
+}
+
+// resumeTokenWarning returns the context size per turn above which resuming asks to trim or summarize
+func resumeTokenWarning() int {
+	if viper.IsSet("resume_token_warning") {
+		return viper.GetInt("resume_token_warning")
+	}
+	return defaultResumeTokenWarning
+}
+
+// prepareResume shows how much context a saved session consumes per turn and,
+// when it is large, offers to trim or summarize it. It returns whether the messages were
+// trimmed or summarized, and false if the user quits.
+func prepareResume(s *session.Session) ([]Message, bool, bool) {
+	messages := messagesFromSession(s)
+	perTurn := contextTokensPerTurn(messages)
+
+	fmt.Printf("Session %s: %d messages, ~%d tokens in total, ~%d tokens of context sent with each new message\n",
+		s.ID, len(s.Messages), conversationTokens(messages), perTurn)
+
+	threshold := resumeTokenWarning()
+	if threshold <= 0 || perTurn <= threshold {
+		return messages, false, true
+	}
+
+	reader := bufio.NewReader(os.Stdin)
+	for {
+		fmt.Printf("This conversation is long. [c]ontinue, [t]rim to the last %d messages, [s]ummarize older messages, [q]uit: ", resumeKeepMessages)
+		answer, err := reader.ReadString('\n')
+		if err != nil {
+			return nil, false, false
+		}
+
+		switch strings.ToLower(strings.TrimSpace(answer)) {
+		case "c", "":
+			return messages, false, true
+		case "t":
+			trimmed := trimMessages(messages, resumeKeepMessages)
+			fmt.Printf("Trimmed to ~%d tokens per turn\n", contextTokensPerTurn(trimmed))
+			return trimmed, true, true
+		case "s":
+			fmt.Println("Summarizing older messages...")
+			summarized, err := summarizeMessages(messages, resumeKeepMessages)
+			if err != nil {
+				fmt.Printf("Error summarizing conversation: %v\n", err)
+				continue
+			}
+			fmt.Printf("Summarized to ~%d tokens per turn\n", contextTokensPerTurn(summarized))
+			return summarized, true, true
+		case "q":
+			return nil, false, false
+		}
+	}
+}
+
+// conversationTokens estimates the tokens of the whole conversation, including messages that are no longer sent
+func conversationTokens(messages []Message) int {
+	var chatMessages []provider.ChatMessage
+	for _, msg := range messages {
+		if isInstruction(msg.Type) || msg.Type == MessageTypeUser || msg.Type == MessageTypeAssistant {
+			chatMessages = append(chatMessages, msg.ToChatMessage())
+		}
+	}
+	return tokens.CountMessages(currentTokenizer(), chatMessages)
+}
+
+// currentTokenizer returns the tokenizer for the active model
+func currentTokenizer() tokens.Tokenizer {
+	return tokens.ForModel(api.GetCurrentModel())
+}
+
+// splitRecent splits a conversation into its system messages, older exchanges and the last keep messages
+func splitRecent(messages []Message, keep int) (system, older, recent []Message) {
+	var chat []Message
+	for _, msg := range messages {
+		switch msg.Type {
+		case MessageTypeSystem, MessageTypeDeveloper:
+			system = append(system, msg)
+		case MessageTypeUser, MessageTypeAssistant:
+			chat = append(chat, msg)
+		}
+	}
+	if len(chat) <= keep {
+		return system, nil, chat
+	}
+	return system, chat[:len(chat)-keep], chat[len(chat)-keep:]
+}
+
+// trimMessages keeps the system prompt and the last keep messages
+func trimMessages(messages []Message, keep int) []Message {
+	system, older, recent := splitRecent(messages, keep)
+	trimmed := system
+	if len(older) > 0 {
+		trimmed = append(trimmed, Message{
+			Type:    MessageTypeChait,
+			Content: fmt.Sprintf("%d older messages were trimmed", len(older)),
+		})
+	}
+	return append(trimmed, recent...)
+}
+
+// summarizeMessages replaces the older messages with a summary appended to the system prompt
+func summarizeMessages(messages []Message, keep int) ([]Message, error) {
+	system, older, recent := splitRecent(messages, keep)
+	if len(older) == 0 {
+		return messages, nil
+	}
+
+	summary, err := summarize(older)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(system) == 0 {
+		system = []Message{{Type: MessageTypeSystem, Content: defaultSystemPrompt}}
+	}
+	system[0].Content += summaryHeading + summary
+
+	summarized := append(system, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("%d older messages were summarized into the system prompt", len(older)),
+	})
+	return append(summarized, recent...), nil
+}
+
+// summarize asks the active model for a summary of the messages
+func summarize(messages []Message) (string, error) {
+	var transcript strings.Builder
+	for _, msg := range messages {
+		transcript.WriteString(fmt.Sprintf("%s: %s\n\n", msg.Type, msg.Content))
+	}
+	summary, err := api.SendChatRequest(context.Background(), []api.ChatMessage{
+		{Role: "system", Content: summarizePrompt},
+		{Role: "user", Content: transcript.String()},
+	})
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimSpace(summary), nil
+}
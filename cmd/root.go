@@ -2,14 +2,20 @@ package cmd
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"os"
 	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
+	"unicode/utf8"
 
+	"github.com/charmbracelet/x/term"
 	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/session"
 	"github.com/plucury/chait/util"
 	"github.com/spf13/cobra"
 	"github.com/spf13/viper"
@@ -24,7 +30,7 @@ var version string = "0.2.5"
 var rootCmd = &cobra.Command{
 	Use:   "chait",
 	Short: "A AI chat command-line tool and more",
-	Long:  `A AI chat command-line tool built with Cobra. support providers: openai, deepseek, grok`,
+	Long:  `A AI chat command-line tool built with Cobra. support providers: openai, deepseek, grok, groq, perplexity, together, moonshot, zhipu`,
 	// Allow arbitrary arguments to be passed
 	Args: cobra.ArbitraryArgs,
 	PersistentPreRun: func(cmd *cobra.Command, args []string) {
@@ -49,7 +55,7 @@ var rootCmd = &cobra.Command{
 		}
 
 		// Check if we need to interactively select a provider
-		if selectProvider {
+		if providerFlag == selectFlagValue {
 			if err := configureProvider(); err != nil {
 				fmt.Printf("Error configuring provider: %v\n", err)
 				return
@@ -57,11 +63,40 @@ var rootCmd = &cobra.Command{
 			return
 		}
 
+		util.SetJSONMode(jsonModeFlag)
+		if sessionFlag != "" || lastSessionFlag {
+			// Opening a saved conversation implies interactive mode
+			interactiveMode = true
+		}
+		if maxTokensFlag < 0 {
+			fmt.Println("Error: --max-tokens must not be negative")
+			return
+		}
+		util.SetMaxTokensOverride(maxTokensFlag)
+		if err := validateWebhookFormat(postToFormat); err != nil {
+			fmt.Printf("Error: --post-format: %v\n", err)
+			return
+		}
+		if briefFlag {
+			util.SetAnswerMode(util.AnswerModeBrief)
+		} else if detailedFlag {
+			util.SetAnswerMode(util.AnswerModeDetailed)
+		}
+		if cmd.Flags().Changed("seed") {
+			util.SetSeed(seedFlag)
+		}
+
 		// Get the currently used provider from configuration
 		providerName := viper.GetString("provider")
 
+		// Values given to --provider, --model and --temperature only apply to this invocation
+		if err := applyFlagOverrides(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
 		// Check if we need to interactively set temperature
-		if setTemperatureInteractive {
+		if temperatureFlag == selectFlagValue {
 			// Get the active provider
 			provider := api.GetActiveProvider()
 			if provider == nil {
@@ -172,26 +207,22 @@ var rootCmd = &cobra.Command{
 				return
 			}
 
-			// Save provider configuration
-			config := make(map[string]interface{})
-			provider.SaveConfig(config)
-
-			// Save to viper
-			for k, v := range config {
-				viper.Set(fmt.Sprintf("providers.%s.%s", provider.GetName(), k), v)
-			}
+			// With --no-save the temperature is only used for this invocation
+			if util.IsNoSave() {
+				fmt.Printf("Temperature for %s set to %.1f for this run.\n", provider.GetName(), newTemperature)
+			} else {
+				// Only the selected setting is saved, not the overrides given for this invocation
+				if err := saveProviderSetting(provider, "temperature", newTemperature); err != nil {
+					fmt.Printf("Error saving temperature setting: %v\n", err)
+				}
 
-			// Write to the configuration file
-			if err := viper.WriteConfig(); err != nil {
-				fmt.Printf("Error saving temperature setting: %v\n", err)
+				fmt.Printf("Temperature for %s set to %.1f and saved to config.\n", provider.GetName(), newTemperature)
+				return
 			}
-
-			fmt.Printf("Temperature for %s set to %.1f and saved to config.\n", provider.GetName(), newTemperature)
-			return
 		}
 
 		// Check if we need to interactively select a model
-		if selectModelInteractive {
+		if modelFlag == selectFlagValue {
 			// Get the active provider
 			provider := api.GetActiveProvider()
 			if provider == nil {
@@ -265,22 +296,18 @@ var rootCmd = &cobra.Command{
 				return
 			}
 
-			// Save provider configuration
-			config := make(map[string]interface{})
-			provider.SaveConfig(config)
-
-			// Save to viper
-			for k, v := range config {
-				viper.Set(fmt.Sprintf("providers.%s.%s", provider.GetName(), k), v)
-			}
+			// With --no-save the model is only used for this invocation
+			if util.IsNoSave() {
+				fmt.Printf("Using model %s for this run.\n", newModel)
+			} else {
+				// Only the selected setting is saved, not the overrides given for this invocation
+				if err := saveProviderSetting(provider, "model", newModel); err != nil {
+					fmt.Printf("Error saving model setting: %v\n", err)
+				}
 
-			// Write to the configuration file
-			if err := viper.WriteConfig(); err != nil {
-				fmt.Printf("Error saving model setting: %v\n", err)
+				fmt.Printf("Switched to model: %s\n", newModel)
+				return
 			}
-
-			fmt.Printf("Switched to model: %s\n", newModel)
-			return
 		}
 		// If no provider is configured, prompt the user to select one
 		if providerName == "" {
@@ -297,24 +324,24 @@ var rootCmd = &cobra.Command{
 				// If still empty, use the default value
 				providerName = api.DefaultProvider
 			}
-		}
 
-		// Load provider configuration
-		providerConfig := viper.GetStringMap(fmt.Sprintf("providers.%s", providerName))
+			// Load provider configuration
+			providerConfig := viper.GetStringMap(fmt.Sprintf("providers.%s", providerName))
 
-		// Convert viper configuration to map[string]interface{}
-		config := make(map[string]interface{})
-		for k, v := range providerConfig {
-			config[k] = v
-		}
+			// Convert viper configuration to map[string]interface{}
+			config := make(map[string]interface{})
+			for k, v := range providerConfig {
+				config[k] = v
+			}
 
-		// Load provider configuration
-		DebugLog("Loading provider configuration for %s", providerName)
-		if err := api.LoadProviderConfig(providerName, config); err != nil {
-			fmt.Printf("Error loading provider config: %v\n", err)
-			return
+			// Load provider configuration
+			DebugLog("Loading provider configuration for %s", providerName)
+			if err := api.LoadProviderConfig(providerName, config); err != nil {
+				fmt.Printf("Error loading provider config: %v\n", err)
+				return
+			}
+			DebugLog("Successfully loaded provider configuration for %s", providerName)
 		}
-		DebugLog("Successfully loaded provider configuration for %s", providerName)
 
 		// Get all ready providers
 		readyProviders := api.GetReadyProviders()
@@ -349,13 +376,31 @@ var rootCmd = &cobra.Command{
 
 		// Check if the current active provider is ready
 		if !provider.IsReady() {
-			// If the current active provider is not ready, but there are other ready providers, switch to the first ready provider
-			if err := api.SetActiveProvider(readyProviders[0].GetName()); err != nil {
-				fmt.Printf("Error setting active provider: %v\n", err)
-				return
+			switch {
+			case useReadyProvider():
+				// Fall back to the first ready provider for this run only, the configured
+				// provider is kept for the next ones and restored with :p!
+				if err := api.UseProvider(readyProviders[0].GetName()); err != nil {
+					fmt.Printf("Error setting active provider: %v\n", err)
+					return
+				}
+				unreadyProvider = provider.GetName()
+				provider = readyProviders[0]
+				util.WarnLog(util.ModuleCLI, "Provider %s is not ready, falling back to %s", unreadyProvider, provider.GetName())
+				fmt.Fprintf(os.Stderr, "Warning: %s has no API key, using %s for this run\n", unreadyProvider, provider.GetName())
+			case !interactiveMode:
+				var names []string
+				for _, p := range readyProviders {
+					names = append(names, p.GetName())
+				}
+				fmt.Fprintf(os.Stderr, "Error: %s has no API key. Set it with chait -p, or set use_ready_provider to true to fall back to a ready provider (%s)\n",
+					provider.GetName(), strings.Join(names, ", "))
+				os.Exit(1)
 			}
-			provider = readyProviders[0]
-			fmt.Printf("Switched to ready provider: %s\n", provider.GetName())
+			// Interactive mode asks for the API key of the configured provider
+		}
+		if cmd.Flags().Changed("seed") && !api.SupportsSeed(provider.GetName()) {
+			fmt.Fprintf(os.Stderr, "Warning: %s does not support --seed, responses may differ between runs\n", provider.GetName())
 		}
 
 		// Check if there's piped input
@@ -364,8 +409,27 @@ var rootCmd = &cobra.Command{
 
 		// We'll handle the -i flag without argument case in a simpler way
 
+		// Load images attached with --image, "-" reads the image from stdin
+		var images []string
+		imageFromStdin := false
+		for _, path := range imagePaths {
+			if path == "-" {
+				if imageFromStdin || !hasPipedInput {
+					fmt.Println("Error: --image - requires an image piped to stdin (only once)")
+					return
+				}
+				imageFromStdin = true
+			}
+			image, err := loadImage(path)
+			if err != nil {
+				fmt.Printf("Error: %v\n", err)
+				return
+			}
+			images = append(images, image)
+		}
+
 		// If there's piped input, read it
-		if hasPipedInput {
+		if hasPipedInput && !imageFromStdin {
 			DebugLog("Detected piped input")
 			reader := bufio.NewReader(os.Stdin)
 			pipedInput, err := io.ReadAll(reader)
@@ -374,8 +438,19 @@ var rootCmd = &cobra.Command{
 				return
 			}
 
-			// Use the piped input as the input message
-			inputMessage = strings.TrimSpace(string(pipedInput))
+			if isImageData(pipedInput) {
+				// Binary image piped without --image -
+				image, err := imageDataURL(pipedInput)
+				if err != nil {
+					fmt.Printf("Error: piped input: %v\n", err)
+					return
+				}
+				DebugLog("Detected image in piped input (%d bytes)", len(pipedInput))
+				images = append(images, image)
+			} else {
+				// Use the piped input as the input message
+				inputMessage = strings.TrimSpace(string(pipedInput))
+			}
 		}
 
 		// No special case handling here - we'll handle it in a cleaner way
@@ -391,20 +466,34 @@ var rootCmd = &cobra.Command{
 		}
 
 		// If we have any input (from arguments or piped input)
-		if inputMessage != "" {
+		if inputMessage != "" || len(images) > 0 {
+			// Quick mode sends the prompt right away, so its problems are only reported
+			if !interactiveMode && lintEnabled() {
+				for _, issue := range lintPrompt(inputMessage) {
+					fmt.Fprintf(os.Stderr, "Warning: %s\n", issue.Warning)
+				}
+			}
+			// Bytes that are not valid UTF-8, e.g. binary data from a pipe, are rejected by the APIs
+			inputMessage = strings.ToValidUTF8(inputMessage, string(utf8.RuneError))
+
 			// Create a single message
 			messages := []api.ChatMessage{
-				{Role: "user", Content: inputMessage},
+				{Role: "user", Content: inputMessage, Images: images},
+			}
+
+			if len(images) > 0 && !api.SupportsVision(provider.GetCurrentModel()) {
+				fmt.Fprintf(os.Stderr, "Warning: model %s may not accept images\n", provider.GetCurrentModel())
 			}
 
 			if interactiveMode {
-				StartInteractiveMode(inputMessage)
+				startInteractive(inputMessage, images)
 				return // Return after starting interactive mode to prevent double initialization
 			} else {
 				DebugLog("Sending chat request to provider %s with message: %s", provider.GetName(), inputMessage)
+				messages = append(systemChatMessages(), messages...)
 
 				// Use streaming API for better user experience
-				streamChan, err := api.SendStreamingChatRequest(messages)
+				streamChan, err := api.SendStreamingChatRequest(context.Background(), messages)
 				if err != nil {
 					fmt.Printf("\nError: %v\n\n", err.Error())
 					return
@@ -412,23 +501,70 @@ var rootCmd = &cobra.Command{
 
 				// Process streaming response
 				var fullResponse strings.Builder
+				var usage *api.Usage
+				answeredBy, answeredModel := provider.GetName(), provider.GetCurrentModel()
 				for streamResp := range streamChan {
 					if streamResp.Error != nil {
 						fmt.Printf("\nError: %v\n\n", streamResp.Error)
 						return
 					}
+					if streamResp.Status != "" {
+						// Progress such as retries goes to stderr to keep the response clean
+						fmt.Fprintln(os.Stderr, api.FormatRetryStatus(streamResp.Status, streamResp.RetryAt))
+						continue
+					}
+					if streamResp.AnsweredBy != "" {
+						answeredBy, answeredModel = streamResp.AnsweredBy, streamResp.AnsweredModel
+						fmt.Fprintf(os.Stderr, "Answered by %s (%s)\n", answeredBy, answeredModel)
+						continue
+					}
+					if streamResp.Usage != nil {
+						usage = streamResp.Usage
+					}
 					fmt.Print(streamResp.Content)
 					fullResponse.WriteString(streamResp.Content)
 				}
 				// 确保在响应后有足够的换行
 				fmt.Println()
+
+				// Record the usage, and report it on stderr to keep the response clean
+				if usage != nil {
+					record := recordUsage(answeredBy, answeredModel, usage)
+					if showUsage {
+						fmt.Fprintln(os.Stderr, formatUsage(usage)+" · "+formatCost(record, 0))
+					}
+				} else if showUsage {
+					fmt.Fprintf(os.Stderr, "tokens: not reported by %s\n", answeredBy)
+				}
+
+				// Mark responses that are not valid JSON in JSON mode
+				if util.IsJSONMode() {
+					if err := validateJSONResponse(fullResponse.String()); err != nil {
+						fmt.Printf("\nError: %v\n\n", err)
+					}
+				}
+
+				// Deliver the response to the webhook if requested
+				if postToURL != "" {
+					envelope := webhookEnvelope{
+						Provider:  answeredBy,
+						Model:     answeredModel,
+						Prompt:    inputMessage,
+						Response:  fullResponse.String(),
+						Timestamp: time.Now(),
+					}
+					if err := postToWebhook(postToURL, postToFormat, envelope); err != nil {
+						fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+						os.Exit(1)
+					}
+				}
 			}
 		}
 
 		// No input messages, check if we should enter interactive mode
 		if interactiveMode {
 			// Start interactive mode without printing welcome again
-			StartInteractiveMode("")
+			startInteractive("", nil)
 		}
 	},
 }
@@ -447,20 +583,70 @@ func Execute() {
 // Whether to display the version information
 var showVersion bool
 
-// Whether to interactively select a provider
-var selectProvider bool
-
 // Whether to run in interactive mode
 var interactiveMode bool
 
 // Input message to send to the AI
 var inputMessage string
 
-// Whether to interactively select a model
-var selectModelInteractive bool
+// Saved conversation to open in interactive mode, by name or ID
+var sessionFlag string
+
+// Whether to open the most recent saved conversation in interactive mode
+var lastSessionFlag bool
+
+// Whether to request responses as JSON objects
+var jsonModeFlag bool
+
+// Maximum number of tokens per response, zero for the provider setting
+var maxTokensFlag int
 
-// Whether to interactively set temperature
-var setTemperatureInteractive bool
+// Whether to ask for brief or detailed answers
+var (
+	briefFlag    bool
+	detailedFlag bool
+)
+
+// Whether to print the token usage after the response
+var showUsage bool
+
+// Sampling seed for reproducible responses, only used if the flag is set
+var seedFlag int64
+
+// Image files to attach to the message, "-" for stdin
+var imagePaths []string
+
+// Configured provider that had no API key at startup, when a ready one is used instead
+var unreadyProvider string
+
+// useReadyProvider returns true if a ready provider is used when the configured one has no
+// API key, instead of asking for it
+func useReadyProvider() bool {
+	return viper.GetBool("use_ready_provider")
+}
+
+// startInteractive enters interactive mode, in the conversation selected with --session or --continue if any
+func startInteractive(input string, images []string) {
+	if sessionFlag == "" && !lastSessionFlag {
+		StartInteractiveMode(input, images)
+		return
+	}
+
+	var s *session.Session
+	var err error
+	if lastSessionFlag {
+		s, err = session.Latest()
+	} else {
+		s, err = session.Load(sessionFlag)
+	}
+	if err != nil {
+		fmt.Printf("Error: %v\n", err)
+		return
+	}
+	if err := resumeSession(s, input, images); err != nil {
+		fmt.Printf("Error: %v\n", err)
+	}
+}
 
 // configureProvider prompts the user to select and configure a provider
 func configureProvider() error {
@@ -516,8 +702,10 @@ func configureProvider() error {
 	// Save the selected provider to the configuration file
 	viper.Set("provider", providerName)
 
-	// Write to the configuration file
-	if err := viper.WriteConfig(); err != nil {
+	// Write to the configuration file, in safe mode the provider is only used for this run
+	if err := util.WriteConfig(); errors.Is(err, util.ErrSafeMode) {
+		fmt.Printf("Using %s for this run, the provider is not saved in safe mode.\n", providerName)
+	} else if err != nil {
 		fmt.Printf("Error saving provider setting: %v\n", err)
 	}
 
@@ -538,14 +726,32 @@ func configureProvider() error {
 	if selectedProvider.GetAPIKey() == "" {
 		// Prompt the user to enter an API key
 		fmt.Printf("Enter API key for %s: ", providerName)
-		apiKeyStr, err := reader.ReadString('\n')
-		if err != nil {
-			return fmt.Errorf("error reading API key: %v", err)
+		var apiKeyStr string
+		if util.IsSafeMode() && term.IsTerminal(os.Stdin.Fd()) {
+			// Don't echo the API key in safe mode
+			apiKeyBytes, err := term.ReadPassword(os.Stdin.Fd())
+			fmt.Println()
+			if err != nil {
+				return fmt.Errorf("error reading API key: %v", err)
+			}
+			apiKeyStr = string(apiKeyBytes)
+		} else {
+			apiKeyStr, err = reader.ReadString('\n')
+			if err != nil {
+				return fmt.Errorf("error reading API key: %v", err)
+			}
 		}
 
 		// Process the input
 		apiKey := strings.TrimSpace(apiKeyStr)
 
+		// Check the key with a minimal request before saving it
+		fmt.Printf("Checking the API key with %s...\n", providerName)
+		status, err := validateAPIKey(selectedProvider, apiKey)
+		if status == api.KeyInvalid {
+			return fmt.Errorf("%s", describeKeyCheck(providerName, status, err))
+		}
+
 		// Set the API key
 		selectedProvider.SetAPIKey(apiKey)
 
@@ -558,8 +764,8 @@ func configureProvider() error {
 			viper.Set(fmt.Sprintf("providers.%s.%s", providerName, k), v)
 		}
 
-		// Write to the configuration file
-		if err := viper.WriteConfig(); err != nil {
+		// Write to the configuration file, in safe mode the key is only kept for this run
+		if err := util.WriteConfig(); err != nil && !errors.Is(err, util.ErrSafeMode) {
 			return fmt.Errorf("error saving API key: %v", err)
 		}
 
@@ -568,7 +774,7 @@ func configureProvider() error {
 			return fmt.Errorf("error reloading provider config: %v", err)
 		}
 
-		fmt.Printf("%s API key set successfully!\n", providerName)
+		fmt.Println(describeKeyCheck(providerName, status, err))
 	}
 
 	// Final check if the provider is ready
@@ -601,6 +807,9 @@ func loadProviderConfigurations() {
 		// Load provider configuration
 		if err := api.LoadProviderConfig(providerName, config); err != nil {
 			fmt.Printf("Warning: Error loading configuration for provider %s: %v\n", providerName, err)
+		} else if problem := temperatureProblem(p, config); problem != "" {
+			// Shown once before interactive mode starts, interactive mode reports reloads itself
+			fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
 		}
 	}
 
@@ -608,7 +817,7 @@ func loadProviderConfigurations() {
 	configuredProvider := viper.GetString("provider")
 	if configuredProvider != "" {
 		DebugLog("Setting active provider from config: %s", configuredProvider)
-		if err := api.SetActiveProvider(configuredProvider); err != nil {
+		if err := api.UseProvider(configuredProvider); err != nil {
 			fmt.Printf("Warning: Error setting active provider to %s: %v\n", configuredProvider, err)
 		} else {
 			DebugLog("Successfully set active provider to: %s", configuredProvider)
@@ -624,19 +833,60 @@ func init() {
 	// Add version flag
 	rootCmd.Flags().BoolVarP(&showVersion, "version", "v", false, "Display the current version of chait")
 	// Add provider selection flag
-	rootCmd.Flags().BoolVarP(&selectProvider, "provider", "p", false, "Interactively select a provider")
+	rootCmd.Flags().StringVarP(&providerFlag, "provider", "p", "", "Use this provider for this invocation (--provider=<name>), or select and configure one interactively (-p)")
+	rootCmd.Flags().Lookup("provider").NoOptDefVal = selectFlagValue
 	// Add interactive mode flag to enter interactive mode
 	rootCmd.Flags().BoolVarP(&interactiveMode, "interactive", "i", false, "Enter interactive mode after sending message")
+	// Add session flags to open a saved conversation
+	rootCmd.Flags().StringVar(&sessionFlag, "session", "", "Open a saved conversation (name or ID) in interactive mode")
+	rootCmd.Flags().BoolVarP(&lastSessionFlag, "continue", "c", false, "Continue the most recent conversation in interactive mode")
+	rootCmd.Flags().BoolVar(&lastSessionFlag, "last", false, "Open the most recent saved conversation in interactive mode")
+	rootCmd.Flags().MarkHidden("last") // Kept for compatibility, replaced by --continue
+	rootCmd.MarkFlagsMutuallyExclusive("session", "last")
+	rootCmd.MarkFlagsMutuallyExclusive("session", "continue")
 	// Add model selection flag
-	rootCmd.Flags().BoolVarP(&selectModelInteractive, "model", "m", false, "Interactively select a model for the current provider")
+	rootCmd.Flags().StringVarP(&modelFlag, "model", "m", "", "Use this model or alias for this invocation (--model=<name>), or select one for the current provider interactively (-m)")
+	rootCmd.Flags().Lookup("model").NoOptDefVal = selectFlagValue
 	// Add temperature setting flag
-	rootCmd.Flags().BoolVarP(&setTemperatureInteractive, "temperature", "t", false, "Interactively set temperature for the current provider")
+	rootCmd.Flags().StringVarP(&temperatureFlag, "temperature", "t", "", "Use this temperature for this invocation (--temperature=<value>), or set one for the current provider interactively (-t)")
+	rootCmd.Flags().Lookup("temperature").NoOptDefVal = selectFlagValue
+	// Add persona flag to apply a bundle of settings at once
+	rootCmd.Flags().StringVar(&personaFlag, "persona", "", "Use the system prompt, provider, model and temperature of a persona from the config for this invocation")
+	// Add JSON mode flag to request structured output
+	rootCmd.Flags().BoolVar(&jsonModeFlag, "json-mode", false, "Request responses as JSON objects and validate them")
+	// Add max tokens flag to cap response length
+	rootCmd.Flags().IntVar(&maxTokensFlag, "max-tokens", 0, "Maximum number of tokens per response (overrides the provider's max_tokens setting)")
+	// Add answer mode flags for short or detailed responses
+	rootCmd.Flags().BoolVar(&briefFlag, "brief", false, "Ask for a short answer and cap its length, for quick factual questions")
+	rootCmd.Flags().BoolVar(&detailedFlag, "detailed", false, "Ask for a detailed answer with reasoning and examples")
+	rootCmd.MarkFlagsMutuallyExclusive("brief", "detailed")
+	// Add usage flag to report the tokens consumed by the request
+	rootCmd.Flags().BoolVar(&showUsage, "show-usage", false, "Print the token usage reported by the provider after the response (to stderr)")
+	// Add seed flag for reproducible responses
+	rootCmd.Flags().Int64Var(&seedFlag, "seed", 0, "Sampling seed for reproducible responses (openai, grok and together)")
+	// Add image flag for vision-capable models
+	rootCmd.Flags().StringArrayVar(&imagePaths, "image", nil, "Attach an image file to the message (repeatable, '-' reads the image from stdin)")
+	// Add inline flag to keep the conversation in the terminal scrollback
+	rootCmd.Flags().Bool("inline", false, "Run interactive mode inline instead of in the alternate screen, keeping the conversation in the terminal scrollback")
+	viper.BindPFlag("inline", rootCmd.Flags().Lookup("inline"))
+	// Add webhook flags to deliver the final response
+	rootCmd.Flags().StringVar(&postToURL, "post-to", "", "POST the final response as a JSON envelope to this webhook URL")
+	rootCmd.Flags().StringVar(&postToFormat, "post-format", "json", "Webhook payload format: json or slack")
 
 	// Here you will define your flags and configuration settings.
 	// Cobra supports persistent flags, which, if defined here,
 	// will be global for your application.
 
 	rootCmd.PersistentFlags().StringVar(&cfgFile, "config", "", "config file (default is $HOME/.config/chait/config.json)")
+	// Add safe mode flag for shared or recorded sessions
+	rootCmd.PersistentFlags().Bool("safe-mode", false, "Disable API key display, config writes, file writes and shell commands (for demos and shared machines)")
+	viper.BindPFlag("safe_mode", rootCmd.PersistentFlags().Lookup("safe-mode"))
+	// Add no-save flag to try settings without changing the configuration
+	rootCmd.PersistentFlags().Bool("no-save", false, "Never write the configuration: providers, models and settings changed by this invocation only apply to it")
+	viper.BindPFlag("no_save", rootCmd.PersistentFlags().Lookup("no-save"))
+	// Add log level flag overriding the log_level config key
+	rootCmd.PersistentFlags().String("log-level", "", "Log level: off, error, warn, info, debug or trace")
+	viper.BindPFlag("log_level", rootCmd.PersistentFlags().Lookup("log-level"))
 }
 
 // IsDebugMode is a wrapper for util.IsDebugMode
@@ -644,9 +894,9 @@ func IsDebugMode() bool {
 	return util.IsDebugMode()
 }
 
-// DebugLog is a wrapper for util.DebugLog
+// DebugLog is a wrapper for util.DebugLog using the cli module
 func DebugLog(format string, args ...interface{}) {
-	util.DebugLog(format, args...)
+	util.DebugLog(util.ModuleCLI, format, args...)
 }
 
 func initConfig() {
@@ -656,9 +906,9 @@ func initConfig() {
 		// Use config file from the flag.
 		viper.SetConfigFile(cfgFile)
 
-		// Ensure the directory for the config file exists
+		// Ensure the directory for the config file exists, nothing is written in safe mode
 		configDir = filepath.Dir(cfgFile)
-		if err := os.MkdirAll(configDir, 0755); err != nil {
+		if err := createConfigDir(configDir); err != nil {
 			fmt.Printf("Error creating config directory %s: %v\n", configDir, err)
 			os.Exit(1)
 		}
@@ -677,8 +927,8 @@ func initConfig() {
 			fmt.Printf("Config directory: %s\n", configDir)
 		}
 
-		// Create config directory if it doesn't exist
-		if err := os.MkdirAll(configDir, 0755); err != nil {
+		// Create config directory if it doesn't exist, nothing is written in safe mode
+		if err := createConfigDir(configDir); err != nil {
 			fmt.Printf("Error creating config directory %s: %v\n", configDir, err)
 			os.Exit(1)
 		}
@@ -701,10 +951,11 @@ func initConfig() {
 
 			// Create default configuration
 			defaultConfig := map[string]interface{}{
-				"version":   1,
-				"provider":  "", // Current provider being used, empty string indicates user needs to choose
-				"providers": map[string]interface{}{},
-				"debug":     false, // Debug mode, when true prints debug logs
+				"version":          1,
+				"provider":         "", // Current provider being used, empty string indicates user needs to choose
+				"providers":        map[string]interface{}{},
+				"log_level":        "error", // Log level: off, error, warn, info, debug or trace
+				"disable_metadata": false,   // When true, no end-user IDs are sent to providers
 			}
 
 			// Create default configuration for each provider
@@ -727,14 +978,48 @@ func initConfig() {
 				viper.SetConfigFile(configFile)
 			}
 
-			fmt.Printf("Writing default config to: %s\n", configFile)
-			if err := viper.WriteConfig(); err != nil {
-				fmt.Printf("Error writing default config: %v\n", err)
+			// Keep the default config in memory only when config writes are disabled
+			if util.IsSafeMode() {
+				util.DebugLog(util.ModuleConfig, "Safe mode enabled, not writing default config to: %s", configFile)
 			} else {
-				fmt.Println("Default config created successfully")
+				fmt.Printf("Writing default config to: %s\n", configFile)
+				if err := util.WriteConfig(); err != nil {
+					fmt.Printf("Error writing default config: %v\n", err)
+				} else {
+					fmt.Println("Default config created successfully")
+				}
 			}
 		} else {
 			fmt.Printf("Error reading config file: %v\n", err)
 		}
 	}
+
+	initLogOutput()
+	session.SetDir(viper.GetString("conversations_dir"))
+}
+
+// createConfigDir creates the config directory unless safe mode disables writes
+func createConfigDir(configDir string) error {
+	if util.IsSafeMode() {
+		return nil
+	}
+	return os.MkdirAll(configDir, 0755)
+}
+
+// initLogOutput sends log messages to the configured log file, if any
+func initLogOutput() {
+	logFile := viper.GetString("log_file")
+	if logFile == "" {
+		return
+	}
+	if err := util.CheckWriteAllowed("writing logs"); err != nil {
+		return
+	}
+
+	file, err := os.OpenFile(logFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		fmt.Printf("Error opening log file %s: %v\n", logFile, err)
+		return
+	}
+	util.SetLogOutput(file)
 }
@@ -0,0 +1,47 @@
+package cmd
+
+import (
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/spf13/viper"
+)
+
+// scrollbarEnabled returns true unless the scrollbar is disabled in the configuration
+func scrollbarEnabled() bool {
+	return !viper.IsSet("scrollbar") || viper.GetBool("scrollbar")
+}
+
+// scrollbarShown returns true if the right column of the conversation is kept for the
+// scrollbar, the terminal scrolls the conversation in inline mode
+func (m interactiveModel) scrollbarShown() bool {
+	return scrollbarEnabled() && !m.inline
+}
+
+// scrollbar returns the cells of the scrollbar for the rows of the viewport, or nil when the
+// whole conversation is visible. The thumb is as long as the viewport is tall relative to
+// the conversation.
+func (m interactiveModel) scrollbar(pos, totalLines int) []string {
+	rows := visibleHeight(m.height)
+	bottom := maxScroll(totalLines, m.height)
+	if !m.scrollbarShown() || bottom == 0 {
+		return nil
+	}
+	thumb := max(1, rows*rows/totalLines)
+	top := clampScroll(pos, totalLines, m.height) * (rows - thumb) / bottom
+	cells := make([]string, rows)
+	for i := range cells {
+		if i >= top && i < top+thumb {
+			cells[i] = chaitStyle.Render("┃")
+		} else {
+			cells[i] = usageStyle.Render("│")
+		}
+	}
+	return cells
+}
+
+// scrollbarPadding returns the spaces between a line of the viewport, without styles, and
+// the scrollbar in the right column
+func (m interactiveModel) scrollbarPadding(content string, margin int) string {
+	return strings.Repeat(" ", max(0, m.width-1-margin-runewidth.StringWidth(content)))
+}
@@ -0,0 +1,205 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// searchState is the state of the '/' search of the conversation
+type searchState struct {
+	active bool   // Matches are highlighted and n/N jump between them
+	typing bool   // The query is being typed
+	query  []rune // Query being typed, or searched once confirmed with Enter
+	line   int    // Line of the current match in the formatted messages
+	col    int    // Rune index of the current match in its line
+}
+
+// searchMatch is an occurrence of the query in a line of the conversation
+type searchMatch struct {
+	line, start int // Line of the match and rune index of its start
+}
+
+// lineMatches returns the start and end rune indexes of the occurrences of the query in a
+// line. The search ignores case unless the query has upper case letters.
+func lineMatches(line string, query []rune) [][2]int {
+	if len(query) == 0 {
+		return nil
+	}
+	text := []rune(line)
+	ignoreCase := true
+	for _, r := range query {
+		if unicode.IsUpper(r) {
+			ignoreCase = false
+			break
+		}
+	}
+
+	var matches [][2]int
+	for start := 0; start+len(query) <= len(text); start++ {
+		found := true
+		for i, r := range query {
+			c := text[start+i]
+			if ignoreCase {
+				c = unicode.ToLower(c)
+			}
+			if c != r {
+				found = false
+				break
+			}
+		}
+		if found {
+			matches = append(matches, [2]int{start, start + len(query)})
+			start += len(query) - 1
+		}
+	}
+	return matches
+}
+
+// searchMatches returns the matches of the query in the lines of the conversation, in order
+func searchMatches(lines []messageWithType, query []rune) []searchMatch {
+	var matches []searchMatch
+	for i, line := range lines {
+		for _, match := range lineMatches(line.Content, query) {
+			matches = append(matches, searchMatch{line: i, start: match[0]})
+		}
+	}
+	return matches
+}
+
+// startSearch shows the search prompt at the bottom of the screen with '/'
+func (m *interactiveModel) startSearch() {
+	m.search = searchState{active: true, typing: true, line: m.scrollPos, col: -1}
+}
+
+// handleSearchKey handles the keys of search mode: the query is typed and searched with
+// Enter, then n/N jump to the next and previous matches until esc. Other keys end the
+// search and return false, to be handled as usual.
+func (m *interactiveModel) handleSearchKey(msg tea.KeyMsg) bool {
+	if m.search.typing {
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			m.search = searchState{}
+		case tea.KeyEnter:
+			m.search.typing = false
+			if len(m.search.query) == 0 {
+				m.search = searchState{}
+			} else {
+				m.jumpToMatch(1)
+			}
+		case tea.KeyBackspace:
+			if len(m.search.query) == 0 {
+				m.search = searchState{}
+			} else {
+				m.search.query = m.search.query[:len(m.search.query)-1]
+			}
+		case tea.KeySpace:
+			m.search.query = append(m.search.query, ' ')
+		case tea.KeyRunes:
+			m.search.query = append(m.search.query, msg.Runes...)
+		}
+		return true
+	}
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.search = searchState{}
+	case "n":
+		m.jumpToMatch(1)
+	case "N":
+		m.jumpToMatch(-1)
+	case "/":
+		m.startSearch()
+	default:
+		m.search = searchState{}
+		return false
+	}
+	return true
+}
+
+// jumpToMatch makes the next match after the current one in the given direction current,
+// wrapping around the conversation, and scrolls it into view
+func (m *interactiveModel) jumpToMatch(direction int) {
+	lines := m.getFormattedMessageLines()
+	matches := searchMatches(lines, m.search.query)
+	if len(matches) == 0 {
+		return
+	}
+
+	next := -1
+	if direction > 0 {
+		for i, match := range matches {
+			if match.line > m.search.line || (match.line == m.search.line && match.start > m.search.col) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = 0
+		}
+	} else {
+		for i := len(matches) - 1; i >= 0; i-- {
+			if matches[i].line < m.search.line || (matches[i].line == m.search.line && matches[i].start < m.search.col) {
+				next = i
+				break
+			}
+		}
+		if next < 0 {
+			next = len(matches) - 1
+		}
+	}
+	m.search.line, m.search.col = matches[next].line, matches[next].start
+
+	// Center the match unless it is already visible
+	height := visibleHeight(m.height)
+	if m.search.line < m.scrollPos || m.search.line >= m.scrollPos+height {
+		m.scrollPos = clampScroll(m.search.line-height/2, len(lines), m.height)
+	}
+	m.autoScrollBottom = false
+}
+
+// renderSearchLine renders a line of the conversation with the matches of the search
+// highlighted, the current one in a different color. It returns false if the line has no match.
+func (m interactiveModel) renderSearchLine(line messageWithType, index int) (string, bool) {
+	matches := lineMatches(line.Content, m.search.query)
+	if len(matches) == 0 {
+		return "", false
+	}
+	style := lineStyle(line.Type)
+	runes := []rune(line.Content)
+	var sb strings.Builder
+	pos := 0
+	for _, match := range matches {
+		sb.WriteString(style.Render(string(runes[pos:match[0]])))
+		matchStyle := style.Reverse(true)
+		if index == m.search.line && match[0] == m.search.col {
+			matchStyle = currentMatchStyle
+		}
+		sb.WriteString(matchStyle.Render(string(runes[match[0]:match[1]])))
+		pos = match[1]
+	}
+	sb.WriteString(style.Render(string(runes[pos:])))
+	return sb.String(), true
+}
+
+// searchStatus shows the query being typed, or the position of the current match and the
+// keys of search mode
+func (m interactiveModel) searchStatus() string {
+	if m.search.typing {
+		return "/" + string(m.search.query)
+	}
+	matches := searchMatches(m.getFormattedMessageLines(), m.search.query)
+	if len(matches) == 0 {
+		return fmt.Sprintf("/%s: no match, /: search again, esc: close", string(m.search.query))
+	}
+	position := 0
+	for i, match := range matches {
+		if match.line == m.search.line && match.start == m.search.col {
+			position = i + 1
+			break
+		}
+	}
+	return fmt.Sprintf("/%s: match %d/%d  n/N: next/previous, /: search again, esc: close", string(m.search.query), position, len(matches))
+}
@@ -0,0 +1,85 @@
+package cmd
+
+import "testing"
+
+func TestVisualColumnToRuneIndex(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		column int
+		want   int
+	}{
+		{"ascii", "hello", 2, 2},
+		{"start", "你好，世界", 0, 0},
+		{"first cell of a wide character", "你好，世界", 4, 2},
+		{"second cell of a wide character", "你好，世界", 5, 2},
+		{"after a full-width comma", "你好，世界", 6, 3},
+		{"mixed widths", "a，b。c！", 2, 1},
+		{"narrow after wide", "a，b。c！", 3, 2},
+		{"second cell of a trailing wide character", "ab！", 3, 2},
+		{"past the end", "ab！", 10, 3},
+		{"empty line", "", 3, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := visualColumnToRuneIndex([]rune(tt.line), tt.column); got != tt.want {
+				t.Errorf("visualColumnToRuneIndex(%q, %d) = %d, want %d", tt.line, tt.column, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVisualColumnToRuneEnd(t *testing.T) {
+	tests := []struct {
+		name   string
+		line   string
+		column int
+		want   int
+	}{
+		{"ascii", "hello", 2, 2},
+		{"nothing", "你好", 0, 0},
+		{"first cell of a wide character", "你好，世界", 4, 2},
+		{"second cell of a wide character", "你好，世界", 5, 3},
+		{"mixed widths", "a，b。c！", 2, 2},
+		{"second cell of a trailing wide character", "ab！", 3, 3},
+		{"past the end", "ab！", 10, 3},
+		{"combining mark", "e\u0301x", 1, 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := visualColumnToRuneEnd([]rune(tt.line), tt.column); got != tt.want {
+				t.Errorf("visualColumnToRuneEnd(%q, %d) = %d, want %d", tt.line, tt.column, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSelectedRunes(t *testing.T) {
+	tests := []struct {
+		name     string
+		line     string
+		startCol int
+		endCol   int
+		want     string
+	}{
+		{"ascii", "hello world", 6, 11, "world"},
+		{"whole wide characters", "你好，世界。", 4, 10, "，世界"},
+		{"starting on the second cell", "你好，世界。", 5, 8, "，世"},
+		{"ending on the first cell", "你好，世界。", 0, 5, "你好，"},
+		{"within one wide character", "你好，世界。", 4, 5, "，"},
+		{"to the end of the line", "ok！", 1, -1, "k！"},
+		{"trailing wide character", "ok！", 3, 4, "！"},
+		{"mixed widths", "a，b。c！", 1, 6, "，b。"},
+		{"reversed columns", "你好", 3, 1, ""},
+		{"past the end", "ok", 5, 9, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			runes := []rune(tt.line)
+			start, end := selectedRunes(runes, tt.startCol, tt.endCol)
+			if got := string(runes[start:end]); got != tt.want {
+				t.Errorf("selectedRunes(%q, %d, %d) selects %q, want %q", tt.line, tt.startCol, tt.endCol, got, tt.want)
+			}
+		})
+	}
+}
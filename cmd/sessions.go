@@ -0,0 +1,241 @@
+package cmd
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/plucury/chait/session"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the sessions command
+var (
+	sessionsMergeOut        string
+	sessionsMergeInterleave bool
+	sessionsExportOutput    string
+	sessionsExportFormat    string
+	sessionsImportAs        string
+)
+
+// sessionsCmd represents the sessions command
+var sessionsCmd = &cobra.Command{
+	Use:   "sessions",
+	Short: "Manage saved conversations",
+	Long: `Manage saved conversations, stored in ~/.local/share/chait/sessions (or
+$XDG_DATA_HOME/chait/sessions) unless conversations_dir is set.`,
+}
+
+// sessionsListCmd lists the saved conversations
+var sessionsListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List saved conversations",
+	Run: func(cmd *cobra.Command, args []string) {
+		sessions, err := session.List()
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(sessions) == 0 {
+			fmt.Println("No saved conversations.")
+			return
+		}
+		for _, s := range sessions {
+			fmt.Printf("%s  %s  %3d messages  %s\n", s.ID, s.Updated.Format("2006-01-02 15:04"), len(s.Messages), sessionTitle(s))
+		}
+	},
+}
+
+// sessionsMergeCmd merges two saved conversations into a new one
+var sessionsMergeCmd = &cobra.Command{
+	Use:   "merge <a> <b> --out <c>",
+	Short: "Merge two saved conversations",
+	Long: `Merge two saved conversations into a new one.
+
+By default the messages of <b> are appended after those of <a>. With --interleave,
+exchanges are ordered by time. A note marks where each block of messages came from.
+The new conversation gets a new ID and the --out name as its title, which opens it.
+
+Example:
+  chait sessions merge 20250101-101500 20250102-090000 --out research`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		if sessionsMergeOut == "" {
+			fmt.Fprintln(os.Stderr, "Error: merge requires an output session name (--out)")
+			os.Exit(2)
+		}
+		if _, err := session.Load(sessionsMergeOut); err == nil {
+			fmt.Fprintf(os.Stderr, "Error: session %s already exists\n", sessionsMergeOut)
+			os.Exit(2)
+		}
+
+		a, err := session.Load(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		b, err := session.Load(args[1])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		merged := session.Merge(a, b, sessionsMergeInterleave)
+		// The name is the title, the ID keeps the format of New so that it is a valid file name
+		merged.Title = sessionsMergeOut
+		if err := session.Save(merged); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Merged %s and %s into %s, %s (%d messages)\n", a.ID, b.ID, merged.Title, merged.ID, len(merged.Messages))
+	},
+}
+
+// sessionsResumeCmd continues a saved conversation in interactive mode
+var sessionsResumeCmd = &cobra.Command{
+	Use:   "resume <name-or-id>",
+	Short: "Continue a saved conversation in interactive mode",
+	Long: `Continue a saved conversation in interactive mode.
+
+Before resuming, chait shows how many tokens of context the conversation will send
+with each new message. If that exceeds resume_token_warning (default 4000), you can
+trim it to the most recent messages or have the model summarize the older ones.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := session.Load(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		if err := resumeSession(s, "", nil); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// sessionsExportCmd writes a saved conversation as JSON
+var sessionsExportCmd = &cobra.Command{
+	Use:   "export <name-or-id>",
+	Short: "Export a saved conversation as JSON or HTML",
+	Long: `Export a saved conversation as JSON, with every message, its system prompt, provider,
+model and parameters, to move it to another machine or feed it to other tools.
+
+With --format html, the conversation is written as a standalone web page with formatted
+messages and highlighted code blocks, to share it with people who do not use a terminal.
+
+Examples:
+  chait sessions export research -o research.json
+  chait sessions export research --format html -o research.html`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		if sessionsExportFormat != "json" && sessionsExportFormat != "html" {
+			fmt.Fprintf(os.Stderr, "Error: unknown format %s (expected json or html)\n", sessionsExportFormat)
+			os.Exit(2)
+		}
+		s, err := session.Load(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+
+		if sessionsExportOutput == "" || sessionsExportOutput == "-" {
+			if err := exportSession(s, os.Stdout); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			return
+		}
+		if err := util.CheckWriteAllowed("exporting conversations"); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		f, err := os.Create(sessionsExportOutput)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		defer f.Close()
+		if err := exportSession(s, f); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Fprintf(os.Stderr, "Exported %s (%d messages) to %s\n", s.ID, len(s.Messages), sessionsExportOutput)
+	},
+}
+
+// exportSession writes a conversation in the format given by --format
+func exportSession(s *session.Session, w io.Writer) error {
+	if sessionsExportFormat == "html" {
+		_, err := io.WriteString(w, renderHTML(s))
+		return err
+	}
+	return session.Export(s, w)
+}
+
+// sessionsImportCmd saves a conversation exported as JSON
+var sessionsImportCmd = &cobra.Command{
+	Use:   "import <file>",
+	Short: "Import a conversation exported as JSON",
+	Long: `Import a conversation written by chait sessions export, or read from standard input
+with "-". It keeps its ID unless --as gives another one.
+
+Example:
+  chait sessions import research.json --as research`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		in := os.Stdin
+		if args[0] != "-" {
+			f, err := os.Open(args[0])
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(2)
+			}
+			defer f.Close()
+			in = f
+		}
+
+		s, err := session.Import(in)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		if sessionsImportAs != "" {
+			s.ID = sessionsImportAs
+		}
+		if s.ID == "" {
+			s.ID = session.New().ID
+		}
+		if filepath.Base(s.ID) != s.ID || s.ID == "." || s.ID == ".." {
+			fmt.Fprintf(os.Stderr, "Error: invalid session ID %q, use --as to import it under another name\n", s.ID)
+			os.Exit(2)
+		}
+		if session.Exists(s.ID) {
+			fmt.Fprintf(os.Stderr, "Error: session %s already exists, use --as to import it under another name\n", s.ID)
+			os.Exit(2)
+		}
+		if err := session.Save(s); err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Printf("Imported %s (%d messages), continue it with chait --session %s\n", s.ID, len(s.Messages), s.ID)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(sessionsCmd)
+	sessionsCmd.AddCommand(sessionsListCmd)
+	sessionsCmd.AddCommand(sessionsMergeCmd)
+	sessionsCmd.AddCommand(sessionsResumeCmd)
+	sessionsCmd.AddCommand(sessionsExportCmd)
+	sessionsCmd.AddCommand(sessionsImportCmd)
+
+	sessionsMergeCmd.Flags().StringVar(&sessionsMergeOut, "out", "", "Title of the merged session, which can be used to open it")
+	sessionsMergeCmd.Flags().BoolVar(&sessionsMergeInterleave, "interleave", false, "Order exchanges by time instead of concatenating")
+	sessionsExportCmd.Flags().StringVarP(&sessionsExportOutput, "output", "o", "", "File to write the conversation to (default: standard output)")
+	sessionsExportCmd.Flags().StringVar(&sessionsExportFormat, "format", "json", "Export format: json or html")
+	sessionsImportCmd.Flags().StringVar(&sessionsImportAs, "as", "", "ID to import the conversation as")
+}
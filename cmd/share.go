@@ -0,0 +1,275 @@
+package cmd
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/x/term"
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/session"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/cobra"
+	"github.com/spf13/viper"
+)
+
+// gistAPI creates GitHub gists
+const gistAPI = "https://api.github.com/gists"
+
+// Flags for the share command
+var shareYes bool
+
+// shareCmd uploads a saved conversation to the configured paste service
+var shareCmd = &cobra.Command{
+	Use:   "share <name-or-id>",
+	Short: "Upload a saved conversation and print its URL",
+	Long: `Upload a saved conversation as Markdown to the configured paste service and print
+its URL. Secrets such as API keys are redacted and the notes of the conversation are left
+out. Nothing is uploaded before you confirm, unless --yes is given.
+
+The service is set with share.service: "gist" (the default) creates a secret GitHub gist
+with the token in share.github_token or GITHUB_TOKEN, any other value is the URL of a
+service the Markdown is posted to, which answers with the URL of the paste.`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		s, err := session.Load(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		if !shareYes {
+			if !term.IsTerminal(os.Stdin.Fd()) {
+				fmt.Fprintf(os.Stderr, "Error: confirm with --yes to share without a terminal\n")
+				os.Exit(1)
+			}
+			fmt.Printf("%s Continue? [y/N] ", shareSummary(s))
+			answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+				fmt.Println("Nothing was uploaded.")
+				return
+			}
+		}
+
+		url, err := shareSession(s)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(url)
+	},
+}
+
+// shareDoneMsg reports the upload of the conversation shared with ':share'
+type shareDoneMsg struct {
+	url string
+	err error
+}
+
+// shareDestination describes where shared conversations are uploaded
+func shareDestination() string {
+	service := viper.GetString("share.service")
+	if service == "" || service == "gist" {
+		if viper.GetBool("share.public") {
+			return "a public GitHub gist"
+		}
+		return "a secret GitHub gist"
+	}
+	return service
+}
+
+// shareSummary describes what sharing a conversation uploads, and where
+func shareSummary(s *session.Session) string {
+	return fmt.Sprintf("This uploads %d messages of %q, with secrets redacted, to %s.", len(s.Messages), s.DisplayTitle(), shareDestination())
+}
+
+// shareSession uploads a conversation as sanitized Markdown and returns its URL
+func shareSession(s *session.Session) (string, error) {
+	if err := util.CheckWriteAllowed("sharing conversations"); err != nil {
+		return "", err
+	}
+	content := redactSecrets(renderMarkdown(s))
+
+	service := viper.GetString("share.service")
+	if service == "" || service == "gist" {
+		return createGist(s.DisplayTitle(), "chait-"+s.ID+".md", content)
+	}
+	return postPaste(service, content)
+}
+
+// renderMarkdown renders a conversation as Markdown, without its notes
+func renderMarkdown(s *session.Session) string {
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "# %s\n\n", s.DisplayTitle())
+	details := []string{s.Created.Format("2006-01-02 15:04")}
+	if s.Model != "" {
+		details = append(details, strings.TrimPrefix(s.Provider+"/"+s.Model, "/"))
+	}
+	fmt.Fprintf(&sb, "_%s_\n", strings.Join(details, " · "))
+
+	for _, m := range s.Messages {
+		if m.Role == session.RoleNote {
+			fmt.Fprintf(&sb, "\n---\n\n_%s_\n", m.Content)
+			continue
+		}
+		fmt.Fprintf(&sb, "\n## %s\n\n%s\n", strings.ToUpper(m.Role[:1])+m.Role[1:], strings.TrimSpace(m.Content))
+	}
+	return sb.String()
+}
+
+// createGist creates a GitHub gist with a single file and returns its URL
+func createGist(description, filename, content string) (string, error) {
+	token := viper.GetString("share.github_token")
+	if token == "" {
+		token = os.Getenv("GITHUB_TOKEN")
+	}
+	if token == "" {
+		return "", fmt.Errorf("no GitHub token, set share.github_token or GITHUB_TOKEN to create gists")
+	}
+
+	body, err := json.Marshal(map[string]interface{}{
+		"description": description,
+		"public":      viper.GetBool("share.public"),
+		"files":       map[string]interface{}{filename: map[string]string{"content": content}},
+	})
+	if err != nil {
+		return "", fmt.Errorf("error encoding gist: %v", err)
+	}
+	req, err := http.NewRequest(http.MethodPost, gistAPI, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+
+	respBody, err := doShareRequest(req)
+	if err != nil {
+		return "", err
+	}
+	var gist struct {
+		HTMLURL string `json:"html_url"`
+	}
+	if err := json.Unmarshal(respBody, &gist); err != nil || gist.HTMLURL == "" {
+		return "", fmt.Errorf("unexpected response from GitHub: %s", truncateForLog(string(respBody)))
+	}
+	return gist.HTMLURL, nil
+}
+
+// postPaste posts Markdown to a paste service, which answers with the URL of the paste as
+// text or as the url field of a JSON object
+func postPaste(endpoint, content string) (string, error) {
+	req, err := http.NewRequest(http.MethodPost, endpoint, strings.NewReader(content))
+	if err != nil {
+		return "", fmt.Errorf("invalid share.service URL: %v", err)
+	}
+	req.Header.Set("Content-Type", "text/markdown; charset=utf-8")
+
+	respBody, err := doShareRequest(req)
+	if err != nil {
+		return "", err
+	}
+	var paste struct {
+		URL string `json:"url"`
+	}
+	if json.Unmarshal(respBody, &paste) == nil && paste.URL != "" {
+		return paste.URL, nil
+	}
+	if url := strings.TrimSpace(string(respBody)); strings.HasPrefix(url, "http") && !strings.ContainsAny(url, " \n") {
+		return url, nil
+	}
+	return "", fmt.Errorf("unexpected response from %s: %s", endpoint, truncateForLog(string(respBody)))
+}
+
+// doShareRequest sends a request to the paste service through the configured proxy and
+// returns the body of its response
+func doShareRequest(req *http.Request) ([]byte, error) {
+	client, err := util.HTTPClient(30 * time.Second)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("error uploading conversation: %v", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+	if err != nil {
+		return nil, fmt.Errorf("error reading response: %v", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return nil, fmt.Errorf("upload failed with status %d: %s", resp.StatusCode, truncateForLog(string(body)))
+	}
+	DebugLog("Shared conversation with %s", req.URL.Host)
+	return body, nil
+}
+
+// truncateForLog shortens a response body included in an error
+func truncateForLog(body string) string {
+	if runes := []rune(strings.TrimSpace(body)); len(runes) > 200 {
+		return string(runes[:200]) + "…"
+	}
+	return strings.TrimSpace(body)
+}
+
+// handleShareCommand asks to confirm the upload of the conversation with ':share'
+func (m *interactiveModel) handleShareCommand() {
+	s := m.shareableSession()
+	if len(s.Messages) == 0 {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "There is nothing to share yet."})
+		return
+	}
+	m.shareConfirm = true
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: shareSummary(s) + " Press 'y' to upload it, any other key to cancel.",
+	})
+	m.scrollToBottom()
+}
+
+// shareableSession returns the conversation as a session, the saved one if there is one
+func (m *interactiveModel) shareableSession() *session.Session {
+	s := session.New()
+	if m.session != nil {
+		copied := *m.session
+		s = &copied
+	}
+	s.Provider, s.Model = api.GetActiveProviderName(), api.GetCurrentModel()
+	s.Messages = sessionMessages(m.messages)
+	return s
+}
+
+// startShare uploads the conversation in the background
+func (m *interactiveModel) startShare() tea.Cmd {
+	s := m.shareableSession()
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "Uploading the conversation to " + shareDestination() + "..."})
+	m.scrollToBottom()
+	return func() tea.Msg {
+		url, err := shareSession(s)
+		return shareDoneMsg{url: url, err: err}
+	}
+}
+
+// handleShareDone shows the URL of the shared conversation
+func (m *interactiveModel) handleShareDone(msg shareDoneMsg) {
+	if msg.err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: msg.err.Error()})
+	} else {
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "Shared at " + msg.url})
+	}
+	m.scrollToBottom()
+}
+
+func init() {
+	rootCmd.AddCommand(shareCmd)
+
+	shareCmd.Flags().BoolVarP(&shareYes, "yes", "y", false, "Upload without asking for confirmation")
+}
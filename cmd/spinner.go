@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"fmt"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// spinnerFrames are the frames of the spinner shown until the first token arrives
+var spinnerFrames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// spinnerInterval is the time between two frames of the spinner
+const spinnerInterval = 100 * time.Millisecond
+
+// Spinner tick message, tagged with the request it animates so a request sent again
+// does not run two spinners at once
+type spinnerMsg struct {
+	since time.Time
+}
+
+// spinnerTick advances the spinner of the request sent at since
+func spinnerTick(since time.Time) tea.Cmd {
+	return tea.Tick(spinnerInterval, func(time.Time) tea.Msg {
+		return spinnerMsg{since: since}
+	})
+}
+
+// startWaiting starts the spinner of a request that was just sent. With reduce_motion
+// it does not spin and the elapsed time is refreshed by the cursor blink ticks instead.
+func (m *interactiveModel) startWaiting() tea.Cmd {
+	m.waitingSince = time.Now()
+	m.spinnerFrame = 0
+	if reduceMotion() {
+		return nil
+	}
+	return spinnerTick(m.waitingSince)
+}
+
+// waiting returns true while the last request has not received any content yet
+func (m interactiveModel) waiting() bool {
+	last := len(m.messages) - 1
+	return !m.enableInput && last >= 0 && m.messages[last].Type == MessageTypeAssistant && m.messages[last].Content == ""
+}
+
+// handleSpinner advances the spinner, it stops ticking once content arrives
+func (m *interactiveModel) handleSpinner(msg spinnerMsg) tea.Cmd {
+	if !msg.since.Equal(m.waitingSince) || !m.waiting() {
+		return nil
+	}
+	m.spinnerFrame = (m.spinnerFrame + 1) % len(spinnerFrames)
+	return spinnerTick(msg.since)
+}
+
+// waitingIndicator replaces the empty response until its first token arrives, with the
+// time elapsed since the request was sent
+func (m interactiveModel) waitingIndicator() string {
+	elapsed := formatElapsed(time.Since(m.waitingSince))
+	if reduceMotion() {
+		return fmt.Sprintf("Waiting for the response… %s", elapsed)
+	}
+	return fmt.Sprintf("%s Waiting for the response… %s", spinnerFrames[m.spinnerFrame], elapsed)
+}
+
+// formatElapsed renders a duration in whole seconds, with minutes above one minute
+func formatElapsed(d time.Duration) string {
+	seconds := int(d.Seconds())
+	if seconds < 60 {
+		return fmt.Sprintf("%ds", seconds)
+	}
+	return fmt.Sprintf("%dm%02ds", seconds/60, seconds%60)
+}
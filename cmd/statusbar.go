@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/mattn/go-runewidth"
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/cost"
+	"github.com/spf13/viper"
+)
+
+// statusBarEnabled returns true unless the status bar is disabled in the configuration
+func statusBarEnabled() bool {
+	return !viper.IsSet("status_bar") || viper.GetBool("status_bar")
+}
+
+// withStatusBar adds the status bar to the last line of the terminal below a view, or
+// below the input in inline mode
+func (m interactiveModel) withStatusBar(view string, totalLines int) string {
+	if !statusBarEnabled() {
+		return view
+	}
+	view = strings.TrimSuffix(view, "\n")
+	if !m.inline {
+		view += strings.Repeat("\n", max(0, m.height-1-strings.Count(view, "\n")-1))
+	}
+	return view + "\n" + m.statusBar(totalLines)
+}
+
+// statusBar renders the provider, model and temperature in use, the tokens streamed or used
+// so far, the estimated cost of the conversation and the scroll position, on one line. The
+// position shows the last visible line and whether auto-scrolling is paused.
+func (m interactiveModel) statusBar(totalLines int) string {
+	parts := []string{
+		fmt.Sprintf("%s/%s", api.GetActiveProviderName(), api.GetCurrentModel()),
+		fmt.Sprintf("temp %.1f", api.GetCurrentTemperature()),
+	}
+
+	used, spent, priced := 0, 0.0, false
+	for _, msg := range m.messages {
+		if msg.Usage != nil {
+			used += msg.Usage.TotalTokens
+		}
+		if msg.Cost != nil && msg.Cost.Priced {
+			spent += msg.Cost.Cost
+			priced = true
+		}
+	}
+	if last := len(m.messages) - 1; !m.enableInput && last >= 0 && m.messages[last].Type == MessageTypeAssistant {
+		parts = append(parts, fmt.Sprintf("↓ ~%s tokens", formatCount(m.streamedTokens)))
+	} else if used > 0 {
+		parts = append(parts, fmt.Sprintf("%s tokens", formatCount(used)))
+	}
+	if priced {
+		parts = append(parts, "~"+cost.Format(spent))
+	}
+
+	left := " " + strings.Join(parts, " · ")
+	right := ""
+	if !m.inline {
+		right = scrollPosition(m.scrollPos, totalLines, m.height) + " "
+		if maxScroll(totalLines, m.height) > 0 {
+			// The last visible line, and whether new output scrolls the conversation
+			_, end := visibleRange(m.scrollPos, totalLines, m.height)
+			right = fmt.Sprintf("[%d/%d] %s", end, totalLines, right)
+			if !m.autoScrollBottom {
+				right = "auto-scroll off · " + right
+			}
+		}
+	}
+	width := max(m.width, 1)
+	left = runewidth.Truncate(left, max(0, width-runewidth.StringWidth(right)), "…")
+	gap := max(0, width-runewidth.StringWidth(left)-runewidth.StringWidth(right))
+	return statusBarStyle.Render(left + strings.Repeat(" ", gap) + right)
+}
+
+// scrollPosition describes the scroll position like vi: All when the whole conversation
+// is visible, Top, Bot, or the percentage of the conversation above the viewport
+func scrollPosition(pos, totalLines, height int) string {
+	bottom := maxScroll(totalLines, height)
+	switch {
+	case bottom == 0:
+		return "All"
+	case pos <= 0:
+		return "Top"
+	case pos >= bottom:
+		return "Bot"
+	}
+	return fmt.Sprintf("%d%%", pos*100/bottom)
+}
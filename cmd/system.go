@@ -0,0 +1,196 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/plucury/chait/api"
+	"github.com/spf13/viper"
+)
+
+// defaultSystemPrompt starts conversations when no system_messages are configured
+const defaultSystemPrompt = "You are a helpful assistant."
+
+// isInstruction returns true for the system-level messages sent before the conversation
+func isInstruction(t MessageType) bool {
+	return t == MessageTypeSystem || t == MessageTypeDeveloper
+}
+
+// systemMessages returns the instructions starting a new conversation: the system prompt
+// of the persona in use, the system_messages setting in order, or the default system
+// prompt. Entries that cannot be used are skipped and described in problems.
+func systemMessages() (messages []Message, problems []string) {
+	if personaSystemPrompt != "" {
+		return []Message{{Type: MessageTypeSystem, Content: personaSystemPrompt}}, nil
+	}
+	if !viper.IsSet("system_messages") {
+		return []Message{{Type: MessageTypeSystem, Content: defaultSystemPrompt}}, nil
+	}
+
+	entries, ok := viper.Get("system_messages").([]interface{})
+	if !ok {
+		// A single string is a single system message
+		if text := viper.GetString("system_messages"); text != "" {
+			return []Message{{Type: MessageTypeSystem, Content: text}}, nil
+		}
+		return nil, []string{"system_messages: expected a list of messages"}
+	}
+	for i, entry := range entries {
+		msg, err := parseSystemMessage(entry)
+		if err != nil {
+			problems = append(problems, fmt.Sprintf("system_messages[%d]: %v", i, err))
+			continue
+		}
+		messages = append(messages, msg)
+	}
+	return messages, problems
+}
+
+// parseSystemMessage converts an entry of system_messages: a string, or an object with a
+// role (system or developer) and either the content or a file to read it from
+func parseSystemMessage(entry interface{}) (Message, error) {
+	if text, ok := entry.(string); ok {
+		return Message{Type: MessageTypeSystem, Content: text}, nil
+	}
+	fields, ok := entry.(map[string]interface{})
+	if !ok {
+		return Message{}, fmt.Errorf("expected a string or an object with role and content or file")
+	}
+
+	msg := Message{Type: MessageTypeSystem}
+	switch role, _ := fields["role"].(string); role {
+	case "", "system":
+	case "developer":
+		msg.Type = MessageTypeDeveloper
+	default:
+		return Message{}, fmt.Errorf("unknown role %q (expected system or developer)", role)
+	}
+
+	content, _ := fields["content"].(string)
+	if file, _ := fields["file"].(string); file != "" {
+		// Relative paths are resolved from the current directory, for project instructions
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return Message{}, err
+		}
+		content = string(data)
+	}
+	msg.Content = strings.TrimSpace(content)
+	if msg.Content == "" {
+		return Message{}, fmt.Errorf("empty message")
+	}
+	return msg, nil
+}
+
+// systemMessagesWithProblems returns the instructions starting a new conversation, followed
+// by an error message describing the entries that were skipped
+func systemMessagesWithProblems() []Message {
+	messages, problems := systemMessages()
+	if len(problems) > 0 {
+		messages = append(messages, Message{
+			Type:    MessageTypeError,
+			Content: "Some system messages were skipped:\n- " + strings.Join(problems, "\n- "),
+		})
+	}
+	return messages
+}
+
+// withSystemMessages starts a resumed conversation without system messages with the
+// configured ones
+func withSystemMessages(messages []Message) []Message {
+	for _, msg := range messages {
+		if isInstruction(msg.Type) {
+			return messages
+		}
+	}
+	return append(systemMessagesWithProblems(), messages...)
+}
+
+// systemChatMessages returns the configured instructions for a single request, printing the
+// skipped entries as warnings, and nothing when neither system_messages nor a persona is set
+func systemChatMessages() []api.ChatMessage {
+	if !viper.IsSet("system_messages") && personaSystemPrompt == "" {
+		return nil
+	}
+	messages, problems := systemMessages()
+	for _, problem := range problems {
+		fmt.Fprintf(os.Stderr, "Warning: %s\n", problem)
+	}
+	chatMessages := make([]api.ChatMessage, len(messages))
+	for i, msg := range messages {
+		chatMessages[i] = msg.ToChatMessage()
+	}
+	return chatMessages
+}
+
+// systemPromptIndex returns the index of the system prompt of the conversation, its first
+// system message, or -1
+func (m interactiveModel) systemPromptIndex() int {
+	for i, msg := range m.messages {
+		if msg.Type == MessageTypeSystem {
+			return i
+		}
+	}
+	return -1
+}
+
+// handleSystemCommand edits the system prompt in the input with ':s', or replaces it with
+// ':s <prompt>'
+func (m *interactiveModel) handleSystemCommand(args []string) {
+	if len(args) > 0 {
+		m.setSystemPrompt(strings.Join(args, " "))
+		return
+	}
+	prompt := ""
+	if i := m.systemPromptIndex(); i >= 0 {
+		prompt = m.messages[i].Content
+	}
+	m.systemPromptEditMode = true
+	m.inputDraft = m.input
+	m.input = []rune(prompt)
+	m.cursor = len(m.input)
+}
+
+// stopSystemPromptEdit leaves the system prompt editor and restores the text typed before
+func (m *interactiveModel) stopSystemPromptEdit() {
+	m.systemPromptEditMode = false
+	m.input = m.inputDraft
+	m.cursor = len(m.input)
+	m.inputDraft = nil
+}
+
+// saveSystemPrompt replaces the system prompt with the text of the editor
+func (m *interactiveModel) saveSystemPrompt() {
+	prompt := string(m.input)
+	m.stopSystemPromptEdit()
+	m.setSystemPrompt(prompt)
+}
+
+// setSystemPrompt replaces the system prompt of the conversation, sent with the next
+// messages. Without one, the prompt starts the conversation, an empty prompt removes it.
+func (m *interactiveModel) setSystemPrompt(prompt string) {
+	prompt = strings.TrimSpace(prompt)
+	i := m.systemPromptIndex()
+	switch {
+	case prompt == "" && i < 0:
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "The conversation has no system prompt."})
+		return
+	case prompt == "":
+		m.messages = append(m.messages[:i], m.messages[i+1:]...)
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "System prompt removed."})
+		return
+	}
+	m.replaceSystemPrompt(prompt)
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "System prompt updated, it applies to the next messages."})
+}
+
+// replaceSystemPrompt replaces the system prompt of the conversation, or starts the
+// conversation with it
+func (m *interactiveModel) replaceSystemPrompt(prompt string) {
+	if i := m.systemPromptIndex(); i >= 0 {
+		m.messages[i].Content = prompt
+		return
+	}
+	m.messages = append([]Message{{Type: MessageTypeSystem, Content: prompt}}, m.messages...)
+}
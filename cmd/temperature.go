@@ -0,0 +1,76 @@
+package cmd
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/plucury/chait/api"
+)
+
+// customTemperature is the value of the last option of the temperature selector, which
+// asks for a temperature in the input instead of applying a preset
+const customTemperature = "custom"
+
+// customTemperatureOption returns the option asking for another temperature, showing the
+// current one when it is not a preset
+func customTemperatureOption(current float64, preset bool) selectorOption {
+	name := "Custom… - Enter another temperature"
+	if !preset {
+		name = fmt.Sprintf("Custom (%s) - Enter another temperature", strconv.FormatFloat(current, 'f', -1, 64))
+	}
+	return selectorOption{name: name, value: customTemperature}
+}
+
+// startTemperatureInput asks for a temperature in the input, the text typed so far is kept
+// until it is entered or canceled
+func (m *interactiveModel) startTemperatureInput() {
+	m.temperatureInputMode = true
+	m.inputDraft = m.input
+	m.input = []rune(strconv.FormatFloat(api.GetCurrentTemperature(), 'f', -1, 64))
+	m.cursor = len(m.input)
+}
+
+// stopTemperatureInput leaves the temperature input and restores the text typed before
+func (m *interactiveModel) stopTemperatureInput() {
+	m.temperatureInputMode = false
+	m.input = m.inputDraft
+	m.cursor = len(m.input)
+	m.inputDraft = nil
+}
+
+// cancelTemperatureInput leaves the temperature input with esc, keeping the temperature
+func (m *interactiveModel) cancelTemperatureInput() {
+	m.stopTemperatureInput()
+	m.resendOnTemperatureSelect = false
+}
+
+// enterCustomTemperature sets the temperature typed in the input. A value that is not a
+// number or out of the range of the provider is reported and can be corrected.
+func (m *interactiveModel) enterCustomTemperature() tea.Cmd {
+	text := strings.TrimSpace(string(m.input))
+	temperature, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("Invalid temperature %q, enter a number such as 0.7", text),
+		})
+		return nil
+	}
+	if err := api.SetProviderTemperature(api.GetActiveProvider(), temperature); err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+		return nil
+	}
+	m.stopTemperatureInput()
+	refreshConfig(m)
+	m.messages = append(m.messages, Message{
+		Type:    MessageTypeChait,
+		Content: fmt.Sprintf("Temperature set to %s", strconv.FormatFloat(temperature, 'f', -1, 64)),
+	})
+	if m.resendOnTemperatureSelect {
+		m.resendOnTemperatureSelect = false
+		return m.resendLastPrompt()
+	}
+	return nil
+}
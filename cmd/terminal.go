@@ -0,0 +1,126 @@
+package cmd
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
+)
+
+// terminalCapabilities describes the features supported by a terminal
+type terminalCapabilities struct {
+	Terminal       string    `json:"terminal"`
+	TTY            bool      `json:"tty"`
+	Mouse          bool      `json:"mouse"`
+	TrueColor      bool      `json:"true_color"`
+	OSC52          bool      `json:"osc52"`
+	AltScreen      bool      `json:"alt_screen"`
+	BracketedPaste bool      `json:"bracketed_paste"`
+	Graphics       string    `json:"graphics,omitempty"` // Image protocol: "kitty", "iterm2", "sixel" or empty
+	Detected       time.Time `json:"detected"`
+}
+
+// terminalID identifies the current terminal, e.g. "iTerm.app", "xterm-kitty" or "tmux/xterm-256color"
+func terminalID() string {
+	id := os.Getenv("TERM_PROGRAM")
+	if id == "" || id == "tmux" {
+		id = os.Getenv("TERM")
+	}
+	if id == "" {
+		id = "unknown"
+	}
+	if os.Getenv("TMUX") != "" && !strings.HasPrefix(id, "tmux") {
+		id = "tmux/" + id
+	}
+	return id
+}
+
+// detectTerminalCapabilities guesses the features of the current terminal from its environment
+func detectTerminalCapabilities() terminalCapabilities {
+	termName := os.Getenv("TERM")
+	program := os.Getenv("TERM_PROGRAM")
+	colorTerm := strings.ToLower(os.Getenv("COLORTERM"))
+
+	caps := terminalCapabilities{
+		Terminal: terminalID(),
+		TTY:      term.IsTerminal(os.Stdout.Fd()) && term.IsTerminal(os.Stdin.Fd()),
+		Detected: time.Now(),
+	}
+
+	// Anything but the dumb terminal and the Linux console speaks the xterm control sequences
+	xtermCompatible := termName != "" && termName != "dumb" && termName != "linux"
+	caps.Mouse = xtermCompatible
+	caps.AltScreen = xtermCompatible
+	caps.BracketedPaste = xtermCompatible
+
+	caps.TrueColor = colorTerm == "truecolor" || colorTerm == "24bit" ||
+		strings.Contains(termName, "direct") || termName == "xterm-kitty" ||
+		program == "iTerm.app" || program == "WezTerm" || program == "vscode" ||
+		os.Getenv("WT_SESSION") != ""
+
+	switch {
+	case termName == "xterm-kitty" || os.Getenv("KITTY_WINDOW_ID") != "":
+		caps.OSC52, caps.Graphics = true, "kitty"
+	case program == "WezTerm":
+		caps.OSC52, caps.Graphics = true, "kitty"
+	case program == "iTerm.app":
+		caps.OSC52, caps.Graphics = true, "iterm2"
+	case termName == "foot" || strings.HasPrefix(termName, "foot-") || strings.Contains(termName, "mlterm"):
+		caps.OSC52, caps.Graphics = true, "sixel"
+	case termName == "alacritty" || program == "vscode" || os.Getenv("WT_SESSION") != "":
+		caps.OSC52 = true
+	case program == "Apple_Terminal":
+		caps.OSC52 = false
+	}
+
+	// tmux does not pass image protocols through without extra configuration
+	if os.Getenv("TMUX") != "" {
+		caps.Graphics = ""
+	}
+
+	return caps
+}
+
+// terminalCapabilitiesPath returns the file where detected terminal capabilities are recorded
+func terminalCapabilitiesPath() string {
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "terminals.json")
+}
+
+// loadRecordedTerminalCapabilities returns the capabilities recorded for the current terminal by "chait doctor --terminal"
+func loadRecordedTerminalCapabilities() (terminalCapabilities, bool) {
+	data, err := os.ReadFile(terminalCapabilitiesPath())
+	if err != nil {
+		return terminalCapabilities{}, false
+	}
+	var recorded map[string]terminalCapabilities
+	if err := json.Unmarshal(data, &recorded); err != nil {
+		util.WarnLog(util.ModuleTUI, "Ignoring invalid %s: %v", terminalCapabilitiesPath(), err)
+		return terminalCapabilities{}, false
+	}
+	caps, ok := recorded[terminalID()]
+	return caps, ok
+}
+
+// recordTerminalCapabilities saves the capabilities of the current terminal for later sessions
+func recordTerminalCapabilities(caps terminalCapabilities) error {
+	if err := util.CheckWriteAllowed("recording terminal capabilities"); err != nil {
+		return err
+	}
+
+	recorded := map[string]terminalCapabilities{}
+	if data, err := os.ReadFile(terminalCapabilitiesPath()); err == nil {
+		_ = json.Unmarshal(data, &recorded)
+	}
+	recorded[caps.Terminal] = caps
+
+	data, err := json.MarshalIndent(recorded, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(terminalCapabilitiesPath(), data, 0600)
+}
@@ -0,0 +1,315 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/term"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
+)
+
+// defaultTheme is the theme of dark backgrounds, used unless theme is set
+const defaultTheme = "default"
+
+// Themes picked by theme: auto, the default, depending on the terminal background
+const (
+	autoTheme  = "auto"
+	lightTheme = "light"
+)
+
+// theme holds the colors of interactive mode, as hex or ANSI colors. An empty color
+// leaves the text in the color of the terminal, an empty code style disables syntax
+// highlighting unless code_theme is set.
+type theme struct {
+	User                    string
+	Assistant               string
+	System                  string
+	Chait                   string
+	Error                   string
+	Usage                   string
+	StatusBar               string
+	StatusBarBackground     string
+	Overlay                 string
+	OverlayHeader           string
+	OverlayHeaderBackground string
+	OverlayInput            string
+	Match                   string
+	MatchBackground         string
+	Code                    string // Chroma style of code blocks
+}
+
+// themes are the built-in themes selected with theme or ':theme'
+var themes = map[string]theme{
+	defaultTheme: {
+		User:                    "#5e9aa4",
+		Assistant:               "#5ea46b",
+		System:                  "#87CEEB",
+		Chait:                   "#D3D3D3",
+		Error:                   "#a45e8b",
+		Usage:                   "#808080",
+		StatusBar:               "#D3D3D3",
+		StatusBarBackground:     "#3a3a3a",
+		Overlay:                 "#e5c07b",
+		OverlayHeader:           "#000000",
+		OverlayHeaderBackground: "#e5c07b",
+		OverlayInput:            "#c678dd",
+		Match:                   "0",
+		MatchBackground:         "11",
+		Code:                    defaultCodeTheme,
+	},
+	lightTheme: {
+		User:                    "#1f6f8b",
+		Assistant:               "#2e7d32",
+		System:                  "#0277bd",
+		Chait:                   "#555555",
+		Error:                   "#a4286a",
+		Usage:                   "#6c6c6c",
+		StatusBar:               "#303030",
+		StatusBarBackground:     "#d7d7d7",
+		Overlay:                 "#8a6d00",
+		OverlayHeader:           "#ffffff",
+		OverlayHeaderBackground: "#8a6d00",
+		OverlayInput:            "#7b1fa2",
+		Match:                   "#000000",
+		MatchBackground:         "#ffd75f",
+		Code:                    "github",
+	},
+	"solarized": {
+		User:                    "#268bd2",
+		Assistant:               "#859900",
+		System:                  "#2aa198",
+		Chait:                   "#93a1a1",
+		Error:                   "#dc322f",
+		Usage:                   "#586e75",
+		StatusBar:               "#93a1a1",
+		StatusBarBackground:     "#073642",
+		Overlay:                 "#b58900",
+		OverlayHeader:           "#002b36",
+		OverlayHeaderBackground: "#b58900",
+		OverlayInput:            "#6c71c4",
+		Match:                   "#002b36",
+		MatchBackground:         "#b58900",
+		Code:                    "solarized-dark",
+	},
+	"gruvbox": {
+		User:                    "#83a598",
+		Assistant:               "#b8bb26",
+		System:                  "#8ec07c",
+		Chait:                   "#d5c4a1",
+		Error:                   "#fb4934",
+		Usage:                   "#928374",
+		StatusBar:               "#ebdbb2",
+		StatusBarBackground:     "#3c3836",
+		Overlay:                 "#fabd2f",
+		OverlayHeader:           "#282828",
+		OverlayHeaderBackground: "#fabd2f",
+		OverlayInput:            "#d3869b",
+		Match:                   "#282828",
+		MatchBackground:         "#fe8019",
+		Code:                    "gruvbox",
+	},
+	// No colors at all, the status bar, headers and matches are reversed instead
+	"none": {},
+}
+
+// colors maps the keys of theme_colors to the colors of the theme they override
+func (t *theme) colors() map[string]*string {
+	return map[string]*string{
+		"user":                      &t.User,
+		"assistant":                 &t.Assistant,
+		"system":                    &t.System,
+		"chait":                     &t.Chait,
+		"error":                     &t.Error,
+		"usage":                     &t.Usage,
+		"status_bar":                &t.StatusBar,
+		"status_bar_background":     &t.StatusBarBackground,
+		"overlay":                   &t.Overlay,
+		"overlay_header":            &t.OverlayHeader,
+		"overlay_header_background": &t.OverlayHeaderBackground,
+		"overlay_input":             &t.OverlayInput,
+		"match":                     &t.Match,
+		"match_background":          &t.MatchBackground,
+		"code":                      &t.Code,
+	}
+}
+
+// activeTheme is the theme interactive mode is rendered with
+var activeTheme = themes[defaultTheme]
+
+// Styles of interactive mode, set from the active theme
+var (
+	userStyle            lipgloss.Style
+	assistantStyle       lipgloss.Style
+	systemStyle          lipgloss.Style
+	chaitStyle           lipgloss.Style
+	errorStyle           lipgloss.Style
+	usageStyle           lipgloss.Style
+	statusBarStyle       lipgloss.Style // Style of the status bar at the bottom of interactive mode
+	overlayHeaderStyle   lipgloss.Style // Styles of the debug overlay, one color per region
+	overlayViewportStyle lipgloss.Style
+	overlayInputStyle    lipgloss.Style
+	currentMatchStyle    lipgloss.Style // Match the viewport was scrolled to, the others are reversed
+)
+
+func init() {
+	applyTheme(activeTheme)
+}
+
+// themeNames returns auto and the names of the built-in themes in alphabetical order
+func themeNames() []string {
+	names := make([]string, 0, len(themes))
+	for name := range themes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return append([]string{autoTheme}, names...)
+}
+
+// darkBackground is detected once, asking the terminal while it would not answer to
+// bubbletea instead
+var darkBackground = sync.OnceValue(func() bool {
+	switch viper.GetString("background") {
+	case "dark":
+		return true
+	case "light":
+		return false
+	}
+	if !term.IsTerminal(os.Stdout.Fd()) || !term.IsTerminal(os.Stdin.Fd()) {
+		return true
+	}
+	dark := lipgloss.HasDarkBackground()
+	util.DebugLog(util.ModuleTUI, "Detected a dark terminal background: %v", dark)
+	return dark
+})
+
+// autoThemeName returns the theme of the terminal background
+func autoThemeName() string {
+	if darkBackground() {
+		return defaultTheme
+	}
+	return lightTheme
+}
+
+// foreground returns a style with the given text color, or no color if it is empty
+func foreground(color string) lipgloss.Style {
+	style := lipgloss.NewStyle()
+	if color != "" {
+		style = style.Foreground(lipgloss.Color(color))
+	}
+	return style
+}
+
+// colored returns a style with the given text and background colors, reversed if there
+// is no background color
+func colored(color, background string) lipgloss.Style {
+	if background == "" {
+		return foreground(color).Reverse(true)
+	}
+	return foreground(color).Background(lipgloss.Color(background))
+}
+
+// applyTheme sets the styles of interactive mode from a theme
+func applyTheme(t theme) {
+	activeTheme = t
+	userStyle = foreground(t.User)
+	assistantStyle = foreground(t.Assistant)
+	systemStyle = foreground(t.System)
+	chaitStyle = foreground(t.Chait)
+	errorStyle = foreground(t.Error)
+	usageStyle = foreground(t.Usage).Faint(true)
+	statusBarStyle = colored(t.StatusBar, t.StatusBarBackground)
+	overlayHeaderStyle = colored(t.OverlayHeader, t.OverlayHeaderBackground)
+	overlayViewportStyle = foreground(t.Overlay)
+	overlayInputStyle = foreground(t.OverlayInput)
+	currentMatchStyle = colored(t.Match, t.MatchBackground)
+	if t.MatchBackground == "" {
+		// Other matches are reversed already
+		currentMatchStyle = currentMatchStyle.Bold(true).Underline(true)
+	}
+	// Highlighted lines hold the colors of the previous theme
+	highlightCache = map[string]string{}
+}
+
+// configuredTheme returns the theme named by theme, with the colors of theme_colors
+// replacing its own
+func configuredTheme(name string) (theme, error) {
+	if name == "" || strings.EqualFold(name, autoTheme) {
+		name = autoThemeName()
+	}
+	t, ok := themes[strings.ToLower(name)]
+	if !ok {
+		return themes[autoThemeName()], fmt.Errorf("unknown theme %q (expected one of: %s)", name, strings.Join(themeNames(), ", "))
+	}
+	colors := t.colors()
+	var unknown []string
+	for key, value := range viper.GetStringMapString("theme_colors") {
+		color, ok := colors[key]
+		if !ok {
+			unknown = append(unknown, key)
+			continue
+		}
+		*color = value
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return t, fmt.Errorf("unknown theme_colors %s", strings.Join(unknown, ", "))
+	}
+	return t, nil
+}
+
+// loadTheme applies the theme of the configuration, the theme of the terminal background
+// replaces an unknown one
+func loadTheme() error {
+	t, err := configuredTheme(viper.GetString("theme"))
+	applyTheme(t)
+	return err
+}
+
+// handleThemeCommand lists the themes with ':theme', or switches to the given one and
+// saves it in the configuration
+func (m *interactiveModel) handleThemeCommand(args []string) {
+	current := viper.GetString("theme")
+	if current == "" {
+		current = autoTheme
+	}
+	if len(args) == 0 {
+		var sb strings.Builder
+		sb.WriteString("Themes (:theme <name> switches):")
+		for _, name := range themeNames() {
+			marker := "  "
+			if name == strings.ToLower(current) {
+				marker = "* "
+			}
+			sb.WriteString("\n" + marker + name)
+		}
+		m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: sb.String()})
+		return
+	}
+
+	name := strings.ToLower(args[0])
+	if _, ok := themes[name]; !ok && name != autoTheme {
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeError,
+			Content: fmt.Sprintf("Unknown theme %q, expected one of: %s", args[0], strings.Join(themeNames(), ", ")),
+		})
+		return
+	}
+	t, err := configuredTheme(name)
+	applyTheme(t)
+	if err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: err.Error()})
+	}
+	viper.Set("theme", name)
+	if err := util.WriteConfig(); err != nil {
+		m.messages = append(m.messages, Message{Type: MessageTypeError, Content: fmt.Sprintf("Error saving the theme: %v", err)})
+	}
+	if name == autoTheme {
+		name = fmt.Sprintf("%s (%s)", autoTheme, autoThemeName())
+	}
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: fmt.Sprintf("Switched to the %s theme.", name)})
+}
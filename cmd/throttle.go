@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/plucury/chait/api/provider"
+	"github.com/spf13/viper"
+)
+
+// defaultStreamRenderInterval is the time during which streamed chunks are merged into a
+// single update, unless stream_render_interval_ms is set
+const defaultStreamRenderInterval = 40 * time.Millisecond
+
+// streamRenderInterval returns the time during which streamed chunks are merged, 0 renders
+// every chunk. It reads viper, so it is only called on the UI goroutine.
+func streamRenderInterval() time.Duration {
+	if !viper.IsSet("stream_render_interval_ms") {
+		return defaultStreamRenderInterval
+	}
+	return time.Duration(max(0, viper.GetInt("stream_render_interval_ms"))) * time.Millisecond
+}
+
+// mergeable returns true for the responses that only carry content
+func mergeable(resp provider.StreamResponse) bool {
+	return resp.Error == nil && resp.Status == "" && resp.AnsweredBy == ""
+}
+
+// mergeStreamResponses appends the content streamed during the render interval to a chunk,
+// so that fast providers update the view a few times per second instead of once per token.
+// A response that is not content ends the merge and is kept to be handled next.
+func mergeStreamResponses(respChan <-chan provider.StreamResponse, first provider.StreamResponse, interval time.Duration) streamResponseMsg {
+	msg := newStreamResponseMsg(respChan, first)
+	if interval <= 0 || first.Done || !mergeable(first) {
+		return msg
+	}
+
+	timer := time.NewTimer(interval)
+	defer timer.Stop()
+	for {
+		select {
+		case resp, ok := <-respChan:
+			if !ok {
+				msg.Done = true
+				return msg
+			}
+			if !mergeable(resp) {
+				msg.pending = &resp
+				return msg
+			}
+			msg.Content += resp.Content
+			if resp.Usage != nil {
+				msg.Usage = resp.Usage
+			}
+			if resp.Done {
+				msg.Done = true
+				return msg
+			}
+		case <-timer.C:
+			return msg
+		}
+	}
+}
+
+// continueStream reads the next response of a stream, after the one kept by the merge
+func continueStream(msg streamResponseMsg, interval time.Duration) tea.Cmd {
+	if msg.pending != nil {
+		next := newStreamResponseMsg(msg.source, *msg.pending)
+		return func() tea.Msg { return next }
+	}
+	return processStreamResponse(msg.source, interval)
+}
@@ -0,0 +1,95 @@
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/api/provider"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the transcribe command
+var (
+	transcribeModel    string
+	transcribeProvider string
+	transcribeLanguage string
+)
+
+// transcribeCmd represents the transcribe command
+var transcribeCmd = &cobra.Command{
+	Use:   "transcribe <file>",
+	Short: "Transcribe an audio file",
+	Long: `Transcribe an audio file (mp3, mp4, m4a, wav, webm, ogg or flac, up to 25 MB)
+with Whisper and print the text.
+
+Transcription is supported by the openai and groq providers. Without --provider, the
+active provider is used if it supports transcription, otherwise the first one with an
+API key.
+
+The transcript can be piped straight into a chat prompt.
+
+Examples:
+  chait transcribe meeting.m4a
+  chait transcribe -p groq --language de memo.ogg
+  chait transcribe meeting.m4a | chait "Summarize this meeting as action items"`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		p, err := transcriptionProvider(transcribeProvider)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+
+		audio, err := os.ReadFile(args[0])
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error reading audio file: %v\n", err)
+			os.Exit(1)
+		}
+
+		text, err := api.Transcribe(context.Background(), p, filepath.Base(args[0]), audio, transcribeModel, transcribeLanguage)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		fmt.Println(text)
+	},
+}
+
+// transcriptionProvider returns the named provider, or picks a ready provider that
+// supports transcription, preferring the active one
+func transcriptionProvider(name string) (provider.Provider, error) {
+	if name != "" {
+		p, ok := api.GetProvider(name)
+		if !ok {
+			return nil, fmt.Errorf("unknown provider: %s", name)
+		}
+		if !p.IsReady() {
+			return nil, fmt.Errorf("provider %s is not ready, please set its API key first", name)
+		}
+		return p, nil
+	}
+
+	if p := api.GetActiveProvider(); p.IsReady() {
+		if _, ok := p.(provider.Transcriber); ok {
+			return p, nil
+		}
+	}
+	for _, providerName := range api.GetTranscriptionProviderNames() {
+		if p, ok := api.GetProvider(providerName); ok && p.IsReady() {
+			return p, nil
+		}
+	}
+	return nil, fmt.Errorf("no provider supporting transcription is ready, set the API key of one of: %s", strings.Join(api.GetTranscriptionProviderNames(), ", "))
+}
+
+func init() {
+	rootCmd.AddCommand(transcribeCmd)
+
+	transcribeCmd.Flags().StringVarP(&transcribeModel, "model", "m", "", "Transcription model (default: the provider's Whisper model)")
+	transcribeCmd.Flags().StringVarP(&transcribeProvider, "provider", "p", "", "Provider to use: openai or groq")
+	transcribeCmd.Flags().StringVar(&transcribeLanguage, "language", "", "Language of the audio as an ISO-639-1 code, e.g. en (default: detected)")
+}
@@ -0,0 +1,63 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/spf13/viper"
+)
+
+// Values of the keep_transcript setting
+const (
+	keepTranscriptOff  = "off"
+	keepTranscriptLast = "last"
+	keepTranscriptAll  = "all"
+)
+
+// keepTranscript returns what is printed to the normal screen when interactive mode exits
+// the alternate screen: nothing, the last exchange or the whole conversation
+func keepTranscript() string {
+	switch mode := viper.GetString("keep_transcript"); mode {
+	case keepTranscriptLast, keepTranscriptAll:
+		return mode
+	case "", keepTranscriptOff:
+		return keepTranscriptOff
+	default:
+		DebugLog("Unknown keep_transcript value %q, expected off, last or all", mode)
+		return keepTranscriptOff
+	}
+}
+
+// printTranscript prints the conversation of the final model once the alternate screen is
+// closed, so that the answer is not lost with the UI
+func printTranscript(m interactiveModel) {
+	mode := keepTranscript()
+	if mode == keepTranscriptOff || !m.altScreen {
+		return
+	}
+
+	// The last exchange starts with the last message of the user
+	first := 0
+	if mode == keepTranscriptLast {
+		first = len(m.messages)
+		for i := len(m.messages) - 1; i >= 0; i-- {
+			if m.messages[i].Type == MessageTypeUser {
+				first = i
+				break
+			}
+		}
+	}
+
+	styled := term.IsTerminal(os.Stdout.Fd())
+	for _, line := range m.getFormattedMessageLines() {
+		if line.Index < first {
+			continue
+		}
+		if styled {
+			fmt.Println(renderLine(line))
+		} else {
+			fmt.Println(line.Content)
+		}
+	}
+}
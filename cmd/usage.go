@@ -0,0 +1,145 @@
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/cost"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the usage command
+var (
+	usageSince string
+	usageBy    string
+)
+
+// formatUsage renders the token usage of a response as a short footer
+func formatUsage(usage *api.Usage) string {
+	return fmt.Sprintf("tokens: %d prompt + %d completion = %d", usage.PromptTokens, usage.CompletionTokens, usage.TotalTokens)
+}
+
+// formatCost renders the estimated cost of a response, and of the whole conversation
+// when sessionCost is positive
+func formatCost(record cost.Record, sessionCost float64) string {
+	if !record.Priced {
+		return fmt.Sprintf("cost: unknown for %s", record.Model)
+	}
+	text := "cost: ~" + cost.Format(record.Cost)
+	if sessionCost > 0 {
+		text += fmt.Sprintf(" (session ~%s)", cost.Format(sessionCost))
+	}
+	return text
+}
+
+// recordUsage adds a finished request to the usage ledger and returns its record
+func recordUsage(providerName, model string, usage *api.Usage) cost.Record {
+	record := cost.NewRecord(providerName, model, usage)
+	if err := cost.Append(record); err != nil {
+		DebugLog("Error recording usage: %v", err)
+	}
+	return record
+}
+
+// usageCmd represents the usage command
+var usageCmd = &cobra.Command{
+	Use:   "usage",
+	Short: "Show token usage and estimated cost",
+	Long: `Show the token usage and estimated cost of past requests, aggregated per
+provider and model.
+
+Costs are estimated from the token usage reported by the provider and a built-in
+price table. Prices can be overridden or added with the "prices" setting:
+
+  "prices": {"gpt-4o": {"input": 2.5, "output": 10}}
+
+in USD per million tokens.
+
+Examples:
+  chait usage
+  chait usage --since 7d
+  chait usage --since 2025-01-01 --by month`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		since, err := parseSince(usageSince)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(2)
+		}
+		key, ok := usageKeys[usageBy]
+		if !ok {
+			fmt.Fprintf(os.Stderr, "Error: invalid grouping %q, use model, provider, day or month\n", usageBy)
+			os.Exit(2)
+		}
+
+		records, err := cost.Load(since)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		if len(records) == 0 {
+			fmt.Println("No usage recorded.")
+			return
+		}
+
+		summaries := cost.Summarize(records, key)
+		total := cost.Summarize(records, func(cost.Record) string { return "total" })[0]
+		width := len(total.Key)
+		for _, s := range summaries {
+			width = max(width, len(s.Key))
+		}
+
+		fmt.Printf("%-*s  %8s  %10s  %10s  %10s\n", width, strings.ToUpper(usageBy), "REQUESTS", "PROMPT", "COMPLETION", "COST")
+		unpriced := false
+		for _, s := range append(summaries, total) {
+			costText := cost.Format(s.Cost)
+			if s.Unpriced > 0 {
+				costText += "*"
+				unpriced = true
+			}
+			fmt.Printf("%-*s  %8d  %10d  %10d  %10s\n", width, s.Key, s.Requests, s.PromptTokens, s.CompletionTokens, costText)
+		}
+		if unpriced {
+			fmt.Println("\n* includes requests to models without a known price, which are not counted")
+		}
+	},
+}
+
+// usageKeys groups usage records for the --by flag
+var usageKeys = map[string]func(cost.Record) string{
+	"model":    func(r cost.Record) string { return r.Provider + "/" + r.Model },
+	"provider": func(r cost.Record) string { return r.Provider },
+	"day":      func(r cost.Record) string { return r.Time.Local().Format("2006-01-02") },
+	"month":    func(r cost.Record) string { return r.Time.Local().Format("2006-01") },
+}
+
+// parseSince parses a start time given as a number of days ("30d"), a duration ("12h")
+// or a date ("2025-01-01"). An empty value means all time.
+func parseSince(value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	if days, ok := strings.CutSuffix(value, "d"); ok {
+		if n, err := strconv.Atoi(days); err == nil && n >= 0 {
+			return time.Now().AddDate(0, 0, -n), nil
+		}
+	}
+	if d, err := time.ParseDuration(value); err == nil && d >= 0 {
+		return time.Now().Add(-d), nil
+	}
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("invalid --since value %q, use e.g. 7d, 12h or 2025-01-01", value)
+}
+
+func init() {
+	rootCmd.AddCommand(usageCmd)
+
+	usageCmd.Flags().StringVar(&usageSince, "since", "", "Only include requests since a number of days (7d), a duration (12h) or a date (2025-01-01)")
+	usageCmd.Flags().StringVar(&usageBy, "by", "model", "Group by model, provider, day or month")
+}
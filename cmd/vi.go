@@ -0,0 +1,129 @@
+package cmd
+
+import (
+	"unicode"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/spf13/viper"
+)
+
+// keymapVi is the keymap setting of the vi-style keys
+const keymapVi = "vi"
+
+// viKeymap returns true if the vi-style keys are enabled with keymap: vi
+func viKeymap() bool {
+	return viper.GetString("keymap") == keymapVi
+}
+
+// viNormalHint describes the keys of normal mode in place of the input hint
+const viNormalHint = "-- NORMAL -- i/a: insert, j/k: scroll, gg/G: top/bottom, ctrl+u/ctrl+d: half page"
+
+// handleViKey handles the keys of the vi keymap before the usual ones, it returns false for
+// the keys left to them. In insert mode the keys edit the input as usual and esc switches
+// to normal mode, where keys scroll the conversation and move in the input instead.
+func (m *interactiveModel) handleViKey(msg tea.KeyMsg) bool {
+	if m.apiKeyInputMode || m.temperatureInputMode || m.systemPromptEditMode || m.selectorActive() || msg.Paste {
+		return false
+	}
+	if !m.viNormal {
+		// Esc still cancels the response being streamed
+		if msg.Type == tea.KeyEsc && m.enableInput {
+			m.viNormal = true
+			m.viPending = ""
+			return true
+		}
+		return false
+	}
+
+	pending := m.viPending
+	m.viPending = ""
+	switch pending + msg.String() {
+	case "j", "down":
+		m.scrollDown(1)
+	case "k", "up":
+		m.scrollUp(1)
+		m.autoScrollBottom = false
+	case "g", "d":
+		m.viPending = msg.String()
+	case "gg":
+		m.scrollToTop()
+		m.autoScrollBottom = false
+	case "G":
+		m.scrollToBottom()
+		m.autoScrollBottom = true
+	case "ctrl+d":
+		m.scrollPageDown()
+	case "ctrl+u":
+		m.scrollPageUp()
+		m.autoScrollBottom = false
+	case "i":
+		m.viNormal = false
+	case "a":
+		m.cursor = min(m.cursor+1, len(m.input))
+		m.viNormal = false
+	case "I":
+		m.cursor = 0
+		m.viNormal = false
+	case "A":
+		m.cursor = len(m.input)
+		m.viNormal = false
+	case ":":
+		// Start typing a command, like the command line of vi
+		m.setInput(":")
+		m.viNormal = false
+	case "h", "left":
+		m.cursor = max(m.cursor-1, 0)
+	case "l", "right":
+		m.cursor = min(m.cursor+1, len(m.input))
+	case "0":
+		m.cursor = 0
+	case "$":
+		m.cursor = len(m.input)
+	case "w":
+		m.cursor = nextWordStart(m.input, m.cursor)
+	case "b":
+		m.cursor = previousWordStart(m.input, m.cursor)
+	case "x":
+		if m.cursor < len(m.input) {
+			m.deleteInput(m.cursor, m.cursor+1)
+		}
+	case "dd":
+		m.setInput("")
+	case "esc":
+		// Esc only cancels the response being streamed, ctrl+c exits
+		if !m.enableInput {
+			return false
+		}
+	case "/":
+		if m.inline {
+			return true
+		}
+		m.startSearch()
+	default:
+		// Other keys such as Enter, ctrl+c or pgup work as usual, letters are not typed
+		return msg.Type == tea.KeyRunes || msg.Type == tea.KeySpace
+	}
+	return true
+}
+
+// nextWordStart returns the position of the start of the word after the cursor
+func nextWordStart(text []rune, pos int) int {
+	for pos < len(text) && !unicode.IsSpace(text[pos]) {
+		pos++
+	}
+	for pos < len(text) && unicode.IsSpace(text[pos]) {
+		pos++
+	}
+	return pos
+}
+
+// previousWordStart returns the position of the start of the word before the cursor
+func previousWordStart(text []rune, pos int) int {
+	for pos > 0 && unicode.IsSpace(text[pos-1]) {
+		pos--
+	}
+	for pos > 0 && !unicode.IsSpace(text[pos-1]) {
+		pos--
+	}
+	return pos
+}
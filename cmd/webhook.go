@@ -0,0 +1,75 @@
+package cmd
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+// Flags for posting responses to a webhook
+var (
+	postToURL    string
+	postToFormat string
+)
+
+// webhookEnvelope is the JSON payload posted to a webhook with the final response
+type webhookEnvelope struct {
+	Provider  string    `json:"provider"`
+	Model     string    `json:"model"`
+	Prompt    string    `json:"prompt"`
+	Response  string    `json:"response"`
+	Timestamp time.Time `json:"timestamp"`
+}
+
+// slackMessage is the payload accepted by Slack incoming webhooks
+type slackMessage struct {
+	Text string `json:"text"`
+}
+
+// validateWebhookFormat returns an error unless the format is "json" or "slack", so that
+// --post-format is checked before the model is called
+func validateWebhookFormat(format string) error {
+	switch format {
+	case "", "json", "slack":
+		return nil
+	}
+	return fmt.Errorf("unknown webhook format %q (expected json or slack)", format)
+}
+
+// postToWebhook sends the envelope to the webhook URL in the given format ("json" or "slack")
+func postToWebhook(webhookURL, format string, envelope webhookEnvelope) error {
+	if err := validateWebhookFormat(format); err != nil {
+		return err
+	}
+	var payload interface{} = envelope
+	if format == "slack" {
+		payload = slackMessage{Text: envelope.Response}
+	}
+
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("error encoding webhook payload: %v", err)
+	}
+
+	client, err := util.HTTPClient(30 * time.Second)
+	if err != nil {
+		return err
+	}
+	resp, err := client.Post(webhookURL, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("error posting to webhook: %v", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("webhook returned status %d: %s", resp.StatusCode, string(respBody))
+	}
+
+	DebugLog("Posted response to webhook (%s format)", format)
+	return nil
+}
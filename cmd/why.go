@@ -0,0 +1,244 @@
+package cmd
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+	"github.com/plucury/chait/api"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/cobra"
+)
+
+// Flags for the why command
+var (
+	whyInit  string
+	whyRerun bool
+)
+
+// whyRerunEnv is set in the environment of the commands run again by --rerun, so that a
+// command calling chait why --rerun cannot run itself again
+const whyRerunEnv = "CHAIT_WHY_RERUN"
+
+// Largest part of the command output sent to the model, the end is kept since errors are
+// usually printed last
+const maxWhyOutput = 8000
+
+// Shell integration snippets exporting the last command and its exit status. They run before
+// each prompt, so `chait why` sees the command that ran before it.
+var whyShellSnippets = map[string]string{
+	"bash": `__chait_record_last_command() {
+  local exit_code=$?
+  export CHAIT_LAST_STATUS=$exit_code
+  export CHAIT_LAST_COMMAND="$(HISTTIMEFORMAT= history 1 | sed 's/^ *[0-9]* *//')"
+  return $exit_code
+}
+PROMPT_COMMAND="__chait_record_last_command${PROMPT_COMMAND:+;$PROMPT_COMMAND}"
+`,
+	"zsh": `__chait_preexec() { __chait_command=$1 }
+__chait_precmd() {
+  local exit_code=$?
+  export CHAIT_LAST_STATUS=$exit_code
+  export CHAIT_LAST_COMMAND=$__chait_command
+}
+autoload -Uz add-zsh-hook
+add-zsh-hook preexec __chait_preexec
+add-zsh-hook precmd __chait_precmd
+`,
+	"fish": `function __chait_record_last_command --on-event fish_postexec
+    set -gx CHAIT_LAST_STATUS $status
+    set -gx CHAIT_LAST_COMMAND $argv[1]
+end
+`,
+}
+
+// whyCmd represents the why command
+var whyCmd = &cobra.Command{
+	Use:   "why",
+	Short: "Explain why the last shell command failed",
+	Long: `Explain why the last shell command failed and suggest a fix.
+
+The last command and its exit status are recorded by a shell integration snippet.
+Add one of these lines to your shell configuration:
+
+  eval "$(chait why --init bash)"    # ~/.bashrc
+  eval "$(chait why --init zsh)"     # ~/.zshrc
+  chait why --init fish | source     # ~/.config/fish/config.fish
+
+The shell does not keep the output of commands. Pipe it to chait why, or use --rerun
+to run the command again, after confirmation, and capture its output (not in safe mode):
+
+  make 2>&1 | chait why
+  chait why --rerun`,
+	Args: cobra.NoArgs,
+	Run: func(cmd *cobra.Command, args []string) {
+		if whyInit != "" {
+			snippet, ok := whyShellSnippets[whyInit]
+			if !ok {
+				fmt.Fprintf(os.Stderr, "Error: unsupported shell %q, use bash, zsh or fish\n", whyInit)
+				os.Exit(2)
+			}
+			fmt.Print(snippet)
+			return
+		}
+
+		command := strings.TrimSpace(os.Getenv("CHAIT_LAST_COMMAND"))
+		status := os.Getenv("CHAIT_LAST_STATUS")
+		if command == "" {
+			fmt.Fprintln(os.Stderr, "Error: the last command is unknown, enable the shell integration first (see chait why --help)")
+			os.Exit(2)
+		}
+
+		var output string
+		stat, _ := os.Stdin.Stat()
+		if (stat.Mode() & os.ModeCharDevice) == 0 {
+			data, err := io.ReadAll(bufio.NewReader(os.Stdin))
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Error reading piped input: %v\n", err)
+				os.Exit(1)
+			}
+			output = string(data)
+		} else if whyRerun {
+			if err := checkRerun(command); err != nil {
+				fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+				os.Exit(1)
+			}
+			fmt.Fprintf(os.Stderr, "Run %s again? [y/N] ", command)
+			answer, err := bufio.NewReader(os.Stdin).ReadString('\n')
+			if err != nil || !strings.EqualFold(strings.TrimSpace(answer), "y") {
+				fmt.Fprintln(os.Stderr, "Nothing was run.")
+				return
+			}
+			output, status = rerunCommand(command)
+		}
+
+		if !api.GetActiveProvider().IsReady() {
+			fmt.Fprintf(os.Stderr, "Error: provider %s is not ready, please set its API key first\n", api.GetActiveProvider().GetName())
+			os.Exit(1)
+		}
+
+		messages := []api.ChatMessage{
+			{Role: "user", Content: buildWhyPrompt(command, status, output)},
+		}
+		if err := streamWhyResponse(messages); err != nil {
+			fmt.Fprintf(os.Stderr, "\nError: %v\n", err)
+			os.Exit(1)
+		}
+	},
+}
+
+// checkRerun refuses to run a command again in safe mode, without a terminal to confirm it,
+// from a command run by --rerun, or when it is chait itself
+func checkRerun(command string) error {
+	if util.IsSafeMode() {
+		return fmt.Errorf("--rerun runs a shell command, it is %w", util.ErrSafeMode)
+	}
+	if os.Getenv(whyRerunEnv) != "" {
+		return fmt.Errorf("--rerun cannot be used by a command run again by chait why")
+	}
+	if fields := strings.Fields(command); len(fields) > 0 && filepath.Base(fields[0]) == "chait" {
+		return fmt.Errorf("the last command is chait, it is not run again")
+	}
+	if !term.IsTerminal(os.Stdin.Fd()) {
+		return fmt.Errorf("--rerun asks for confirmation and needs a terminal, pipe the output to chait why instead")
+	}
+	return nil
+}
+
+// rerunCommand runs a command again in the user's shell and returns its combined output and
+// exit status
+func rerunCommand(command string) (string, string) {
+	shell := os.Getenv("SHELL")
+	if shell == "" {
+		shell = "/bin/sh"
+	}
+	cmd := exec.Command(shell, "-c", command)
+	cmd.Env = append(os.Environ(), whyRerunEnv+"=1")
+	out, err := cmd.CombinedOutput()
+	if exitErr, ok := err.(*exec.ExitError); ok {
+		return string(out), fmt.Sprint(exitErr.ExitCode())
+	} else if err != nil {
+		return string(out) + err.Error(), "unknown"
+	}
+	return string(out), "0"
+}
+
+// buildWhyPrompt asks the model to explain the failure of a command, with the secrets of the
+// command and its output redacted
+func buildWhyPrompt(command, status, output string) string {
+	command, output = redactSecrets(command), redactSecrets(output)
+	var sb strings.Builder
+	sb.WriteString("The following shell command failed. Explain briefly why it failed and how to fix it, ")
+	sb.WriteString("with the corrected command if there is one.\n\n")
+	fmt.Fprintf(&sb, "Shell: %s\nOS: %s\n", filepath.Base(os.Getenv("SHELL")), runtime.GOOS)
+	fmt.Fprintf(&sb, "Command: %s\n", command)
+	if status != "" {
+		fmt.Fprintf(&sb, "Exit status: %s\n", status)
+	}
+
+	output = strings.TrimSpace(output)
+	if output == "" {
+		sb.WriteString("Output: not captured\n")
+		return sb.String()
+	}
+	if len(output) > maxWhyOutput {
+		start := len(output) - maxWhyOutput
+		for start < len(output) && !utf8.RuneStart(output[start]) {
+			start++
+		}
+		output = "…" + output[start:]
+	}
+	fmt.Fprintf(&sb, "Output:\n```\n%s\n```\n", output)
+	return sb.String()
+}
+
+// streamWhyResponse prints the explanation as it arrives and records its usage
+func streamWhyResponse(messages []api.ChatMessage) error {
+	streamChan, err := api.SendStreamingChatRequest(context.Background(), messages)
+	if err != nil {
+		return err
+	}
+
+	var usage *api.Usage
+	p := api.GetActiveProvider()
+	answeredBy, answeredModel := p.GetName(), p.GetCurrentModel()
+	for streamResp := range streamChan {
+		if streamResp.Error != nil {
+			return streamResp.Error
+		}
+		if streamResp.Status != "" {
+			fmt.Fprintln(os.Stderr, api.FormatRetryStatus(streamResp.Status, streamResp.RetryAt))
+			continue
+		}
+		if streamResp.AnsweredBy != "" {
+			answeredBy, answeredModel = streamResp.AnsweredBy, streamResp.AnsweredModel
+			fmt.Fprintf(os.Stderr, "Answered by %s (%s)\n", answeredBy, answeredModel)
+			continue
+		}
+		if streamResp.Usage != nil {
+			usage = streamResp.Usage
+		}
+		fmt.Print(streamResp.Content)
+	}
+	fmt.Println()
+
+	if usage != nil {
+		recordUsage(answeredBy, answeredModel, usage)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(whyCmd)
+
+	whyCmd.Flags().StringVar(&whyInit, "init", "", "Print the shell integration snippet for bash, zsh or fish")
+	whyCmd.Flags().BoolVar(&whyRerun, "rerun", false, "Run the last command again to capture its output (disabled in safe mode)")
+}
@@ -0,0 +1,35 @@
+package cmd
+
+import "github.com/spf13/viper"
+
+// maxWidth returns the width the conversation wraps at on wider terminals, 0 for the
+// width of the terminal
+func maxWidth() int {
+	return max(0, viper.GetInt("max_width"))
+}
+
+// centerContent returns true if the conversation is centered when max_width is narrower
+// than the terminal
+func centerContent() bool {
+	return viper.GetBool("center_content")
+}
+
+// textWidth returns the width the conversation wraps at, left of the scrollbar
+func (m interactiveModel) textWidth() int {
+	width := m.width
+	if m.scrollbarShown() && width > 0 {
+		width--
+	}
+	if limit := maxWidth(); limit > 0 && width > limit {
+		return limit
+	}
+	return width
+}
+
+// textMargin returns the number of columns left of the conversation when it is centered
+func (m interactiveModel) textMargin() int {
+	if !centerContent() {
+		return 0
+	}
+	return (m.width - m.textWidth()) / 2
+}
@@ -0,0 +1,26 @@
+package cmd
+
+import (
+	"fmt"
+	"strings"
+)
+
+// handleCopyCommand copies the full text of the last response to the clipboard with ':y'
+func (m *interactiveModel) handleCopyCommand() {
+	for i := m.completeMessages() - 1; i >= 0; i-- {
+		msg := m.messages[i]
+		if msg.Type != MessageTypeAssistant || strings.TrimSpace(msg.Content) == "" {
+			continue
+		}
+		if err := copyToClipboard(msg.Content); err != nil {
+			m.messages = append(m.messages, Message{Type: MessageTypeError, Content: fmt.Sprintf("Error copying to the clipboard: %v", err)})
+			return
+		}
+		m.messages = append(m.messages, Message{
+			Type:    MessageTypeChait,
+			Content: fmt.Sprintf("Copied the last response (%s characters) to the clipboard", formatCount(len([]rune(msg.Content)))),
+		})
+		return
+	}
+	m.messages = append(m.messages, Message{Type: MessageTypeChait, Content: "There is no response to copy yet."})
+}
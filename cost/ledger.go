@@ -0,0 +1,139 @@
+package cost
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/session"
+	"github.com/plucury/chait/util"
+)
+
+// Record is the token usage and estimated cost of a single request
+type Record struct {
+	Time             time.Time `json:"time"`
+	Provider         string    `json:"provider"`
+	Model            string    `json:"model"`
+	PromptTokens     int       `json:"prompt_tokens"`
+	CompletionTokens int       `json:"completion_tokens"`
+	Cost             float64   `json:"cost"`
+	Priced           bool      `json:"priced"` // Whether the price of the model was known
+}
+
+// NewRecord creates the record of a request that just finished
+func NewRecord(providerName, model string, usage *provider.Usage) Record {
+	cost, priced := Estimate(model, usage)
+	return Record{
+		Time:             time.Now(),
+		Provider:         providerName,
+		Model:            model,
+		PromptTokens:     usage.PromptTokens,
+		CompletionTokens: usage.CompletionTokens,
+		Cost:             cost,
+		Priced:           priced,
+	}
+}
+
+// LedgerPath returns the file where the usage of every request is recorded
+func LedgerPath() string {
+	return filepath.Join(filepath.Dir(session.Dir()), "usage.jsonl")
+}
+
+// Append adds a record to the usage ledger
+func Append(r Record) error {
+	if err := util.CheckWriteAllowed("recording usage"); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(LedgerPath()), 0700); err != nil {
+		return fmt.Errorf("error creating data directory: %v", err)
+	}
+
+	data, err := json.Marshal(r)
+	if err != nil {
+		return fmt.Errorf("error encoding usage: %v", err)
+	}
+
+	file, err := os.OpenFile(LedgerPath(), os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error opening usage ledger: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(data, '\n')); err != nil {
+		return fmt.Errorf("error writing usage ledger: %v", err)
+	}
+	return nil
+}
+
+// Load returns the records of the ledger made at or after since, skipping malformed lines
+func Load(since time.Time) ([]Record, error) {
+	file, err := os.Open(LedgerPath())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading usage ledger: %v", err)
+	}
+	defer file.Close()
+
+	var records []Record
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var r Record
+		if err := json.Unmarshal(scanner.Bytes(), &r); err != nil {
+			util.DebugLog(util.ModuleConfig, "Skipping malformed usage record: %v", err)
+			continue
+		}
+		if r.Time.Before(since) {
+			continue
+		}
+		records = append(records, r)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading usage ledger: %v", err)
+	}
+	return records, nil
+}
+
+// Summary aggregates the records that share a key
+type Summary struct {
+	Key              string
+	Requests         int
+	PromptTokens     int
+	CompletionTokens int
+	Cost             float64
+	Unpriced         int // Requests to models without a known price
+}
+
+// Summarize groups records by the key returned for each of them, sorted by key
+func Summarize(records []Record, key func(Record) string) []Summary {
+	byKey := make(map[string]*Summary)
+	for _, r := range records {
+		k := key(r)
+		s, ok := byKey[k]
+		if !ok {
+			s = &Summary{Key: k}
+			byKey[k] = s
+		}
+		s.Requests++
+		s.PromptTokens += r.PromptTokens
+		s.CompletionTokens += r.CompletionTokens
+		s.Cost += r.Cost
+		if !r.Priced {
+			s.Unpriced++
+		}
+	}
+
+	summaries := make([]Summary, 0, len(byKey))
+	for _, s := range byKey {
+		summaries = append(summaries, *s)
+	}
+	sort.Slice(summaries, func(i, j int) bool {
+		return summaries[i].Key < summaries[j].Key
+	})
+	return summaries
+}
@@ -0,0 +1,147 @@
+package cost
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/plucury/chait/api/provider"
+	"github.com/spf13/viper"
+)
+
+// Price is the price of a model in USD per million tokens
+type Price struct {
+	Input  float64 `json:"input"`
+	Output float64 `json:"output"`
+}
+
+// prices lists the public list prices of the built-in models. They are estimates,
+// prices in CNY are converted at roughly 7.2 CNY per USD. Override or extend them
+// with the "prices" setting of the configuration.
+var prices = map[string]Price{
+	// OpenAI
+	"o1":          {15.00, 60.00},
+	"o3-mini":     {1.10, 4.40},
+	"gpt-4.5":     {75.00, 150.00},
+	"gpt-4o":      {2.50, 10.00},
+	"gpt-4o-mini": {0.15, 0.60},
+
+	// DeepSeek
+	"deepseek-chat":     {0.27, 1.10},
+	"deepseek-reasoner": {0.55, 2.19},
+
+	// Grok
+	"grok-2-1212": {2.00, 10.00},
+
+	// Perplexity
+	"sonar":               {1.00, 1.00},
+	"sonar-pro":           {3.00, 15.00},
+	"sonar-reasoning":     {1.00, 5.00},
+	"sonar-reasoning-pro": {2.00, 8.00},
+	"sonar-deep-research": {2.00, 8.00},
+
+	// Together
+	"meta-llama/Llama-3.3-70B-Instruct-Turbo":       {0.88, 0.88},
+	"meta-llama/Meta-Llama-3.1-8B-Instruct-Turbo":   {0.18, 0.18},
+	"meta-llama/Meta-Llama-3.1-405B-Instruct-Turbo": {3.50, 3.50},
+	"deepseek-ai/DeepSeek-V3":                       {1.25, 1.25},
+	"deepseek-ai/DeepSeek-R1":                       {3.00, 7.00},
+	"Qwen/Qwen2.5-72B-Instruct-Turbo":               {1.20, 1.20},
+	"Qwen/Qwen2.5-Coder-32B-Instruct":               {0.80, 0.80},
+	"mistralai/Mixtral-8x7B-Instruct-v0.1":          {0.60, 0.60},
+	"mistralai/Mistral-7B-Instruct-v0.3":            {0.20, 0.20},
+	"google/gemma-2-27b-it":                         {0.80, 0.80},
+
+	// Groq
+	"llama-3.3-70b-versatile":       {0.59, 0.79},
+	"llama-3.1-8b-instant":          {0.05, 0.08},
+	"gemma2-9b-it":                  {0.20, 0.20},
+	"deepseek-r1-distill-llama-70b": {0.75, 0.99},
+
+	// Moonshot (CNY 12/24/60 per million tokens)
+	"moonshot-v1-8k":   {1.67, 1.67},
+	"moonshot-v1-32k":  {3.33, 3.33},
+	"moonshot-v1-128k": {8.33, 8.33},
+
+	// Zhipu (CNY 5/0.5/10/1 per million tokens, glm-4-flash is free)
+	"glm-4-plus":  {0.69, 0.69},
+	"glm-4-air":   {0.07, 0.07},
+	"glm-4-airx":  {1.39, 1.39},
+	"glm-4-long":  {0.14, 0.14},
+	"glm-4-flash": {0, 0},
+}
+
+// PriceFor returns the price of a model, settings in the configuration take precedence
+// over the built-in table. Lookups ignore case.
+func PriceFor(model string) (Price, bool) {
+	if price, ok := configuredPrice(model); ok {
+		return price, true
+	}
+	for name, price := range prices {
+		if strings.EqualFold(name, model) {
+			return price, true
+		}
+	}
+	return Price{}, false
+}
+
+// configuredPrice looks up a model in the "prices" setting, which maps model names
+// to {"input": ..., "output": ...} in USD per million tokens
+func configuredPrice(model string) (Price, bool) {
+	// Viper lowercases keys, so match model names case-insensitively
+	for name, value := range viper.GetStringMap("prices") {
+		if !strings.EqualFold(name, model) {
+			continue
+		}
+		entry, ok := value.(map[string]interface{})
+		if !ok {
+			return Price{}, false
+		}
+		input, inputOK := toFloat(entry["input"])
+		output, outputOK := toFloat(entry["output"])
+		if !inputOK || !outputOK {
+			return Price{}, false
+		}
+		return Price{Input: input, Output: output}, true
+	}
+	return Price{}, false
+}
+
+// toFloat converts a number decoded from the configuration
+func toFloat(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case int:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	}
+	return 0, false
+}
+
+// Estimate returns the estimated cost in USD of a request with the given token usage,
+// false if the price of the model is unknown
+func Estimate(model string, usage *provider.Usage) (float64, bool) {
+	if usage == nil {
+		return 0, false
+	}
+	price, ok := PriceFor(model)
+	if !ok {
+		return 0, false
+	}
+	return (float64(usage.PromptTokens)*price.Input + float64(usage.CompletionTokens)*price.Output) / 1e6, true
+}
+
+// Format renders a cost in USD with enough precision for cheap requests
+func Format(cost float64) string {
+	switch {
+	case cost == 0:
+		return "$0"
+	case cost < 0.0001:
+		return "<$0.0001"
+	case cost < 0.01:
+		return fmt.Sprintf("$%.4f", cost)
+	default:
+		return fmt.Sprintf("$%.2f", cost)
+	}
+}
@@ -0,0 +1,258 @@
+//go:build e2e && linux
+
+// Package e2e drives the compiled chait binary against the mock provider, to catch
+// regressions in the wiring of the commands and of interactive mode. Run it with
+//
+//	go test -tags e2e ./e2e
+//
+// or make e2e.
+package e2e
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/charmbracelet/x/ansi"
+)
+
+// binary is the chait binary built with the mock provider by TestMain
+var binary string
+
+// Keys sent to interactive mode
+const (
+	keyEnter = "\r"
+	keyEsc   = "\x1b"
+	keyCtrlC = "\x03"
+)
+
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "chait-e2e")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	binary = filepath.Join(dir, "chait")
+	build := exec.Command("go", "build", "-tags", "e2e", "-o", binary, "..")
+	build.Stdout, build.Stderr = os.Stderr, os.Stderr
+	if err := build.Run(); err != nil {
+		fmt.Fprintf(os.Stderr, "Error building chait: %v\n", err)
+		os.RemoveAll(dir)
+		os.Exit(1)
+	}
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// command returns a chait command using the mock provider, with its configuration and data
+// in a temporary directory. delay is the pause before each streamed word.
+func command(t *testing.T, delay time.Duration, args ...string) *exec.Cmd {
+	t.Helper()
+	home := t.TempDir()
+	config := map[string]interface{}{
+		"provider":           "mock",
+		"save_conversations": false,
+		"providers": map[string]interface{}{
+			"mock": map[string]interface{}{"api_key": "test", "delay_ms": int(delay / time.Millisecond)},
+		},
+	}
+	data, err := json.Marshal(config)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configFile := filepath.Join(home, "config.json")
+	if err := os.WriteFile(configFile, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	cmd := exec.Command(binary, append([]string{"--config", configFile}, args...)...)
+	cmd.Env = append(os.Environ(), "HOME="+home, "XDG_CONFIG_HOME="+home, "XDG_DATA_HOME="+home, "TERM=xterm-256color")
+	return cmd
+}
+
+func TestOneShot(t *testing.T) {
+	cmd := command(t, 0, "hello", "world")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("chait failed: %v", err)
+	}
+	if got := strings.TrimSpace(string(output)); got != "Echo: hello world" {
+		t.Errorf("output = %q, want %q", got, "Echo: hello world")
+	}
+}
+
+func TestPipe(t *testing.T) {
+	cmd := command(t, 0, "summarize")
+	cmd.Stdin = strings.NewReader("lines from a file\n")
+	output, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("chait failed: %v", err)
+	}
+	if !strings.Contains(string(output), "lines from a file") || !strings.Contains(string(output), "summarize") {
+		t.Errorf("output %q does not contain the piped input and the prompt", output)
+	}
+}
+
+// terminal is chait running in interactive mode in a pseudo-terminal
+type terminal struct {
+	t      *testing.T
+	cmd    *exec.Cmd
+	master *os.File
+
+	mu     sync.Mutex
+	output bytes.Buffer
+	exited chan error
+}
+
+// startInteractive starts chait in interactive mode in an 100x30 pseudo-terminal, with
+// additional arguments if any
+func startInteractive(t *testing.T, delay time.Duration, args ...string) *terminal {
+	t.Helper()
+	cmd := command(t, delay, append([]string{"-i"}, args...)...)
+	master, err := startPTY(cmd, 30, 100)
+	if err != nil {
+		t.Fatalf("starting chait: %v", err)
+	}
+	term := &terminal{t: t, cmd: cmd, master: master, exited: make(chan error, 1)}
+	go func() {
+		buf := make([]byte, 4096)
+		for {
+			n, err := master.Read(buf)
+			term.mu.Lock()
+			term.output.Write(buf[:n])
+			term.mu.Unlock()
+			if err != nil {
+				return
+			}
+			term.answerQueries(string(buf[:n]))
+		}
+	}()
+	go func() { term.exited <- cmd.Wait() }()
+	t.Cleanup(func() {
+		cmd.Process.Kill()
+		master.Close()
+	})
+	return term
+}
+
+// Queries sent by chait at startup and the answers of a terminal with a black background,
+// which would otherwise only be given up after a timeout
+var terminalQueries = map[string]string{
+	"\x1b]11;?": "\x1b]11;rgb:0000/0000/0000\x1b\\", // Background color
+	"\x1b[6n":   "\x1b[1;1R",                        // Cursor position
+}
+
+// answerQueries answers the terminal queries found in the output
+func (term *terminal) answerQueries(output string) {
+	for query, answer := range terminalQueries {
+		if strings.Contains(output, query) {
+			term.master.Write([]byte(answer))
+		}
+	}
+}
+
+// raw returns everything written to the terminal, with the escape sequences
+func (term *terminal) raw() string {
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	return term.output.String()
+}
+
+// screen returns everything displayed so far without the escape sequences
+func (term *terminal) screen() string {
+	term.mu.Lock()
+	defer term.mu.Unlock()
+	return ansi.Strip(term.output.String())
+}
+
+// send types keys into the terminal
+func (term *terminal) send(keys string) {
+	term.t.Helper()
+	if _, err := term.master.Write([]byte(keys)); err != nil {
+		term.t.Fatalf("writing to the terminal: %v", err)
+	}
+}
+
+// waitFor waits until the text has been displayed since the given length of the screen
+func (term *terminal) waitFor(text string, since int) {
+	term.t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for time.Now().Before(deadline) {
+		if screen := term.screen(); len(screen) >= since && strings.Contains(screen[since:], text) {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	term.t.Fatalf("%q was not displayed, the screen shows:\n%s", text, term.screen())
+}
+
+// waitExit waits for chait to exit
+func (term *terminal) waitExit() {
+	term.t.Helper()
+	select {
+	case err := <-term.exited:
+		if err != nil {
+			term.t.Errorf("chait exited with %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		term.t.Fatalf("chait did not exit, the screen shows:\n%s", term.screen())
+	}
+}
+
+func TestInteractive(t *testing.T) {
+	term := startInteractive(t, 0)
+	term.waitFor("Ask anything", 0)
+
+	mark := len(term.screen())
+	term.send("hi there" + keyEnter)
+	term.waitFor("Echo: hi there", mark)
+
+	term.send(keyCtrlC)
+	term.waitExit()
+}
+
+func TestEscCancelsResponse(t *testing.T) {
+	term := startInteractive(t, 300*time.Millisecond)
+	term.waitFor("Ask anything", 0)
+
+	term.send("one two three four five six seven eight nine ten" + keyEnter)
+	term.waitFor("Echo:", 0)
+	term.send(keyEsc)
+
+	// The input comes back instead of the rest of the response
+	mark := len(term.screen())
+	term.waitFor("Ask anything", mark)
+	time.Sleep(time.Second)
+	if strings.Contains(term.screen(), "Echo: one two three four five six seven eight nine ten") {
+		t.Errorf("the response kept streaming after Esc:\n%s", term.screen())
+	}
+
+	// Esc with nothing to cancel quits
+	time.Sleep(100 * time.Millisecond)
+	term.send(keyEsc)
+	term.waitExit()
+}
+
+func TestInline(t *testing.T) {
+	term := startInteractive(t, 0, "--inline")
+	term.waitFor("Ask anything", 0)
+
+	mark := len(term.screen())
+	term.send("hi there" + keyEnter)
+	term.waitFor("Echo: hi there", mark)
+
+	term.send(keyCtrlC)
+	term.waitExit()
+	if strings.Contains(term.raw(), "\x1b[?1049h") {
+		t.Errorf("inline mode switched to the alternate screen")
+	}
+}
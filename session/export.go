@@ -0,0 +1,61 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"time"
+)
+
+// exportFormat identifies the files written by Export
+const (
+	exportFormat  = "chait-conversation"
+	exportVersion = 1
+)
+
+// exported is a session with the format and version of the export, so other tools can
+// recognize the file
+type exported struct {
+	Format  string `json:"format"`
+	Version int    `json:"version"`
+	*Session
+}
+
+// Export writes a session as JSON with every message, its system prompt, model and
+// parameters, so it can be imported on another machine or read by other tools
+func Export(s *Session, w io.Writer) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(exported{Format: exportFormat, Version: exportVersion, Session: s}); err != nil {
+		return fmt.Errorf("error encoding session: %v", err)
+	}
+	return nil
+}
+
+// Import reads a session written by Export, or a session file copied from the store
+func Import(r io.Reader) (*Session, error) {
+	e := exported{Session: &Session{}}
+	if err := json.NewDecoder(r).Decode(&e); err != nil {
+		return nil, fmt.Errorf("error parsing conversation: %v", err)
+	}
+	if e.Format != "" && e.Format != exportFormat {
+		return nil, fmt.Errorf("unknown format %q (expected %s)", e.Format, exportFormat)
+	}
+	if e.Version > exportVersion {
+		return nil, fmt.Errorf("conversation exported by a newer version of chait (format version %d)", e.Version)
+	}
+
+	s := e.Session
+	for i, m := range s.Messages {
+		if m.Role == "" {
+			return nil, fmt.Errorf("message %d has no role", i+1)
+		}
+	}
+	if s.Created.IsZero() {
+		s.Created = time.Now()
+	}
+	if s.Updated.IsZero() {
+		s.Updated = s.Created
+	}
+	return s, nil
+}
@@ -0,0 +1,61 @@
+package session
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestImport(t *testing.T) {
+	tests := []struct {
+		name     string
+		input    string
+		wantErr  string
+		wantID   string
+		messages int
+	}{
+		{"export", `{"format": "chait-conversation", "version": 1, "id": "x", "created": "2025-01-01T10:00:00Z", "updated": "2025-01-02T10:00:00Z", "messages": [{"role": "user", "content": "hi"}]}`, "", "x", 1},
+		{"session file", `{"id": "y", "created": "2025-01-01T10:00:00Z", "updated": "2025-01-01T10:00:00Z", "messages": []}`, "", "y", 0},
+		{"unknown format", `{"format": "other", "id": "x"}`, "unknown format", "", 0},
+		{"newer version", `{"format": "chait-conversation", "version": 2, "id": "x"}`, "newer version", "", 0},
+		{"message without role", `{"id": "x", "messages": [{"content": "hi"}]}`, "message 1 has no role", "", 0},
+		{"invalid JSON", `{"id": `, "error parsing conversation", "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, err := Import(strings.NewReader(tt.input))
+			if tt.wantErr != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+					t.Fatalf("Import() error = %v, want %q", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if s.ID != tt.wantID || len(s.Messages) != tt.messages {
+				t.Errorf("Import() = %s with %d messages, want %s with %d", s.ID, len(s.Messages), tt.wantID, tt.messages)
+			}
+		})
+	}
+}
+
+// Imported sessions without dates are dated now, and never updated before being created
+func TestImportDates(t *testing.T) {
+	before := time.Now()
+	s, err := Import(strings.NewReader(`{"id": "x", "messages": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Created.Before(before) || !s.Updated.Equal(s.Created) {
+		t.Errorf("Import() dated %v, updated %v, want now", s.Created, s.Updated)
+	}
+
+	s, err = Import(strings.NewReader(`{"id": "x", "created": "2025-01-01T10:00:00Z", "messages": []}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Updated.Equal(s.Created) {
+		t.Errorf("Import() updated %v, want the creation date %v", s.Updated, s.Created)
+	}
+}
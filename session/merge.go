@@ -0,0 +1,117 @@
+package session
+
+import (
+	"fmt"
+	"time"
+)
+
+// Merge combines two sessions into a new one, with a note before each block marking its origin.
+// Messages are concatenated (a then b) or, with interleave, ordered by the time of each exchange.
+func Merge(a, b *Session, interleave bool) *Session {
+	merged := New()
+	merged.Title = fmt.Sprintf("%s + %s", a.DisplayTitle(), b.DisplayTitle())
+	merged.Provider, merged.Model, merged.Temperature = a.Provider, a.Model, a.Temperature
+
+	// Keep only the first system messages, the second ones would override them mid-conversation
+	if system := systemMessages(a); len(system) > 0 {
+		merged.Messages = append(merged.Messages, system...)
+	} else {
+		merged.Messages = append(merged.Messages, systemMessages(b)...)
+	}
+
+	blocksA, blocksB := exchanges(a), exchanges(b)
+	if !interleave {
+		merged.appendBlocks(a, blocksA)
+		merged.appendBlocks(b, blocksB)
+		return merged
+	}
+
+	// Interleave exchanges chronologically, adding a note whenever the source changes
+	var last *Session
+	for len(blocksA) > 0 || len(blocksB) > 0 {
+		var src *Session
+		var block []Message
+		if len(blocksB) == 0 || (len(blocksA) > 0 && !blockTime(blocksA[0]).After(blockTime(blocksB[0]))) {
+			src, block, blocksA = a, blocksA[0], blocksA[1:]
+		} else {
+			src, block, blocksB = b, blocksB[0], blocksB[1:]
+		}
+		if src != last {
+			merged.Messages = append(merged.Messages, provenanceNote(src))
+			last = src
+		}
+		merged.appendMessages(src, block)
+	}
+	return merged
+}
+
+// appendBlocks appends all exchanges of a source session after a provenance note
+func (s *Session) appendBlocks(src *Session, blocks [][]Message) {
+	if len(blocks) == 0 {
+		return
+	}
+	s.Messages = append(s.Messages, provenanceNote(src))
+	for _, block := range blocks {
+		s.appendMessages(src, block)
+	}
+}
+
+// appendMessages appends messages, recording the session they came from
+func (s *Session) appendMessages(src *Session, messages []Message) {
+	for _, m := range messages {
+		if m.Source == "" {
+			m.Source = src.ID
+		}
+		s.Messages = append(s.Messages, m)
+	}
+}
+
+// provenanceNote returns the note inserted before messages merged from a session
+func provenanceNote(src *Session) Message {
+	return Message{
+		Role:    RoleNote,
+		Content: fmt.Sprintf("Merged from session %s (%s)", src.ID, src.DisplayTitle()),
+		Source:  src.ID,
+	}
+}
+
+// systemMessages returns the system and developer messages of a session, in order
+func systemMessages(s *Session) []Message {
+	var system []Message
+	for _, m := range s.Messages {
+		if isSystemLevel(m.Role) {
+			system = append(system, m)
+		}
+	}
+	return system
+}
+
+// isSystemLevel returns true for the roles of instructions rather than conversation turns
+func isSystemLevel(role string) bool {
+	return role == "system" || role == "developer"
+}
+
+// exchanges splits a session into blocks starting at each user message, excluding system messages
+func exchanges(s *Session) [][]Message {
+	var blocks [][]Message
+	for _, m := range s.Messages {
+		if isSystemLevel(m.Role) {
+			continue
+		}
+		if m.Role == "user" || len(blocks) == 0 {
+			blocks = append(blocks, nil)
+		}
+		blocks[len(blocks)-1] = append(blocks[len(blocks)-1], m)
+	}
+	return blocks
+}
+
+// blockTime returns the time of the first timestamped message of an exchange
+func blockTime(block []Message) time.Time {
+	for _, m := range block {
+		if !m.Time.IsZero() {
+			return m.Time
+		}
+	}
+	return time.Time{}
+}
@@ -0,0 +1,64 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMerge(t *testing.T) {
+	at := func(minute int) time.Time {
+		return time.Date(2025, 1, 1, 10, minute, 0, 0, time.UTC)
+	}
+	a := &Session{ID: "a", Title: "A", Messages: []Message{
+		{Role: "system", Content: "be brief"},
+		{Role: "user", Content: "a1", Time: at(0)},
+		{Role: "assistant", Content: "a1 answer", Time: at(1)},
+		{Role: "user", Content: "a2", Time: at(10)},
+		{Role: "assistant", Content: "a2 answer", Time: at(11)},
+	}}
+	b := &Session{ID: "b", Title: "B", Messages: []Message{
+		{Role: "system", Content: "be verbose"},
+		{Role: "user", Content: "b1", Time: at(5)},
+		{Role: "assistant", Content: "b1 answer", Time: at(6)},
+		{Role: "user", Content: "b2", Time: at(20)},
+	}}
+	untimed := &Session{ID: "c", Messages: []Message{
+		{Role: "user", Content: "c1"},
+		{Role: "assistant", Content: "c1 answer"},
+	}}
+
+	tests := []struct {
+		name       string
+		a, b       *Session
+		interleave bool
+		want       []string // Content of the messages, "@id" for the provenance note of a session
+	}{
+		{"concatenated", a, b, false, []string{"be brief", "@a", "a1", "a1 answer", "a2", "a2 answer", "@b", "b1", "b1 answer", "b2"}},
+		{"interleaved", a, b, true, []string{"be brief", "@a", "a1", "a1 answer", "@b", "b1", "b1 answer", "@a", "a2", "a2 answer", "@b", "b2"}},
+		{"interleaved reversed", b, a, true, []string{"be verbose", "@a", "a1", "a1 answer", "@b", "b1", "b1 answer", "@a", "a2", "a2 answer", "@b", "b2"}},
+		{"system of the second", untimed, a, false, []string{"be brief", "@c", "c1", "c1 answer", "@a", "a1", "a1 answer", "a2", "a2 answer"}},
+		{"untimed first when interleaved", a, untimed, true, []string{"be brief", "@c", "c1", "c1 answer", "@a", "a1", "a1 answer", "a2", "a2 answer"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := Merge(tt.a, tt.b, tt.interleave)
+			var got []string
+			for _, m := range merged.Messages {
+				if m.Role == RoleNote {
+					got = append(got, "@"+m.Source)
+				} else {
+					got = append(got, m.Content)
+				}
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Merge() messages = %q, want %q", got, tt.want)
+			}
+			for _, m := range merged.Messages {
+				if !isSystemLevel(m.Role) && m.Source == "" {
+					t.Errorf("message %q has no source", m.Content)
+				}
+			}
+		})
+	}
+}
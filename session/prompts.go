@@ -0,0 +1,99 @@
+package session
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/plucury/chait/util"
+)
+
+// promptsPath returns the file of the input history, kept on this machine even when
+// conversations are stored in a synced directory
+func promptsPath() string {
+	return filepath.Join(dataDir(), "prompt_history.jsonl")
+}
+
+// LoadPrompts returns the last limit entries of the input history, oldest first. A history
+// grown to twice the limit is trimmed to it.
+func LoadPrompts(limit int) ([]string, error) {
+	data, err := os.ReadFile(promptsPath())
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error reading input history: %v", err)
+	}
+
+	var prompts []string
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	scanner.Buffer(make([]byte, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		var prompt string
+		// Skip the lines left truncated by a crash
+		if json.Unmarshal(scanner.Bytes(), &prompt) == nil {
+			prompts = append(prompts, prompt)
+		}
+	}
+	if len(prompts) > limit {
+		trim := len(prompts) > 2*limit
+		prompts = prompts[len(prompts)-limit:]
+		if trim && util.CheckWriteAllowed("trimming the input history") == nil {
+			if err := writePrompts(prompts); err != nil {
+				util.DebugLog(util.ModuleConfig, "Error trimming the input history: %v", err)
+			}
+		}
+	}
+	return prompts, nil
+}
+
+// AppendPrompt adds an entry to the input history as a single appended line, so that the
+// sessions running at the same time keep each other's entries
+func AppendPrompt(prompt string) error {
+	if err := util.CheckWriteAllowed("saving the input history"); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(dataDir(), 0700); err != nil {
+		return fmt.Errorf("error creating data directory: %v", err)
+	}
+
+	line, err := json.Marshal(prompt)
+	if err != nil {
+		return fmt.Errorf("error encoding input history: %v", err)
+	}
+	file, err := os.OpenFile(promptsPath(), os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		return fmt.Errorf("error writing input history: %v", err)
+	}
+	defer file.Close()
+	if _, err := file.Write(append(line, '\n')); err != nil {
+		return fmt.Errorf("error writing input history: %v", err)
+	}
+	return nil
+}
+
+// writePrompts replaces the input history, one JSON string per line, through a temporary
+// file so that a crash never leaves it truncated
+func writePrompts(prompts []string) error {
+	var buf bytes.Buffer
+	for _, prompt := range prompts {
+		line, err := json.Marshal(prompt)
+		if err != nil {
+			return fmt.Errorf("error encoding input history: %v", err)
+		}
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	tmp := promptsPath() + ".tmp"
+	if err := os.WriteFile(tmp, buf.Bytes(), 0600); err != nil {
+		return fmt.Errorf("error writing input history: %v", err)
+	}
+	if err := os.Rename(tmp, promptsPath()); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing input history: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+package session
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Entries appended by sessions running at the same time are all kept, and the history is
+// trimmed once it grows to twice the limit
+func TestAppendAndLoadPrompts(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+
+	for _, prompt := range []string{"first", "second\nline", "third"} {
+		if err := AppendPrompt(prompt); err != nil {
+			t.Fatal(err)
+		}
+	}
+	got, err := LoadPrompts(10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"first", "second\nline", "third"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prompts = %q, want %q", got, want)
+	}
+
+	// A line truncated by a crash is skipped
+	file, err := os.OpenFile(promptsPath(), os.O_APPEND|os.O_WRONLY, 0600)
+	if err != nil {
+		t.Fatal(err)
+	}
+	file.WriteString(`"trunc` + "\n")
+	file.Close()
+	for _, prompt := range []string{"fourth", "fifth"} {
+		if err := AppendPrompt(prompt); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	got, err = LoadPrompts(2)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"fourth", "fifth"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("prompts = %q, want %q", got, want)
+	}
+	data, err := os.ReadFile(promptsPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if lines := strings.Count(string(data), "\n"); lines != 2 {
+		t.Errorf("history has %d lines after trimming, want 2", lines)
+	}
+}
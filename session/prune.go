@@ -0,0 +1,76 @@
+package session
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+// Retention limits the conversations kept in the store, zero values meaning no limit
+type Retention struct {
+	MaxCount int           // Number of most recently updated conversations kept
+	MaxAge   time.Duration // Conversations not updated for longer are removed
+}
+
+// IsSet returns true if the retention policy limits the stored conversations
+func (r Retention) IsSet() bool {
+	return r.MaxCount > 0 || r.MaxAge > 0
+}
+
+// Expired returns the stored sessions beyond the retention limits at the given time, most
+// recently updated first, except the ones with the given IDs
+func Expired(r Retention, now time.Time, keep ...string) ([]*Session, error) {
+	if !r.IsSet() {
+		return nil, nil
+	}
+	sessions, err := List()
+	if err != nil {
+		return nil, err
+	}
+
+	kept := make(map[string]bool, len(keep))
+	for _, id := range keep {
+		kept[id] = true
+	}
+	var expired []*Session
+	for i, s := range sessions {
+		if kept[s.ID] {
+			continue
+		}
+		if (r.MaxCount > 0 && i >= r.MaxCount) || (r.MaxAge > 0 && now.Sub(s.Updated) > r.MaxAge) {
+			expired = append(expired, s)
+		}
+	}
+	return expired, nil
+}
+
+// Prune deletes the stored sessions beyond the retention limits, except the ones with the
+// given IDs, and returns them
+func Prune(r Retention, keep ...string) ([]*Session, error) {
+	expired, err := Expired(r, time.Now(), keep...)
+	if err != nil {
+		return nil, err
+	}
+	for i, s := range expired {
+		if err := Delete(s.ID); err != nil {
+			return expired[:i], err
+		}
+	}
+	if len(expired) > 0 {
+		util.DebugLog(util.ModuleConfig, "Pruned %d sessions", len(expired))
+	}
+	return expired, nil
+}
+
+// Delete removes a session from the store
+func Delete(id string) error {
+	if err := util.CheckWriteAllowed("deleting conversations"); err != nil {
+		return err
+	}
+	if err := os.Remove(path(id)); err != nil {
+		return fmt.Errorf("error deleting session %s: %v", id, err)
+	}
+	return nil
+}
@@ -0,0 +1,50 @@
+package session
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExpired(t *testing.T) {
+	t.Setenv("XDG_DATA_HOME", t.TempDir())
+	SetDir("")
+
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	for i, id := range []string{"new", "day", "week", "month"} {
+		age := []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}[i]
+		if err := Save(&Session{ID: id, Created: now.Add(-age), Updated: now.Add(-age)}); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	tests := []struct {
+		name      string
+		retention Retention
+		keep      []string
+		want      []string
+	}{
+		{"no limit", Retention{}, nil, nil},
+		{"count", Retention{MaxCount: 2}, nil, []string{"week", "month"}},
+		{"age", Retention{MaxAge: 48 * time.Hour}, nil, []string{"week", "month"}},
+		{"age at the limit", Retention{MaxAge: 24 * time.Hour}, nil, []string{"week", "month"}},
+		{"count and age", Retention{MaxCount: 3, MaxAge: 10 * 24 * time.Hour}, nil, []string{"month"}},
+		{"kept", Retention{MaxCount: 1}, []string{"week"}, []string{"day", "month"}},
+		{"within the limits", Retention{MaxCount: 10, MaxAge: 365 * 24 * time.Hour}, nil, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			expired, err := Expired(tt.retention, now, tt.keep...)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var got []string
+			for _, s := range expired {
+				got = append(got, s.ID)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expired(%+v) = %q, want %q", tt.retention, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,265 @@
+package session
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/plucury/chait/util"
+)
+
+// RoleNote marks provenance notes inside a conversation, they are never sent to providers
+const RoleNote = "note"
+
+// Message is a single message of a saved conversation
+type Message struct {
+	Role    string    `json:"role"`
+	Content string    `json:"content"`
+	Time    time.Time `json:"time,omitempty"`
+	Source  string    `json:"source,omitempty"` // ID of the session the message was merged from
+	Pinned  bool      `json:"pinned,omitempty"` // Always sent as context, see :pin
+}
+
+// Params are the sampling settings a conversation was held with, zero for the provider defaults
+type Params struct {
+	TopP             float64 `json:"top_p,omitempty"`
+	FrequencyPenalty float64 `json:"frequency_penalty,omitempty"`
+	PresencePenalty  float64 `json:"presence_penalty,omitempty"`
+}
+
+// Session is a saved conversation
+type Session struct {
+	ID          string    `json:"id"`
+	Title       string    `json:"title,omitempty"`
+	Provider    string    `json:"provider,omitempty"`
+	Model       string    `json:"model,omitempty"`
+	Temperature float64   `json:"temperature,omitempty"`
+	Params      *Params   `json:"params,omitempty"`
+	Tags        []string  `json:"tags,omitempty"`
+	Notes       string    `json:"notes,omitempty"`
+	Part        int       `json:"part,omitempty"`     // Number of the part of a conversation split by its length
+	Previous    string    `json:"previous,omitempty"` // ID of the archived previous part
+	Next        string    `json:"next,omitempty"`     // ID of the part continuing the conversation
+	Created     time.Time `json:"created"`
+	Updated     time.Time `json:"updated"`
+	Messages    []Message `json:"messages"`
+}
+
+// syncDir is the directory set with SetDir, empty for the default one
+var syncDir string
+
+// SetDir stores conversations in the given directory instead of the default one, typically
+// a git repository or a synced folder shared by several machines. The IDs of the sessions
+// created there end with the name of the machine, so that two machines never write the
+// same file. An empty directory restores the default one.
+func SetDir(dir string) {
+	if strings.HasPrefix(dir, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			dir = filepath.Join(home, dir[2:])
+		}
+	}
+	syncDir = dir
+}
+
+// Dir returns the directory where conversations are stored
+func Dir() string {
+	if syncDir != "" {
+		return syncDir
+	}
+	return filepath.Join(dataDir(), "sessions")
+}
+
+// dataDir returns the directory of the data of chait on this machine
+func dataDir() string {
+	if dataHome := os.Getenv("XDG_DATA_HOME"); dataHome != "" {
+		return filepath.Join(dataHome, "chait")
+	}
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ".chait"
+	}
+	return filepath.Join(home, ".local", "share", "chait")
+}
+
+// path returns the file of the session with the given ID
+func path(id string) string {
+	return filepath.Join(Dir(), id+".json")
+}
+
+// New creates an empty session with a time-based ID, followed by the machine name in a
+// directory set with SetDir, and suffixed if a session with that ID exists
+func New() *Session {
+	now := time.Now()
+	base := now.Format("20060102-150405")
+	if syncDir != "" {
+		base += "-" + machineName()
+	}
+	id := base
+	for n := 2; Exists(id); n++ {
+		id = fmt.Sprintf("%s-%d", base, n)
+	}
+	return &Session{
+		ID:      id,
+		Created: now,
+		Updated: now,
+	}
+}
+
+// Load reads a session by ID, or by title if no session has that ID
+func Load(nameOrID string) (*Session, error) {
+	data, err := os.ReadFile(path(nameOrID))
+	if err == nil {
+		var s Session
+		if err := json.Unmarshal(data, &s); err != nil {
+			return nil, fmt.Errorf("error parsing session %s: %v", nameOrID, err)
+		}
+		return &s, nil
+	}
+	if !os.IsNotExist(err) {
+		return nil, fmt.Errorf("error reading session %s: %v", nameOrID, err)
+	}
+
+	// Fall back to matching by title
+	sessions, listErr := List()
+	if listErr != nil {
+		return nil, listErr
+	}
+	for _, s := range sessions {
+		if s.Title != "" && strings.EqualFold(s.Title, nameOrID) {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("session not found: %s", nameOrID)
+}
+
+// Save writes the session to the store, replacing any previous version
+func Save(s *Session) error {
+	if err := util.CheckWriteAllowed("saving conversations"); err != nil {
+		return err
+	}
+	if s.ID == "" {
+		return fmt.Errorf("session has no ID")
+	}
+
+	if err := os.MkdirAll(Dir(), 0700); err != nil {
+		return fmt.Errorf("error creating session directory: %v", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return fmt.Errorf("error encoding session: %v", err)
+	}
+
+	// Write to a temporary file first so a crash never leaves a truncated session, hidden
+	// from the tools syncing the directory
+	tmp := filepath.Join(Dir(), "."+s.ID+".json.tmp")
+	if err := os.WriteFile(tmp, data, 0600); err != nil {
+		return fmt.Errorf("error writing session: %v", err)
+	}
+	if err := os.Rename(tmp, path(s.ID)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("error writing session: %v", err)
+	}
+
+	util.DebugLog(util.ModuleConfig, "Saved session %s (%d messages)", s.ID, len(s.Messages))
+	return nil
+}
+
+// machineName returns the short host name of the machine, reduced to the characters safe in
+// file names on every system
+func machineName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		return "unknown"
+	}
+	host, _, _ = strings.Cut(strings.ToLower(host), ".")
+	var sb strings.Builder
+	for _, r := range host {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') || r == '-' {
+			sb.WriteRune(r)
+		}
+	}
+	if sb.Len() == 0 {
+		return "unknown"
+	}
+	return sb.String()
+}
+
+// Exists returns true if a session with the given ID is stored
+func Exists(id string) bool {
+	_, err := os.Stat(path(id))
+	return err == nil
+}
+
+// Latest returns the most recently updated session
+func Latest() (*Session, error) {
+	sessions, err := List()
+	if err != nil {
+		return nil, err
+	}
+	if len(sessions) == 0 {
+		return nil, fmt.Errorf("no saved conversations in %s", Dir())
+	}
+	return sessions[0], nil
+}
+
+// List returns all stored sessions, most recently updated first
+func List() ([]*Session, error) {
+	entries, err := os.ReadDir(Dir())
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, fmt.Errorf("error reading session directory: %v", err)
+	}
+
+	var sessions []*Session
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(Dir(), entry.Name()))
+		if err != nil {
+			util.WarnLog(util.ModuleConfig, "Skipping session %s: %v", entry.Name(), err)
+			continue
+		}
+		var s Session
+		if err := json.Unmarshal(data, &s); err != nil {
+			util.WarnLog(util.ModuleConfig, "Skipping session %s: %v", entry.Name(), err)
+			continue
+		}
+		// Copies made by sync tools on conflicting edits, e.g. "<id> (conflicted copy).json",
+		// are listed under their file name so they can be opened or merged
+		if name := strings.TrimSuffix(entry.Name(), ".json"); name != s.ID {
+			util.WarnLog(util.ModuleConfig, "Session file %s holds session %s, listing it as %s", entry.Name(), s.ID, name)
+			s.ID = name
+		}
+		sessions = append(sessions, &s)
+	}
+
+	sort.Slice(sessions, func(i, j int) bool {
+		return sessions[i].Updated.After(sessions[j].Updated)
+	})
+	return sessions, nil
+}
+
+// DisplayTitle returns the title of the session, or the start of its first user message
+func (s *Session) DisplayTitle() string {
+	if s.Title != "" {
+		return s.Title
+	}
+	for _, m := range s.Messages {
+		if m.Role == "user" {
+			title := strings.Join(strings.Fields(m.Content), " ")
+			if runes := []rune(title); len(runes) > 50 {
+				title = string(runes[:50]) + "…"
+			}
+			return title
+		}
+	}
+	return "(empty)"
+}
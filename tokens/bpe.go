@@ -0,0 +1,135 @@
+package tokens
+
+import (
+	"bufio"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"math"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// piecePatterns split text into the pieces BPE merges are applied to, for each encoding.
+// They are the patterns of tiktoken without the `\s+(?!\S)` lookahead, which Go's regexp
+// does not support; splitPieces emulates it.
+var piecePatterns = map[string]*regexp.Regexp{
+	"cl100k_base": regexp.MustCompile(`\A(?:(?i:'s|'t|'re|'ve|'m|'ll|'d)|[^\r\n\p{L}\p{N}]?\p{L}+|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n]*|\s*[\r\n]+|\s+)`),
+	"o200k_base": regexp.MustCompile(`\A(?:[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]*[\p{Ll}\p{Lm}\p{Lo}\p{M}]+(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|[^\r\n\p{L}\p{N}]?[\p{Lu}\p{Lt}\p{Lm}\p{Lo}\p{M}]+[\p{Ll}\p{Lm}\p{Lo}\p{M}]*(?i:'s|'t|'re|'ve|'m|'ll|'d)?` +
+		`|\p{N}{1,3}| ?[^\s\p{L}\p{N}]+[\r\n/]*|\s*[\r\n]+|\s+)`),
+}
+
+// BPE is a byte pair encoding tokenizer compatible with tiktoken encoding files
+type BPE struct {
+	ranks   map[string]int
+	pattern *regexp.Regexp
+}
+
+// LoadBPE loads a tiktoken encoding file, which has one base64 encoded token and its rank
+// per line, splitting text with the pattern of the encoding
+func LoadBPE(path string, pattern *regexp.Regexp) (*BPE, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	return readBPE(file, path, pattern)
+}
+
+// readBPE reads an encoding in the format of tiktoken files, name is used in errors
+func readBPE(r io.Reader, name string, pattern *regexp.Regexp) (*BPE, error) {
+	ranks := make(map[string]int)
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		if len(fields) != 2 {
+			return nil, fmt.Errorf("%s:%d: expected a token and a rank", name, line)
+		}
+		token, err := base64.StdEncoding.DecodeString(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid token: %w", name, line, err)
+		}
+		rank, err := strconv.Atoi(fields[1])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: invalid rank: %w", name, line, err)
+		}
+		ranks[string(token)] = rank
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if len(ranks) == 0 {
+		return nil, fmt.Errorf("%s: no tokens found", name)
+	}
+	return &BPE{ranks: ranks, pattern: pattern}, nil
+}
+
+// Count returns the number of tokens in the text
+func (b *BPE) Count(text string) int {
+	count := 0
+	for _, piece := range splitPieces(b.pattern, text) {
+		count += b.countPiece(piece)
+	}
+	return count
+}
+
+// countPiece applies the BPE merges to a piece, always merging the pair with the lowest rank first
+func (b *BPE) countPiece(piece string) int {
+	if _, ok := b.ranks[piece]; ok {
+		return 1
+	}
+
+	// bounds holds the start offsets of the current parts followed by the end of the piece
+	bounds := make([]int, len(piece)+1)
+	for i := range bounds {
+		bounds[i] = i
+	}
+	for len(bounds) > 2 {
+		best, bestRank := -1, math.MaxInt
+		for i := 0; i+2 < len(bounds); i++ {
+			if rank, ok := b.ranks[piece[bounds[i]:bounds[i+2]]]; ok && rank < bestRank {
+				best, bestRank = i, rank
+			}
+		}
+		if best < 0 {
+			break
+		}
+		bounds = append(bounds[:best+1], bounds[best+2:]...)
+	}
+	return len(bounds) - 1
+}
+
+// splitPieces splits text like tiktoken's pre-tokenizer
+func splitPieces(pattern *regexp.Regexp, text string) []string {
+	var pieces []string
+	for len(text) > 0 {
+		loc := pattern.FindStringIndex(text)
+		if loc == nil || loc[1] == 0 {
+			// Not reachable with the pattern above, but never loop forever
+			_, size := utf8.DecodeRuneInString(text)
+			loc = []int{0, size}
+		}
+		end := loc[1]
+
+		// Emulate `\s+(?!\S)`: a run of whitespace followed by a word leaves its last character to the word
+		if end < len(text) && strings.TrimSpace(text[:end]) == "" {
+			next, _ := utf8.DecodeRuneInString(text[end:])
+			last, size := utf8.DecodeLastRuneInString(text[:end])
+			if !unicode.IsSpace(next) && end > size && last != '\n' && last != '\r' {
+				end -= size
+			}
+		}
+
+		pieces = append(pieces, text[:end])
+		text = text[end:]
+	}
+	return pieces
+}
@@ -0,0 +1,114 @@
+package tokens
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// Pieces produced by tiktoken's pre-tokenizer for each encoding
+func TestSplitPieces(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		want     []string
+	}{
+		{"cl100k_base", "Hello world", []string{"Hello", " world"}},
+		{"cl100k_base", "hello   world", []string{"hello", "  ", " world"}},
+		{"cl100k_base", "I'm 12345", []string{"I", "'m", " ", "123", "45"}},
+		{"cl100k_base", "hello\n\nworld", []string{"hello", "\n\n", "world"}},
+		{"cl100k_base", "a  \n b", []string{"a", "  \n", " b"}},
+		{"cl100k_base", "x  ", []string{"x", "  "}},
+		{"cl100k_base", "$100 foo()", []string{"$", "100", " foo", "()"}},
+		{"cl100k_base", "helloWorld", []string{"helloWorld"}},
+		{"cl100k_base", "Hello World's", []string{"Hello", " World", "'s"}},
+		{"cl100k_base", "你好，世界", []string{"你好", "，世界"}},
+		{"o200k_base", "helloWorld", []string{"hello", "World"}},
+		{"o200k_base", "HTTPServer", []string{"HTTPServer"}},
+		{"o200k_base", "Hello World's", []string{"Hello", " World's"}},
+		{"o200k_base", "I'm 12345", []string{"I'm", " ", "123", "45"}},
+		{"o200k_base", "hello   world", []string{"hello", "  ", " world"}},
+	}
+	for _, tt := range tests {
+		if got := splitPieces(piecePatterns[tt.encoding], tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("%s: splitPieces(%q) = %q, want %q", tt.encoding, tt.text, got, tt.want)
+		}
+	}
+}
+
+// writeEncoding writes a tiktoken encoding file with the given tokens ranked in order
+func writeEncoding(t *testing.T, tokens ...string) string {
+	t.Helper()
+	var lines []string
+	for rank, token := range tokens {
+		lines = append(lines, fmt.Sprintf("%s %d", base64.StdEncoding.EncodeToString([]byte(token)), rank))
+	}
+	path := filepath.Join(t.TempDir(), "test.tiktoken")
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// Merges always apply the pair with the lowest rank first, as tiktoken does
+func TestBPECount(t *testing.T) {
+	tests := []struct {
+		name   string
+		tokens []string
+		text   string
+		want   int
+	}{
+		{"pairs", []string{"ab", "cd"}, "abcd", 2},
+		{"lowest rank first", []string{"bc", "ab"}, "abc", 2},
+		{"merged pair merges again", []string{"bc", "ab", "abc"}, "abc", 1},
+		{"whole piece", []string{"xyz"}, "xyz", 1},
+		{"unknown bytes", []string{"ab"}, "xyz", 3},
+		{"several pieces", []string{"ab", "cd"}, "abcd abcd", 5},
+		{"multibyte characters", []string{"\xe4\xbd", "\xe4\xbd\xa0"}, "你", 1},
+	}
+	for _, tt := range tests {
+		bpe, err := LoadBPE(writeEncoding(t, tt.tokens...), piecePatterns["cl100k_base"])
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		if got := bpe.Count(tt.text); got != tt.want {
+			t.Errorf("%s: Count(%q) = %d, want %d", tt.name, tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestLoadBPEInvalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "invalid.tiktoken")
+	if err := os.WriteFile(path, []byte("YWI= 0\nnot-base64 1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBPE(path, piecePatterns["cl100k_base"]); err == nil || !strings.Contains(err.Error(), ":2:") {
+		t.Errorf("LoadBPE() error = %v, want an error on line 2", err)
+	}
+}
+
+// Token counts of tiktoken for the shipped encodings
+func TestBPEEncodingFiles(t *testing.T) {
+	tests := []struct {
+		encoding string
+		text     string
+		want     int
+	}{
+		{"cl100k_base", "hello world", 2},
+		{"cl100k_base", "tiktoken is great!", 6},
+		{"o200k_base", "hello world", 2},
+	}
+	for _, tt := range tests {
+		bpe, err := loadEncoding(tt.encoding)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := bpe.Count(tt.text); got != tt.want {
+			t.Errorf("%s: Count(%q) = %d, want %d", tt.encoding, tt.text, got, tt.want)
+		}
+	}
+}
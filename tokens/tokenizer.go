@@ -0,0 +1,110 @@
+package tokens
+
+import (
+	"compress/gzip"
+	"embed"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/plucury/chait/api/provider"
+	"github.com/plucury/chait/util"
+	"github.com/spf13/viper"
+)
+
+// Tokenizer counts the tokens a model family uses for a text
+type Tokenizer interface {
+	Count(text string) int
+}
+
+// defaultApproximation is used for models without a specific tokenizer
+var defaultApproximation = approximation{charsPerToken: 4, tokensPerCJK: 1}
+
+// registry of available tokenizers
+var (
+	tokenizersMu sync.Mutex
+	tokenizers   = map[string]Tokenizer{
+		"default":  defaultApproximation,
+		"deepseek": approximation{charsPerToken: 3.3, tokensPerCJK: 0.6}, // Ratios published by Deepseek
+		"claude":   approximation{charsPerToken: 3.5, tokensPerCJK: 1.2},
+		"llama":    approximation{charsPerToken: 3.8, tokensPerCJK: 1},
+	}
+)
+
+// encodings holds the tiktoken files of the OpenAI encodings (MIT licensed, from
+// openaipublic.blob.core.windows.net/encodings), compressed with gzip
+//
+//go:embed encodings/*.tiktoken.gz
+var encodings embed.FS
+
+// BPE encodings shipped with chait, with the approximation used if one cannot be loaded
+var bpeFallbacks = map[string]Tokenizer{
+	"cl100k_base": defaultApproximation,
+	"o200k_base":  defaultApproximation,
+}
+
+// Register adds a tokenizer to the registry, replacing any tokenizer with the same name
+func Register(name string, t Tokenizer) {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+	tokenizers[name] = t
+}
+
+// Get returns the tokenizer with the given name
+// BPE encodings are loaded on first use, from <config dir>/tokenizers/<name>.tiktoken if
+// the file exists or else from the encodings shipped with chait
+func Get(name string) Tokenizer {
+	tokenizersMu.Lock()
+	defer tokenizersMu.Unlock()
+
+	if t, ok := tokenizers[name]; ok {
+		return t
+	}
+
+	fallback, ok := bpeFallbacks[name]
+	if !ok {
+		util.DebugLog(util.ModuleConfig, "Unknown tokenizer %s, using default approximation", name)
+		return defaultApproximation
+	}
+
+	bpe, err := loadEncoding(name)
+	if err != nil {
+		util.DebugLog(util.ModuleConfig, "Could not load %s encoding, using approximation: %v", name, err)
+		tokenizers[name] = fallback
+		return fallback
+	}
+	tokenizers[name] = bpe
+	return bpe
+}
+
+// loadEncoding loads a BPE encoding, preferring a file in the tokenizer directory to the
+// shipped one
+func loadEncoding(name string) (*BPE, error) {
+	path := filepath.Join(TokenizerDir(), name+".tiktoken")
+	if _, err := os.Stat(path); err == nil {
+		util.DebugLog(util.ModuleConfig, "Loading %s encoding from %s", name, path)
+		return LoadBPE(path, piecePatterns[name])
+	}
+
+	file, err := encodings.Open("encodings/" + name + ".tiktoken.gz")
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+	reader, err := gzip.NewReader(file)
+	if err != nil {
+		return nil, err
+	}
+	defer reader.Close()
+	return readBPE(reader, name, piecePatterns[name])
+}
+
+// ForModel returns the tokenizer for a model as selected by the model capability registry
+func ForModel(model string) Tokenizer {
+	return Get(provider.LookupModel(model).Tokenizer)
+}
+
+// TokenizerDir returns the directory of BPE encoding files replacing the shipped ones
+func TokenizerDir() string {
+	return filepath.Join(filepath.Dir(viper.ConfigFileUsed()), "tokenizers")
+}
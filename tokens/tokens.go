@@ -0,0 +1,47 @@
+package tokens
+
+import (
+	"unicode"
+
+	"github.com/plucury/chait/api/provider"
+)
+
+// messageOverhead is the number of tokens added by the chat format for each message
+const messageOverhead = 4
+
+// CountMessages returns the token count of a list of chat messages using the given tokenizer
+func CountMessages(t Tokenizer, messages []provider.ChatMessage) int {
+	total := 0
+	for _, m := range messages {
+		total += messageOverhead + t.Count(m.Content)
+	}
+	return total
+}
+
+// approximation estimates tokens from character counts, treating CJK characters separately
+type approximation struct {
+	charsPerToken float64 // Latin characters per token
+	tokensPerCJK  float64 // Tokens per CJK character
+}
+
+// Count returns the approximate number of tokens in the text
+func (a approximation) Count(text string) int {
+	latin, cjk := 0, 0
+	for _, r := range text {
+		if isCJK(r) {
+			cjk++
+		} else {
+			latin++
+		}
+	}
+	if latin == 0 && cjk == 0 {
+		return 0
+	}
+	return int(float64(latin)/a.charsPerToken + float64(cjk)*a.tokensPerCJK + 0.99)
+}
+
+// isCJK returns true for Chinese, Japanese and Korean characters
+func isCJK(r rune) bool {
+	return unicode.Is(unicode.Han, r) || unicode.Is(unicode.Hiragana, r) ||
+		unicode.Is(unicode.Katakana, r) || unicode.Is(unicode.Hangul, r)
+}
@@ -0,0 +1,24 @@
+package util
+
+import "sync/atomic"
+
+// Answer modes asking for shorter or longer responses than usual
+const (
+	AnswerModeBrief    = "brief"
+	AnswerModeDetailed = "detailed"
+)
+
+// answerMode is the answer mode of the current session, empty for the usual responses
+var answerMode atomic.Value
+
+// AnswerMode returns the answer mode of the current session, empty if none is set
+func AnswerMode() string {
+	mode, _ := answerMode.Load().(string)
+	return mode
+}
+
+// SetAnswerMode sets the answer mode of the current session, empty to go back to the usual responses
+func SetAnswerMode(mode string) {
+	answerMode.Store(mode)
+	DebugLog(ModuleProvider, "Answer mode set to: %q", mode)
+}
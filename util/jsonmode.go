@@ -0,0 +1,113 @@
+package util
+
+import (
+	"encoding/json"
+	"strings"
+	"sync/atomic"
+)
+
+// jsonMode is set when responses should be requested as JSON objects
+var jsonMode atomic.Bool
+
+// IsJSONMode returns true if JSON mode is enabled for the current session
+func IsJSONMode() bool {
+	return jsonMode.Load()
+}
+
+// SetJSONMode enables or disables JSON mode for the current session
+func SetJSONMode(enabled bool) {
+	jsonMode.Store(enabled)
+	DebugLog(ModuleProvider, "JSON mode set to: %v", enabled)
+}
+
+// CompletePartialJSON turns a truncated JSON document into a parseable one by closing
+// open strings, objects and arrays, dropping a trailing value that cannot be completed.
+// It returns false if no parseable prefix could be produced.
+func CompletePartialJSON(s string) (string, bool) {
+	s = strings.TrimSpace(s)
+	for s != "" {
+		completed, cut := closePartialJSON(s)
+		if json.Valid([]byte(completed)) {
+			return completed, true
+		}
+		if cut < 0 || cut >= len(s) {
+			break
+		}
+		// Drop the incomplete value after the last separator and try again
+		s = strings.TrimSpace(s[:cut])
+	}
+	return "", false
+}
+
+// closePartialJSON appends the characters needed to close open strings and containers
+// and returns the offset of the last separator outside a string
+func closePartialJSON(s string) (string, int) {
+	var stack []byte
+	inString, escaped := false, false
+	lastSep := -1
+	escapeStart, hexLeft := 0, 0 // Start and missing digits of a \u escape
+
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if inString {
+			switch {
+			case hexLeft > 0:
+				hexLeft--
+			case escaped:
+				escaped = false
+				if c == 'u' {
+					escapeStart, hexLeft = i-1, 4
+				}
+			case c == '\\':
+				escaped = true
+			case c == '"':
+				inString = false
+			}
+			continue
+		}
+
+		switch c {
+		case '"':
+			inString = true
+		case '{', '[':
+			stack = append(stack, c)
+			lastSep = i + 1 // Keep the opening bracket
+		case '}', ']':
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		case ',':
+			lastSep = i
+		}
+	}
+
+	completed := s
+	if inString {
+		if escaped {
+			// A dangling backslash would escape the closing quote
+			completed = completed[:len(completed)-1]
+		} else if hexLeft > 0 {
+			// Drop a \u escape missing some of its digits
+			completed = completed[:escapeStart]
+		}
+		completed += "\""
+	}
+
+	completed = strings.TrimSpace(completed)
+	if strings.HasSuffix(completed, ",") {
+		completed = strings.TrimSuffix(completed, ",")
+	} else if strings.HasSuffix(completed, ":") {
+		completed += "null"
+	}
+
+	var sb strings.Builder
+	sb.WriteString(completed)
+	for i := len(stack) - 1; i >= 0; i-- {
+		if stack[i] == '{' {
+			sb.WriteByte('}')
+		} else {
+			sb.WriteByte(']')
+		}
+	}
+	return sb.String(), lastSep
+}
@@ -0,0 +1,39 @@
+package util
+
+import "testing"
+
+func TestCompletePartialJSON(t *testing.T) {
+	tests := []struct {
+		name    string
+		partial string
+		want    string
+		ok      bool
+	}{
+		{"complete", `{"a": 1}`, `{"a": 1}`, true},
+		{"truncated string", `{"a": "hel`, `{"a": "hel"}`, true},
+		{"truncated key", `{"a`, `{}`, true},
+		{"escaped quote", `{"a": "say \"hi`, `{"a": "say \"hi"}`, true},
+		{"dangling backslash", `{"a": "x\`, `{"a": "x"}`, true},
+		{"escaped backslash", `{"a": "b\\`, `{"a": "b\\"}`, true},
+		{"truncated unicode escape", `{"a": "caf\u00`, `{"a": "caf"}`, true},
+		{"complete unicode escape", `{"a": "caf\u00e9`, `{"a": "caf\u00e9"}`, true},
+		{"brackets in a string", `{"a": "}{`, `{"a": "}{"}`, true},
+		{"trailing comma", `{"a": 1,`, `{"a": 1}`, true},
+		{"missing value", `{"a":`, `{"a":null}`, true},
+		{"truncated number", `{"a": 1, "b": 1.`, `{"a": 1}`, true},
+		{"nested", `{"a": {"b": [1, 2`, `{"a": {"b": [1, 2]}}`, true},
+		{"truncated literal in nested object", `{"a": [1, 2, {"b": tr`, `{"a": [1, 2, {}]}`, true},
+		{"after a closed array", `{"a": [1, 2], "b": "c`, `{"a": [1, 2], "b": "c"}`, true},
+		{"open array", `[`, `[]`, true},
+		{"empty", ``, ``, false},
+		{"truncated literal", `tru`, ``, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := CompletePartialJSON(tt.partial)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("CompletePartialJSON(%q) = %q, %v, want %q, %v", tt.partial, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
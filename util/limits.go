@@ -0,0 +1,17 @@
+package util
+
+import "sync/atomic"
+
+// maxTokensOverride caps response length for the current session, zero to use the provider setting
+var maxTokensOverride atomic.Int64
+
+// MaxTokensOverride returns the response length cap set for the current session, zero if unset
+func MaxTokensOverride() int {
+	return int(maxTokensOverride.Load())
+}
+
+// SetMaxTokensOverride caps response length for the current session without changing the configuration
+func SetMaxTokensOverride(maxTokens int) {
+	maxTokensOverride.Store(int64(maxTokens))
+	DebugLog(ModuleProvider, "Max tokens override set to: %d", maxTokens)
+}
@@ -0,0 +1,189 @@
+package util
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// LogLevel represents the severity of a log message
+type LogLevel int
+
+const (
+	LevelOff LogLevel = iota
+	LevelError
+	LevelWarn
+	LevelInfo
+	LevelDebug
+	LevelTrace
+)
+
+// Log modules used to filter messages by subsystem
+const (
+	ModuleProvider = "provider"
+	ModuleTUI      = "tui"
+	ModuleConfig   = "config"
+	ModuleCLI      = "cli"
+)
+
+// LogModules lists the modules that can be filtered individually
+var LogModules = []string{ModuleProvider, ModuleTUI, ModuleConfig, ModuleCLI}
+
+var logLevelNames = map[LogLevel]string{
+	LevelOff:   "off",
+	LevelError: "error",
+	LevelWarn:  "warn",
+	LevelInfo:  "info",
+	LevelDebug: "debug",
+	LevelTrace: "trace",
+}
+
+// String returns the name of the log level
+func (l LogLevel) String() string {
+	if name, ok := logLevelNames[l]; ok {
+		return name
+	}
+	return fmt.Sprintf("level(%d)", int(l))
+}
+
+// ParseLogLevel converts a level name like "debug" to a LogLevel
+func ParseLogLevel(name string) (LogLevel, error) {
+	name = strings.ToLower(strings.TrimSpace(name))
+	if name == "warning" {
+		name = "warn"
+	}
+	for level, levelName := range logLevelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return LevelOff, fmt.Errorf("unknown log level %q (expected off, error, warn, info, debug or trace)", name)
+}
+
+// Runtime overrides set via SetLogLevel, taking precedence over the configuration
+var (
+	logMu             sync.Mutex
+	logLevelOverride  *LogLevel
+	logModuleOverride           = map[string]LogLevel{}
+	logOutput         io.Writer = os.Stdout
+)
+
+// defaultLogLevel returns the global level from the configuration
+// The legacy `debug: true` setting maps to the debug level
+func defaultLogLevel() LogLevel {
+	if logLevelOverride != nil {
+		return *logLevelOverride
+	}
+	if name := viper.GetString("log_level"); name != "" {
+		if level, err := ParseLogLevel(name); err == nil {
+			return level
+		}
+	}
+	if viper.GetBool("debug") {
+		return LevelDebug
+	}
+	return LevelError
+}
+
+// GetLogLevel returns the effective log level of a module
+func GetLogLevel(module string) LogLevel {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if level, ok := logModuleOverride[module]; ok {
+		return level
+	}
+	if name, ok := viper.GetStringMapString("log_modules")[module]; ok {
+		if level, err := ParseLogLevel(name); err == nil {
+			return level
+		}
+	}
+	return defaultLogLevel()
+}
+
+// SetLogLevel overrides the log level at runtime, for all modules when module is empty
+func SetLogLevel(module string, level LogLevel) {
+	logMu.Lock()
+	defer logMu.Unlock()
+
+	if module == "" {
+		logLevelOverride = &level
+		// A new global level replaces previous per-module overrides
+		logModuleOverride = map[string]LogLevel{}
+		return
+	}
+	logModuleOverride[module] = level
+}
+
+// SetLogOutput changes where log messages are written and returns the previous output
+func SetLogOutput(w io.Writer) io.Writer {
+	logMu.Lock()
+	defer logMu.Unlock()
+	previous := logOutput
+	logOutput = w
+	return previous
+}
+
+// LogEnabled returns true if messages of the given level are printed for the module
+func LogEnabled(level LogLevel, module string) bool {
+	return level != LevelOff && level <= GetLogLevel(module)
+}
+
+// IsDebugMode returns true if debug logging is enabled for any module
+func IsDebugMode() bool {
+	if LogEnabled(LevelDebug, "") {
+		return true
+	}
+	for _, module := range LogModules {
+		if LogEnabled(LevelDebug, module) {
+			return true
+		}
+	}
+	return false
+}
+
+// Log prints a message if the level is enabled for the module
+func Log(level LogLevel, module, format string, args ...interface{}) {
+	if !LogEnabled(level, module) {
+		return
+	}
+
+	logMu.Lock()
+	defer logMu.Unlock()
+	timestamp := time.Now().Format("2006-01-02 15:04:05")
+	tag := strings.ToUpper(level.String())
+	if module != "" {
+		tag += " " + module
+	}
+	fmt.Fprintf(logOutput, "[%s %s] %s\n", tag, timestamp, fmt.Sprintf(format, args...))
+}
+
+// ErrorLog prints an error message for the module
+func ErrorLog(module, format string, args ...interface{}) {
+	Log(LevelError, module, format, args...)
+}
+
+// WarnLog prints a warning message for the module
+func WarnLog(module, format string, args ...interface{}) {
+	Log(LevelWarn, module, format, args...)
+}
+
+// InfoLog prints an informational message for the module
+func InfoLog(module, format string, args ...interface{}) {
+	Log(LevelInfo, module, format, args...)
+}
+
+// DebugLog prints a debug message for the module
+func DebugLog(module, format string, args ...interface{}) {
+	Log(LevelDebug, module, format, args...)
+}
+
+// TraceLog prints a trace message for the module, for very verbose output like stream chunks
+func TraceLog(module, format string, args ...interface{}) {
+	Log(LevelTrace, module, format, args...)
+}
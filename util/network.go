@@ -0,0 +1,78 @@
+package util
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// GlobalProxy returns the proxy URL configured for all providers, empty if none is set
+func GlobalProxy() string {
+	return viper.GetString("proxy")
+}
+
+// ProxyTransport returns a copy of the default transport sending requests through the proxy,
+// or through the proxy of the environment when it is empty
+func ProxyTransport(proxy string) (*http.Transport, error) {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.Proxy = http.ProxyFromEnvironment
+	if proxy != "" {
+		proxyURL, err := url.Parse(proxy)
+		if err != nil || proxyURL.Host == "" {
+			return nil, fmt.Errorf("invalid proxy URL: %s", proxy)
+		}
+		transport.Proxy = http.ProxyURL(proxyURL)
+	}
+	return transport, nil
+}
+
+// HTTPClient returns an HTTP client for requests other than the provider ones, such as
+// webhooks and shares, going through the configured proxy like the provider requests
+func HTTPClient(timeout time.Duration) (*http.Client, error) {
+	transport, err := ProxyTransport(GlobalProxy())
+	if err != nil {
+		return nil, err
+	}
+	return &http.Client{Transport: transport, Timeout: timeout}, nil
+}
+
+// RetrySettings controls automatic retries of transient request failures
+type RetrySettings struct {
+	MaxAttempts int
+	Backoff     time.Duration
+	MaxBackoff  time.Duration
+	Jitter      float64
+}
+
+// GetRetrySettings returns the retry settings from the configuration, with defaults for unset keys
+func GetRetrySettings() RetrySettings {
+	settings := RetrySettings{
+		MaxAttempts: 3,
+		Backoff:     time.Second,
+		MaxBackoff:  30 * time.Second,
+		Jitter:      0.2,
+	}
+	if viper.IsSet("retry.max_attempts") {
+		settings.MaxAttempts = viper.GetInt("retry.max_attempts")
+	}
+	if viper.IsSet("retry.backoff") {
+		settings.Backoff = time.Duration(viper.GetFloat64("retry.backoff") * float64(time.Second))
+	}
+	if viper.IsSet("retry.max_backoff") {
+		settings.MaxBackoff = time.Duration(viper.GetFloat64("retry.max_backoff") * float64(time.Second))
+	}
+	if viper.IsSet("retry.jitter") {
+		settings.Jitter = viper.GetFloat64("retry.jitter")
+	}
+
+	if settings.MaxAttempts < 1 {
+		settings.MaxAttempts = 1
+	}
+	if settings.Jitter < 0 || settings.Jitter > 1 {
+		settings.Jitter = 0.2
+	}
+	return settings
+}
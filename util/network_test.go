@@ -0,0 +1,45 @@
+package util
+
+import (
+	"net/http"
+	"testing"
+)
+
+func TestProxyTransport(t *testing.T) {
+	tests := []struct {
+		name    string
+		proxy   string
+		want    string
+		wantErr bool
+	}{
+		{"environment", "", "", false},
+		{"http proxy", "http://proxy.local:8080", "http://proxy.local:8080", false},
+		{"socks proxy", "socks5://127.0.0.1:1080", "socks5://127.0.0.1:1080", false},
+		{"missing scheme", "proxy.local:8080", "", true},
+		{"not a URL", "http://%zz", "", true},
+	}
+	req, _ := http.NewRequest(http.MethodGet, "https://example.com", nil)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("HTTPS_PROXY", "")
+			transport, err := ProxyTransport(tt.proxy)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ProxyTransport(%q) error = %v, wantErr %v", tt.proxy, err, tt.wantErr)
+			}
+			if err != nil {
+				return
+			}
+			proxyURL, err := transport.Proxy(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			got := ""
+			if proxyURL != nil {
+				got = proxyURL.String()
+			}
+			if got != tt.want {
+				t.Errorf("ProxyTransport(%q) proxies through %q, want %q", tt.proxy, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,9 @@
+package util
+
+import "github.com/spf13/viper"
+
+// IsMetadataDisabled returns true if optional request metadata (end-user IDs)
+// must not be sent to providers
+func IsMetadataDisabled() bool {
+	return viper.GetBool("disable_metadata")
+}
@@ -0,0 +1,70 @@
+package util
+
+import (
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/spf13/viper"
+)
+
+// ErrSafeMode is returned when an operation is blocked because safe mode is enabled
+var ErrSafeMode = errors.New("disabled in safe mode")
+
+// IsSafeMode returns true if safe mode is enabled via --safe-mode or the configuration
+func IsSafeMode() bool {
+	return viper.GetBool("safe_mode")
+}
+
+// CheckWriteAllowed returns an error describing the blocked action if safe mode is enabled
+func CheckWriteAllowed(action string) error {
+	if IsSafeMode() {
+		DebugLog(ModuleConfig, "Blocked %s in safe mode", action)
+		return fmt.Errorf("%s is %w", action, ErrSafeMode)
+	}
+	return nil
+}
+
+// IsNoSave returns true if settings changed by this invocation must not be written to the
+// configuration, set with --no-save
+func IsNoSave() bool {
+	return viper.GetBool("no_save")
+}
+
+// WriteConfig writes the configuration file unless safe mode is enabled, and silently
+// keeps it unchanged with --no-save
+func WriteConfig() error {
+	if err := CheckWriteAllowed("writing config"); err != nil {
+		return err
+	}
+	if IsNoSave() {
+		DebugLog(ModuleConfig, "Not writing config with --no-save")
+		return nil
+	}
+	if err := viper.WriteConfig(); err != nil {
+		return err
+	}
+
+	// Remember what was written so the config watcher can tell it from external edits
+	if data, err := os.ReadFile(viper.ConfigFileUsed()); err == nil {
+		ownWriteMu.Lock()
+		ownWriteHash = sha256.Sum256(data)
+		ownWriteMu.Unlock()
+	}
+	return nil
+}
+
+// Hash of the config file content written last by WriteConfig
+var (
+	ownWriteMu   sync.Mutex
+	ownWriteHash [sha256.Size]byte
+)
+
+// IsOwnConfigWrite returns true if the config file content was written by WriteConfig
+func IsOwnConfigWrite(data []byte) bool {
+	ownWriteMu.Lock()
+	defer ownWriteMu.Unlock()
+	return sha256.Sum256(data) == ownWriteHash
+}
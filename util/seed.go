@@ -0,0 +1,27 @@
+package util
+
+import "sync"
+
+// seedOverride is the sampling seed for the current session, nil if unset
+var (
+	seedMu       sync.Mutex
+	seedOverride *int64
+)
+
+// Seed returns the sampling seed set for the current session and whether one is set
+func Seed() (int64, bool) {
+	seedMu.Lock()
+	defer seedMu.Unlock()
+	if seedOverride == nil {
+		return 0, false
+	}
+	return *seedOverride, true
+}
+
+// SetSeed sets the sampling seed sent with requests of the current session
+func SetSeed(seed int64) {
+	seedMu.Lock()
+	defer seedMu.Unlock()
+	seedOverride = &seed
+	DebugLog(ModuleProvider, "Seed set to: %d", seed)
+}